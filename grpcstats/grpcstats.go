@@ -0,0 +1,165 @@
+// Package grpcstats serves the samples of one or more monitors over gRPC (see statspb.StatsService).
+package grpcstats
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/galbarnahum/remoteSystemStatsMonitor/stats"
+	"github.com/galbarnahum/remoteSystemStatsMonitor/statspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subscriberBuffer is the number of samples buffered per stream before samples are dropped for it
+const subscriberBuffer = 16
+
+type subscriber struct {
+	hosts   map[string]bool // nil for all hosts
+	samples chan *statspb.Sample
+}
+
+// Server implements statspb.StatsService. Add it to a monitor with AddSink, then expose it with
+// ListenAndServe or register it on an existing *grpc.Server with Register.
+type Server struct {
+	statspb.UnimplementedStatsServiceServer
+
+	mu          sync.Mutex
+	latest      map[string]*statspb.Sample
+	subscribers map[*subscriber]struct{}
+	grpcServer  *grpc.Server
+	closed      bool
+}
+
+var _ stats.Sink = (*Server)(nil)
+
+// NewServer creates a gRPC stats server
+func NewServer() *Server {
+	return &Server{
+		latest:      make(map[string]*statspb.Sample),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Register registers the service on grpcServer
+func (s *Server) Register(grpcServer *grpc.Server) {
+	statspb.RegisterStatsServiceServer(grpcServer, s)
+}
+
+// ListenAndServe serves the service on addr (e.g., ":50051") until Close is called
+func (s *Server) ListenAndServe(addr string, opts ...grpc.ServerOption) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	grpcServer := grpc.NewServer(opts...)
+	s.Register(grpcServer)
+
+	s.mu.Lock()
+	s.grpcServer = grpcServer
+	s.mu.Unlock()
+	return grpcServer.Serve(listener)
+}
+
+// WriteSample stores the sample as the host's current one and pushes it to every matching stream.
+// A stream that falls behind by more than a few samples misses samples rather than slowing the monitor.
+func (s *Server) WriteSample(sample *stats.Sample) error {
+	msg := statspb.FromSample(sample)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest[sample.Host] = msg
+	for sub := range s.subscribers {
+		if sub.hosts != nil && !sub.hosts[sample.Host] {
+			continue
+		}
+		select {
+		case sub.samples <- msg:
+		default:
+		}
+	}
+	return nil
+}
+
+// StreamStats streams every new sample of the requested hosts until the client goes away
+func (s *Server) StreamStats(req *statspb.StreamRequest, stream grpc.ServerStreamingServer[statspb.Sample]) error {
+	sub := &subscriber{samples: make(chan *statspb.Sample, subscriberBuffer)}
+	if len(req.GetHosts()) > 0 {
+		sub.hosts = make(map[string]bool, len(req.GetHosts()))
+		for _, host := range req.GetHosts() {
+			sub.hosts[host] = true
+		}
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return status.Error(codes.Unavailable, "server is closed")
+	}
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg, ok := <-sub.samples:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetCurrent returns the latest sample of the requested host, or of every host sorted by name
+func (s *Server) GetCurrent(_ context.Context, req *statspb.GetCurrentRequest) (*statspb.GetCurrentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if host := req.GetHost(); host != "" {
+		msg, ok := s.latest[host]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "no sample for host %q", host)
+		}
+		return &statspb.GetCurrentResponse{Samples: []*statspb.Sample{msg}}, nil
+	}
+	resp := &statspb.GetCurrentResponse{}
+	for _, msg := range s.latest {
+		resp.Samples = append(resp.Samples, msg)
+	}
+	sort.Slice(resp.Samples, func(i, j int) bool { return resp.Samples[i].GetHost() < resp.Samples[j].GetHost() })
+	return resp, nil
+}
+
+// Close ends every stream and stops the server started by ListenAndServe
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for sub := range s.subscribers {
+		close(sub.samples)
+		delete(s.subscribers, sub)
+	}
+	grpcServer := s.grpcServer
+	s.mu.Unlock()
+
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	return nil
+}
@@ -13,17 +13,16 @@ func main() {
 	// Create SSH client config
 	config := &ssh.ClientConfig{
 		User: "gal",
-		Auth: []ssh.AuthMethod{
-			ssh.Password("gal"),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
 	}
 
 	// Create a logger that writes to stdout
 	logger := log.New(os.Stdout, "", 0)
 
-	// Create the monitor with 1 second interval and 100ms CPU sampling
-	monitor, err := stats.NewRemoteStatsMonitorFromSSHConfig("192.168.205.131:22", config, time.Second, 300*time.Millisecond, logger)
+	// Create the monitor with 1 second interval and 300ms CPU sampling,
+	// authenticating with the keys of the running SSH agent and checking
+	// the server key against ~/.ssh/known_hosts
+	monitor, err := stats.NewRemoteStatsMonitorFromSSHConfig("192.168.205.131:22", config, time.Second, 300*time.Millisecond, logger,
+		stats.WithAgentAuth(), stats.WithKnownHosts(""))
 	if err != nil {
 		log.Fatalf("Failed to create monitor: %v", err)
 	}
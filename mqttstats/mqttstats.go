@@ -0,0 +1,112 @@
+// Package mqttstats publishes remote system stats samples to an MQTT broker.
+// It lives in its own package so users of the stats package do not pull in the MQTT client.
+package mqttstats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/galbarnahum/remoteSystemStatsMonitor/stats"
+)
+
+// DefaultTopic is the topic template used when Config.Topic is empty
+const DefaultTopic = "sysstats/{host}"
+
+// Config configures the MQTT sink
+type Config struct {
+	Broker   string // e.g., "tcp://broker:1883" or "ssl://broker:8883"
+	ClientID string // defaults to a random id chosen by the broker
+	Username string
+	Password string
+	Topic    string        // topic template, "{host}" is replaced by the sample host
+	QoS      byte          // 0, 1 or 2
+	Retained bool          // retain the last sample per topic, so new subscribers get it immediately
+	Timeout  time.Duration // how long to wait for connect and publish acknowledgements, defaults to 10s
+}
+
+// Sink publishes every sample as a JSON message to the topic of its host
+type Sink struct {
+	client  mqtt.Client
+	topic   string
+	qos     byte
+	retain  bool
+	timeout time.Duration
+}
+
+var _ stats.Sink = (*Sink)(nil)
+
+// topicReplacer strips characters that are not allowed in (or change the meaning of) a published topic
+var topicReplacer = strings.NewReplacer("+", "_", "#", "_", "/", "_")
+
+// NewSink connects to the broker. The client reconnects automatically if the connection drops.
+func NewSink(config Config) (*Sink, error) {
+	if config.Broker == "" {
+		return nil, errors.New("mqtt broker is required")
+	}
+	if config.QoS > 2 {
+		return nil, fmt.Errorf("invalid mqtt qos %d", config.QoS)
+	}
+	if config.Topic == "" {
+		config.Topic = DefaultTopic
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectTimeout(config.Timeout)
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(config.Timeout) {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("timed out connecting to mqtt broker %s", config.Broker)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker: %w", err)
+	}
+
+	return &Sink{
+		client:  client,
+		topic:   config.Topic,
+		qos:     config.QoS,
+		retain:  config.Retained,
+		timeout: config.Timeout,
+	}, nil
+}
+
+// Topic returns the topic samples of host are published to
+func (s *Sink) Topic(host string) string {
+	return strings.ReplaceAll(s.topic, "{host}", topicReplacer.Replace(host))
+}
+
+// WriteSample publishes a sample and, for QoS 1 and 2, waits for the broker to acknowledge it
+func (s *Sink) WriteSample(sample *stats.Sample) error {
+	payload, err := json.Marshal(stats.SampleToJSON(sample))
+	if err != nil {
+		return fmt.Errorf("failed to marshal sample: %w", err)
+	}
+	token := s.client.Publish(s.Topic(sample.Host), s.qos, s.retain, payload)
+	if !token.WaitTimeout(s.timeout) {
+		return fmt.Errorf("timed out publishing to %s", s.Topic(sample.Host))
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to publish sample: %w", err)
+	}
+	return nil
+}
+
+// Close disconnects from the broker, waiting briefly for in-flight messages
+func (s *Sink) Close() error {
+	s.client.Disconnect(250)
+	return nil
+}
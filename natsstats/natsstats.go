@@ -0,0 +1,119 @@
+// Package natsstats publishes remote system stats samples to NATS.
+// It lives in its own package so users of the stats package do not pull in the NATS client.
+package natsstats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/galbarnahum/remoteSystemStatsMonitor/stats"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultSubject is the subject template used when Config.Subject is empty
+const DefaultSubject = "sysstats.{host}"
+
+// Config configures the NATS sink
+type Config struct {
+	URL           string // e.g., "nats://localhost:4222", a comma separated list for a cluster
+	Name          string // connection name shown in the server monitoring
+	Token         string
+	User          string
+	Password      string
+	Subject       string        // subject template, "{host}" is replaced by the sample host
+	MaxReconnects int           // 0 means reconnect forever
+	ReconnectWait time.Duration // defaults to the client default (2s)
+	// OnDisconnect and OnReconnect are called when the connection drops and is re-established.
+	// While disconnected, published samples are buffered by the client up to its reconnect buffer size.
+	OnDisconnect func(err error)
+	OnReconnect  func()
+}
+
+// Sink publishes every sample as a JSON message to the subject of its host
+type Sink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+var _ stats.Sink = (*Sink)(nil)
+
+// subjectReplacer strips characters that would split the host into several subject tokens or act as wildcards
+var subjectReplacer = strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_")
+
+// NewSink connects to NATS
+func NewSink(config Config) (*Sink, error) {
+	if config.URL == "" {
+		return nil, errors.New("nats url is required")
+	}
+	if config.Subject == "" {
+		config.Subject = DefaultSubject
+	}
+	maxReconnects := config.MaxReconnects
+	if maxReconnects == 0 {
+		maxReconnects = -1
+	}
+
+	opts := []nats.Option{
+		nats.MaxReconnects(maxReconnects),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if config.OnDisconnect != nil {
+				config.OnDisconnect(err)
+			}
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			if config.OnReconnect != nil {
+				config.OnReconnect()
+			}
+		}),
+	}
+	if config.Name != "" {
+		opts = append(opts, nats.Name(config.Name))
+	}
+	if config.Token != "" {
+		opts = append(opts, nats.Token(config.Token))
+	}
+	if config.User != "" {
+		opts = append(opts, nats.UserInfo(config.User, config.Password))
+	}
+	if config.ReconnectWait > 0 {
+		opts = append(opts, nats.ReconnectWait(config.ReconnectWait))
+	}
+
+	conn, err := nats.Connect(config.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	return &Sink{conn: conn, subject: config.Subject}, nil
+}
+
+// Subject returns the subject samples of host are published to
+func (s *Sink) Subject(host string) string {
+	return strings.ReplaceAll(s.subject, "{host}", subjectReplacer.Replace(host))
+}
+
+// IsConnected returns whether the connection to NATS is currently up
+func (s *Sink) IsConnected() bool {
+	return s.conn.IsConnected()
+}
+
+// WriteSample publishes a sample. While reconnecting the sample is buffered by the client,
+// an error is only returned once that buffer is full or the connection is closed.
+func (s *Sink) WriteSample(sample *stats.Sample) error {
+	payload, err := json.Marshal(stats.SampleToJSON(sample))
+	if err != nil {
+		return fmt.Errorf("failed to marshal sample: %w", err)
+	}
+	if err := s.conn.Publish(s.Subject(sample.Host), payload); err != nil {
+		return fmt.Errorf("failed to publish sample: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the connection
+func (s *Sink) Close() error {
+	return s.conn.Drain()
+}
@@ -0,0 +1,170 @@
+// Package otelstats exports remote system stats samples as OpenTelemetry gauges over OTLP.
+// It lives in its own package so users of the stats package do not pull in the OpenTelemetry SDK.
+package otelstats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/galbarnahum/remoteSystemStatsMonitor/stats"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// Protocol selects the OTLP transport
+type Protocol string
+
+const (
+	ProtocolGRPC Protocol = "grpc"
+	ProtocolHTTP Protocol = "http"
+)
+
+// Config configures the OTLP export
+type Config struct {
+	Protocol       Protocol          // defaults to ProtocolGRPC
+	Endpoint       string            // host:port of the collector, e.g., "localhost:4317"
+	Insecure       bool              // disable TLS
+	Headers        map[string]string // e.g., authentication headers
+	ExportInterval time.Duration     // defaults to the SDK default (60s)
+	ServiceName    string            // service.name resource attribute, defaults to "remote-stats-monitor"
+}
+
+const meterName = "github.com/galbarnahum/remoteSystemStatsMonitor/otelstats"
+
+// hostMeter holds the meter provider of a single remote host, whose resource identifies that host
+type hostMeter struct {
+	provider *sdkmetric.MeterProvider
+	meter    metric.Meter
+	gauges   map[string]metric.Float64Gauge
+}
+
+// Sink converts every sample into OpenTelemetry gauges and exports them via OTLP.
+// Each remote host gets its own resource (host.name) so backends attribute the metrics to it.
+type Sink struct {
+	config Config
+	mu     sync.Mutex
+	hosts  map[string]*hostMeter
+}
+
+var _ stats.Sink = (*Sink)(nil)
+
+// NewSink creates an OTLP sink; the connection to the collector is made lazily per host
+func NewSink(config Config) (*Sink, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("otlp endpoint is required")
+	}
+	if config.Protocol == "" {
+		config.Protocol = ProtocolGRPC
+	}
+	if config.Protocol != ProtocolGRPC && config.Protocol != ProtocolHTTP {
+		return nil, fmt.Errorf("unsupported otlp protocol %q", config.Protocol)
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "remote-stats-monitor"
+	}
+	return &Sink{
+		config: config,
+		hosts:  make(map[string]*hostMeter),
+	}, nil
+}
+
+func (s *Sink) newExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	if s.config.Protocol == ProtocolHTTP {
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(s.config.Endpoint)}
+		if s.config.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(s.config.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(s.config.Headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(s.config.Endpoint)}
+	if s.config.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	if len(s.config.Headers) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(s.config.Headers))
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+func (s *Sink) hostMeter(host string) (*hostMeter, error) {
+	if hm, ok := s.hosts[host]; ok {
+		return hm, nil
+	}
+	exporter, err := s.newExporter(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", s.config.ServiceName),
+		attribute.String("host.name", host),
+	)
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if s.config.ExportInterval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(s.config.ExportInterval))
+	}
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
+	)
+	hm := &hostMeter{
+		provider: provider,
+		meter:    provider.Meter(meterName),
+		gauges:   make(map[string]metric.Float64Gauge),
+	}
+	s.hosts[host] = hm
+	return hm, nil
+}
+
+// WriteSample records every metric of sample as the current value of its gauge
+func (s *Sink) WriteSample(sample *stats.Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hm, err := s.hostMeter(sample.Host)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	for _, point := range stats.SampleToMetrics(sample) {
+		gauge, ok := hm.gauges[point.Name]
+		if !ok {
+			if gauge, err = hm.meter.Float64Gauge("system." + point.Name); err != nil {
+				return fmt.Errorf("failed to create gauge %s: %w", point.Name, err)
+			}
+			hm.gauges[point.Name] = gauge
+		}
+		attrs := make([]attribute.KeyValue, 0, len(point.Labels))
+		for k, v := range point.Labels {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+		gauge.Record(ctx, point.Value, metric.WithAttributes(attrs...))
+	}
+	return nil
+}
+
+// Close flushes the pending metrics of every host and shuts the exporters down
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var errs []error
+	for host, hm := range s.hosts {
+		if err := hm.provider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("host %s: %w", host, err))
+		}
+		delete(s.hosts, host)
+	}
+	return errors.Join(errs...)
+}
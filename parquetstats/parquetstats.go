@@ -0,0 +1,147 @@
+// Package parquetstats writes remote system stats samples to Parquet files for long captures.
+// It lives in its own package so users of the stats package do not pull in the Parquet library.
+package parquetstats
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/galbarnahum/remoteSystemStatsMonitor/stats"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Row is the typed Parquet schema of a single sample
+type Row struct {
+	Host               string            `parquet:"host,dict"`
+	Timestamp          time.Time         `parquet:"timestamp,timestamp(millisecond)"`
+	TotalMemoryMB      float64           `parquet:"total_memory_mb"`
+	UsedMemoryMB       float64           `parquet:"used_memory_mb"`
+	UsedMemoryPercent  float64           `parquet:"used_memory_percent"`
+	SwapTotalMB        float64           `parquet:"swap_total_mb"`
+	SwapUsedMB         float64           `parquet:"swap_used_mb"`
+	TotalCPUPercentage float64           `parquet:"total_cpu_percentage"`
+	Load1              float64           `parquet:"load1"`
+	Load5              float64           `parquet:"load5"`
+	Load15             float64           `parquet:"load15"`
+	CPUPercentages     []float64         `parquet:"cpu_percentages,list"` // indexed by core number
+	Labels             map[string]string `parquet:"labels"`
+}
+
+// NewRow converts a sample to its Parquet row
+func NewRow(sample *stats.Sample) Row {
+	s := sample.Stats
+	row := Row{
+		Host:               sample.Host,
+		Timestamp:          sample.Timestamp,
+		TotalMemoryMB:      s.TotalMemoryMB,
+		UsedMemoryMB:       s.UsedMemoryMB,
+		UsedMemoryPercent:  s.UsedMemoryPercent,
+		SwapTotalMB:        s.SwapTotalMB,
+		SwapUsedMB:         s.SwapUsedMB,
+		TotalCPUPercentage: s.TotalCPUPercentage,
+		Load1:              s.LoadAvg.Load1,
+		Load5:              s.LoadAvg.Load5,
+		Load15:             s.LoadAvg.Load15,
+		Labels:             sample.Labels,
+	}
+	for _, cpu := range s.CPUStats {
+		idx, err := strconv.Atoi(strings.TrimPrefix(cpu.Core, "cpu"))
+		if err != nil || idx < 0 {
+			continue
+		}
+		for len(row.CPUPercentages) <= idx {
+			row.CPUPercentages = append(row.CPUPercentages, 0)
+		}
+		row.CPUPercentages[idx] = cpu.UsagePct
+	}
+	return row
+}
+
+const (
+	defaultFlushRows     = 3600
+	defaultFlushInterval = 5 * time.Minute
+)
+
+// Sink writes samples to a Parquet file. Rows are buffered and flushed as a row group once
+// FlushRows rows are buffered or FlushInterval has passed since the last flush, whichever comes first.
+// The file is only readable once the sink is closed, since Close writes the Parquet footer.
+type Sink struct {
+	mu            sync.Mutex
+	file          *os.File
+	writer        *parquet.GenericWriter[Row]
+	flushRows     int
+	flushInterval time.Duration
+	buffered      int
+	lastFlush     time.Time
+}
+
+var _ stats.Sink = (*Sink)(nil)
+
+// NewSink creates (or truncates) the Parquet file at path
+func NewSink(path string) (*Sink, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet file: %w", err)
+	}
+	return &Sink{
+		file:          file,
+		writer:        parquet.NewGenericWriter[Row](file, parquet.Compression(&parquet.Zstd)),
+		flushRows:     defaultFlushRows,
+		flushInterval: defaultFlushInterval,
+		lastFlush:     time.Now(),
+	}, nil
+}
+
+// SetFlushRows sets the number of buffered rows that triggers a row group flush
+func (s *Sink) SetFlushRows(rows int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushRows = rows
+}
+
+// SetFlushInterval sets the maximum time between row group flushes
+func (s *Sink) SetFlushInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushInterval = interval
+}
+
+// WriteSample appends a sample to the current row group, flushing it when due
+func (s *Sink) WriteSample(sample *stats.Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.writer.Write([]Row{NewRow(sample)}); err != nil {
+		return fmt.Errorf("failed to write parquet row: %w", err)
+	}
+	s.buffered++
+	if (s.flushRows > 0 && s.buffered >= s.flushRows) || (s.flushInterval > 0 && time.Since(s.lastFlush) >= s.flushInterval) {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *Sink) flush() error {
+	if err := s.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush parquet row group: %w", err)
+	}
+	s.buffered = 0
+	s.lastFlush = time.Now()
+	return nil
+}
+
+// Close flushes the remaining rows, writes the file footer and closes the file
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.Close(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+	return s.file.Close()
+}
@@ -2,9 +2,10 @@ package stats
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"sync"
 	"time"
@@ -15,18 +16,46 @@ import (
 
 // RemoteStatsMonitor monitors remote system stats at regular intervals
 type RemoteStatsMonitor struct {
-	collector   *remoteStatsCollector
-	interval    time.Duration
-	sampleDelta time.Duration // CPU sampling interval
-	logger      *log.Logger
-	logLineFunc func(*SystemStats) ([]byte, error)
-	ctx         context.Context
-	cancel      context.CancelFunc
-	wg          sync.WaitGroup
-	ctxMu       sync.Mutex // Protects context recreation
+	collector         *remoteStatsCollector // holds the optional metric group settings
+	source            StatsCollector        // collects the samples; the collector itself when nil
+	settingsMu        sync.Mutex            // Protects interval and sampleDelta, changed while the monitor runs
+	interval          time.Duration
+	sampleDelta       time.Duration // CPU sampling interval
+	logger            *log.Logger
+	slogger           *slog.Logger
+	sink              Sink // replaces the logger as the sample output when set
+	sinks             *FanOutSink
+	poolEvents        EventSink  // the pool's sinks when collected by a MonitorPool, see hostEventSink
+	healthMu          sync.Mutex // Protects health and latest, read from other goroutines
+	health            MonitorHealth
+	latest            *Sample
+	conn              ConnectionState
+	disconnected      bool // set by a failed keepalive or reconnect, cleared once the host answers
+	keepaliveInterval time.Duration
+	keepaliveTimeout  time.Duration
+	phaseOffset       time.Duration      // delay of the first collection, see SetPhase
+	phaseJitter       time.Duration      // random extra delay of every collection
+	overrunPolicy     OverrunPolicy      // ticks missed by a slow collection, see SetOverrunPolicy
+	cycleTimeout      time.Duration      // deadline of a single collection, 0 for none
+	abandoned         <-chan error       // result of a collection abandoned at its deadline, nil once it ended
+	breaker           circuitBreaker     // see SetCircuitBreaker
+	staleAfter        time.Duration      // age of a written sample flagged as stale, see SetStaleAfter
+	timestamps        timestampFormatter // format of the event timestamps
+	logLineFunc       func(*SystemStats) ([]byte, error)
+	alertOnNetErrors  bool // report increased interface errors/drops through the error path
+	host              string
+	labels            map[string]string
+	sampleHandlers    []func(*Sample) error
+	collectors        *CollectorRegistry // custom collectors merged into every sample, nil if none
+	loggedInfo        *SystemInfo        // last system info reported in a "system_info" event
+	ctx               context.Context
+	cancel            context.CancelFunc
+	wg                sync.WaitGroup
+	ctxMu             sync.Mutex // Protects context recreation
 }
 
 // NewRemoteStatsMonitorFromSFTP creates a new monitor from an existing SFTP client
+// logger may be nil if samples are written to a Sink instead (see SetSink)
 func NewRemoteStatsMonitorFromSFTP(sftpClient *sftp.Client, interval time.Duration, sampleDelta time.Duration, logger *log.Logger) *RemoteStatsMonitor {
 	collector := NewRemoteStatsCollectorFromSFTP(sftpClient, sampleDelta)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -42,17 +71,11 @@ func NewRemoteStatsMonitorFromSFTP(sftpClient *sftp.Client, interval time.Durati
 }
 
 func jsonLogLine(stats *SystemStats) ([]byte, error) {
-	data := SystemStatsToJSON(stats)
-	data["timestamp"] = time.Now().Format("15:04:05.000000")
-	//bytes, err := json.MarshalIndent(data, "", "  ")
-	bytes, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-	return bytes, nil
+	return formatJSONLogLine(stats, defaultTimestampFormatter)
 }
 
 // NewRemoteStatsMonitorFromSSH creates a new monitor from an existing SSH client
+// logger may be nil if samples are written to a Sink instead (see SetSink)
 func NewRemoteStatsMonitorFromSSH(sshClient *ssh.Client, interval time.Duration, sampleDelta time.Duration, logger *log.Logger) (*RemoteStatsMonitor, error) {
 	collector, err := NewRemoteStatsCollectorFromSSH(sshClient, sampleDelta)
 	if err != nil {
@@ -62,6 +85,7 @@ func NewRemoteStatsMonitorFromSSH(sshClient *ssh.Client, interval time.Duration,
 	ctx, cancel := context.WithCancel(context.Background())
 	return &RemoteStatsMonitor{
 		collector:   collector,
+		host:        sshClient.RemoteAddr().String(),
 		interval:    interval,
 		sampleDelta: sampleDelta,
 		logger:      logger,
@@ -72,8 +96,42 @@ func NewRemoteStatsMonitorFromSSH(sshClient *ssh.Client, interval time.Duration,
 }
 
 // NewRemoteStatsMonitorFromSSHConfig creates a new monitor from SSH configuration
-func NewRemoteStatsMonitorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, interval time.Duration, sampleDelta time.Duration, logger *log.Logger) (*RemoteStatsMonitor, error) {
-	collector, err := NewRemoteStatsCollectorFromSSHConfig(serverAddress, config, sampleDelta)
+// logger may be nil if samples are written to a Sink instead (see SetSink)
+// opts adjust the connection, e.g., WithAgentAuth()
+func NewRemoteStatsMonitorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, interval time.Duration, sampleDelta time.Duration, logger *log.Logger, opts ...SSHOption) (*RemoteStatsMonitor, error) {
+	collector, err := NewRemoteStatsCollectorFromSSHConfig(serverAddress, config, sampleDelta, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collector: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RemoteStatsMonitor{
+		collector:   collector,
+		host:        serverAddress,
+		interval:    interval,
+		sampleDelta: sampleDelta,
+		logger:      logger,
+		logLineFunc: jsonLogLine, // Default log line function
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// NewRemoteStatsMonitorViaBastion creates a new monitor for targetAddr, reached through the SSH
+// server at bastionAddr. The connection is re-established through the bastion when it drops.
+// logger may be nil if samples are written to a Sink instead (see SetSink)
+func NewRemoteStatsMonitorViaBastion(bastionAddr string, bastionConfig *ssh.ClientConfig, targetAddr string, targetConfig *ssh.ClientConfig, interval time.Duration, sampleDelta time.Duration, logger *log.Logger) (*RemoteStatsMonitor, error) {
+	return NewRemoteStatsMonitorViaJumpHosts([]SSHHop{{Address: bastionAddr, Config: bastionConfig}}, targetAddr, targetConfig, interval, sampleDelta, logger)
+}
+
+// NewRemoteStatsMonitorViaJumpHosts creates a new monitor for targetAddr, reached through
+// jumpHosts in order (the first one is dialed directly)
+// logger may be nil if samples are written to a Sink instead (see SetSink)
+func NewRemoteStatsMonitorViaJumpHosts(jumpHosts []SSHHop, targetAddr string, targetConfig *ssh.ClientConfig, interval time.Duration, sampleDelta time.Duration, logger *log.Logger) (*RemoteStatsMonitor, error) {
+	hops := append(append([]SSHHop{}, jumpHosts...), SSHHop{Address: targetAddr, Config: targetConfig})
+	collector, err := newRemoteStatsCollectorFromDial(func() (*ssh.Client, error) {
+		return DialSSHChain(hops...)
+	}, sampleDelta)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create collector: %w", err)
 	}
@@ -81,6 +139,7 @@ func NewRemoteStatsMonitorFromSSHConfig(serverAddress string, config *ssh.Client
 	ctx, cancel := context.WithCancel(context.Background())
 	return &RemoteStatsMonitor{
 		collector:   collector,
+		host:        targetAddr,
 		interval:    interval,
 		sampleDelta: sampleDelta,
 		logger:      logger,
@@ -90,6 +149,47 @@ func NewRemoteStatsMonitorFromSSHConfig(serverAddress string, config *ssh.Client
 	}, nil
 }
 
+// NewStatsMonitor creates a new monitor collecting from collector, e.g., a LocalStatsCollector or
+// a StatsCollector of another kind of system. The optional metric groups of the monitor only apply
+// to the /proc based collectors of this package.
+// logger may be nil if samples are written to a Sink instead (see SetSink)
+func NewStatsMonitor(collector StatsCollector, interval time.Duration, sampleDelta time.Duration, logger *log.Logger) *RemoteStatsMonitor {
+	settings := NewRemoteStatsCollectorFromSFTP(nil, sampleDelta) // placeholder without a connection
+	if linux, ok := collector.(linuxCollector); ok {
+		settings = linux.linux()
+	}
+	collector.SetSampleDelta(sampleDelta)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RemoteStatsMonitor{
+		collector:   settings,
+		source:      collector,
+		interval:    interval,
+		sampleDelta: sampleDelta,
+		logger:      logger,
+		logLineFunc: jsonLogLine, // Default log line function
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// NewLocalStatsMonitor creates a new monitor for the machine the program runs on, named after its hostname
+// logger may be nil if samples are written to a Sink instead (see SetSink)
+func NewLocalStatsMonitor(interval time.Duration, sampleDelta time.Duration, logger *log.Logger) *RemoteStatsMonitor {
+	m := NewStatsMonitor(NewLocalStatsCollector(sampleDelta), interval, sampleDelta, logger)
+	if hostname, err := os.Hostname(); err == nil {
+		m.host = hostname
+	}
+	return m
+}
+
+// dropConnection closes the connection of the collector, if it has one, to end reads hung on it
+func (m *RemoteStatsMonitor) dropConnection() {
+	if p, ok := m.statsSource().(pinger); ok {
+		p.dropConnection()
+	}
+}
+
 // IsRunning returns whether the monitor is currently running
 func (m *RemoteStatsMonitor) IsRunning() bool {
 	m.ctxMu.Lock()
@@ -116,49 +216,189 @@ func (m *RemoteStatsMonitor) ensureFreshContext() {
 	}
 }
 
-// collectAndLog collects stats and logs them using the configured logLine function
-func (m *RemoteStatsMonitor) collectAndLog() error {
-	stats, err := m.collector.GetSystemStats()
-	if err != nil {
+// collectAndLog collects stats and logs them using the configured logLine function.
+// The collection gives up when ctx is done.
+func (m *RemoteStatsMonitor) collectAndLog(ctx context.Context) error {
+	stats, err := m.statsSource().GetSystemStats(ctx)
+	// A partial sample is written like a complete one, listing the failed groups in its errors
+	partialErr := err
+	if err != nil && !isPartial(stats, err) {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// Stopped while collecting, not a failure of the host; a missed deadline is one
+			return fmt.Errorf("collection interrupted: %w", err)
+		}
+		m.recordFailure(err)
+		// If the connection is gone (e.g., the host rebooted), try to re-establish it
+		// so the next cycle can collect again
+		if rc, ok := m.statsSource().(reconnector); ok && rc.CanReconnect() && !rc.IsConnected() {
+			if reconnectErr := rc.Reconnect(); reconnectErr == nil {
+				m.recordReconnect()
+				m.logEvent("reconnected", nil)
+			} else {
+				m.recordDisconnected(reconnectErr)
+			}
+		}
 		return fmt.Errorf("failed to collect stats: %w", err)
 	}
 
-	// Use the configured logLine function to format the stats
-	logData, err := m.logLineFunc(stats)
-	if err != nil {
-		return fmt.Errorf("failed to format log line: %w", err)
+	if stats.Reboot != nil {
+		m.logEvent("reboot", map[string]any{
+			"previous_uptime_seconds": stats.Reboot.PreviousUptimeSeconds,
+			"uptime_seconds":          stats.Reboot.UptimeSeconds,
+		})
 	}
 
-	// Log the formatted data
-	m.logger.Printf("%s", string(logData))
+	m.logSystemInfo(stats)
 
-	return nil
+	if len(m.labels) > 0 {
+		stats.Labels = m.labels
+	}
+	m.collectCustom(ctx, stats)
+	sample := &Sample{Host: m.host, Timestamp: time.Now(), Stats: stats, Labels: stats.Labels, StaleAfter: m.staleAge()}
+	m.recordSample(sample)
+	if partialErr != nil {
+		m.recordPartial(partialErr)
+	}
+
+	if err := m.output().WriteSample(sample); err != nil {
+		return err
+	}
+	m.logSample(sample)
+	if m.sinks != nil {
+		m.sinks.WriteSample(sample)
+	}
+
+	for _, handler := range m.sampleHandlers {
+		if err := handler(sample); err != nil {
+			return fmt.Errorf("sample handler failed: %w", err)
+		}
+	}
+
+	if m.alertOnNetErrors {
+		if err := netErrorsError(stats.NetInterfaces); err != nil {
+			return err
+		}
+	}
+	return partialErr
+}
+
+// collectCycle runs collectAndLog within the cycle timeout. A collection missing it is abandoned with
+// a "collection_timeout" event, and its connection is dropped so that the reads it is stuck in end;
+// until it returns, the following cycles are skipped.
+func (m *RemoteStatsMonitor) collectCycle(ctx context.Context) error {
+	timeout := m.cycleTimeout
+	if timeout <= 0 {
+		return m.collectAndLog(ctx)
+	}
+	if m.abandoned != nil {
+		select {
+		case <-m.abandoned:
+			m.abandoned = nil
+		default:
+			return fmt.Errorf("abandoned collection is still running")
+		}
+	}
+
+	m.wg.Add(1)
+	abandoned, err := m.collectWithTimeout(ctx, timeout, m.wg.Done)
+	m.abandoned = abandoned
+	return err
+}
+
+// collectWithTimeout runs collectAndLog with a deadline of timeout (none if 0) and calls finished
+// once it returns. A missed deadline is reported through a "collection_timeout" event; a collection
+// still running then is abandoned, its connection dropped, and the returned channel receives its
+// result once it returns (nil if the collection was not abandoned).
+func (m *RemoteStatsMonitor) collectWithTimeout(ctx context.Context, timeout time.Duration, finished func()) (<-chan error, error) {
+	if timeout <= 0 {
+		err := m.collectAndLog(ctx)
+		finished()
+		return nil, err
+	}
+
+	cycleCtx, cancel := context.WithTimeout(ctx, timeout)
+	done := make(chan error, 1)
+	go func() {
+		defer cancel()
+		err := m.collectAndLog(cycleCtx)
+		finished()
+		done <- err
+	}()
+
+	var abandoned <-chan error
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		// The reads gave up at the deadline on their own
+		if err == nil || ctx.Err() != nil || !errors.Is(cycleCtx.Err(), context.DeadlineExceeded) {
+			return nil, err
+		}
+	case <-timer.C:
+		m.dropConnection()
+		abandoned = done
+	}
+	m.logEvent("collection_timeout", map[string]any{"timeout_ms": timeout.Milliseconds()})
+	return abandoned, fmt.Errorf("collection did not finish within %v", timeout)
 }
 
 // StartSync starts monitoring synchronously (blocking call)
 func (m *RemoteStatsMonitor) StartSync() error {
 	// Ensure we have a fresh context if the previous one was cancelled
 	m.ensureFreshContext()
+	m.ctxMu.Lock()
+	ctx := m.ctx
+	m.ctxMu.Unlock()
 
 	m.wg.Add(1)
 	defer m.wg.Done()
 
-	ticker := time.NewTicker(m.interval)
+	if m.keepaliveInterval > 0 {
+		m.wg.Add(1)
+		go func(ctx context.Context) {
+			defer m.wg.Done()
+			m.runKeepalive(ctx)
+		}(ctx)
+	}
+
+	// Shift the phase of the schedule before starting the ticker
+	if !sleepContext(ctx, m.phaseOffset+jitterDelay(m.phaseJitter)) {
+		return nil
+	}
+
+	currentInterval := m.GetInterval()
+	ticker := time.NewTicker(currentInterval)
 	defer ticker.Stop()
 
 	// Collect initial stats
-	if err := m.collectAndLog(); err != nil {
+	start := time.Now()
+	if err := m.collectScheduled(ctx); err != nil && ctx.Err() == nil {
 		fmt.Printf("Error collecting initial stats: %v", err)
 	}
+	if ctx.Err() == nil && cycleOverran(ticker, start, currentInterval, m.overrunPolicy) {
+		m.recordOverrun()
+	}
 
 	for {
 		select {
-		case <-m.ctx.Done():
+		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			if err := m.collectAndLog(); err != nil {
+			start := time.Now()
+			if !sleepContext(ctx, jitterDelay(m.phaseJitter)) {
+				return nil
+			}
+			if err := m.collectScheduled(ctx); err != nil && ctx.Err() == nil {
 				fmt.Printf("Error collecting stats: %v", err)
 			}
+			if ctx.Err() == nil && cycleOverran(ticker, start, currentInterval, m.overrunPolicy) {
+				m.recordOverrun()
+			}
+			// Pick up an interval changed with SetInterval while running
+			if interval := m.GetInterval(); interval != currentInterval {
+				currentInterval = interval
+				ticker.Reset(interval)
+			}
 		}
 	}
 }
@@ -169,7 +409,7 @@ func (m *RemoteStatsMonitor) StartAsync() error {
 	m.ensureFreshContext()
 
 	go func() {
-		if err := m.StartSync(); err != nil {
+		if err := m.StartSync(); err != nil && m.logger != nil {
 			m.logger.Printf("Async monitoring stopped with error: %v", err)
 		}
 	}()
@@ -190,12 +430,72 @@ func (m *RemoteStatsMonitor) Stop() {
 // Close closes the underlying collector and stops monitoring
 func (m *RemoteStatsMonitor) Close() error {
 	m.Stop() // Safe to call multiple times due to sync.Once
-	return m.collector.Close()
+	if m.sinks != nil {
+		if err := m.sinks.Close(); err != nil {
+			m.statsSource().Close()
+			return err
+		}
+	}
+	return m.statsSource().Close()
+}
+
+// statsSource returns the collector the samples come from
+func (m *RemoteStatsMonitor) statsSource() StatsCollector {
+	if m.source != nil {
+		return m.source
+	}
+	return m.collector
+}
+
+// GetCurrentStats gets the current system stats without logging, giving up when ctx is done
+func (m *RemoteStatsMonitor) GetCurrentStats(ctx context.Context) (*SystemStats, error) {
+	return m.statsSource().GetSystemStats(ctx)
+}
+
+// AddSampleHandler registers a function called with every collected sample, after it is logged
+// (e.g., an exporter's WriteSample method)
+func (m *RemoteStatsMonitor) AddSampleHandler(handler func(*Sample) error) {
+	m.sampleHandlers = append(m.sampleHandlers, handler)
 }
 
-// GetCurrentStats gets the current system stats without logging
-func (m *RemoteStatsMonitor) GetCurrentStats() (*SystemStats, error) {
-	return m.collector.GetSystemStats()
+// SetHost sets the name identifying the monitored host in samples
+// (defaults to the SSH server address)
+func (m *RemoteStatsMonitor) SetHost(host string) {
+	m.host = host
+}
+
+// GetHost returns the name identifying the monitored host in samples
+func (m *RemoteStatsMonitor) GetHost() string {
+	return m.host
+}
+
+// SetLabels sets static key/value labels (e.g., role=db, dc=eu-west) included in every sample:
+// in the JSON output under "labels", and as labels or tags by the metric sinks
+func (m *RemoteStatsMonitor) SetLabels(labels map[string]string) {
+	m.labels = make(map[string]string, len(labels))
+	for k, v := range labels {
+		m.labels[k] = v
+	}
+}
+
+// SetLabel sets a single static label, see SetLabels
+func (m *RemoteStatsMonitor) SetLabel(key, value string) {
+	// Copy rather than modify, samples already handed to sinks share the current map
+	labels := make(map[string]string, len(m.labels)+1)
+	for k, v := range m.labels {
+		labels[k] = v
+	}
+	labels[key] = value
+	m.labels = labels
+}
+
+// GetLabels returns the static labels of the monitor
+func (m *RemoteStatsMonitor) GetLabels() map[string]string {
+	labels := make(map[string]string, len(m.labels))
+	for k, v := range m.labels {
+		labels[k] = v
+	}
+	return labels
 }
 
 // SetLogLine sets a custom log line formatting function
@@ -203,28 +503,407 @@ func (m *RemoteStatsMonitor) SetLogLineFunc(logLineFunc func(*SystemStats) ([]by
 	m.logLineFunc = logLineFunc
 }
 
-// SetInterval updates the monitoring interval (only effective after restart)
+// SetTimestampFormat switches the log lines to JSON with the timestamp written in format, in location
+// (time.UTC, or nil for local time), and uses the same format for event records
+func (m *RemoteStatsMonitor) SetTimestampFormat(format TimestampFormat, location *time.Location) {
+	m.timestamps = timestampFormatter{format: format, location: location}
+	m.logLineFunc = NewJSONLogLine(format, location)
+}
+
+// SetInterval updates the monitoring interval (a running monitor switches after its next collection)
 func (m *RemoteStatsMonitor) SetInterval(interval time.Duration) {
+	m.settingsMu.Lock()
+	defer m.settingsMu.Unlock()
 	m.interval = interval
 }
 
+// SetCycleTimeout sets the deadline of a single collection (0, the default, waits forever). A
+// collection missing it is abandoned and reported through a "collection_timeout" event, so that a
+// slow host leaves a visible gap in the timeline rather than stalling it. Monitors added to a
+// MonitorPool use the pool's host timeout instead (see MonitorPool.SetHostTimeout).
+func (m *RemoteStatsMonitor) SetCycleTimeout(timeout time.Duration) {
+	m.cycleTimeout = timeout
+}
+
+// GetCycleTimeout returns the deadline of a single collection, 0 for none
+func (m *RemoteStatsMonitor) GetCycleTimeout() time.Duration {
+	return m.cycleTimeout
+}
+
+// SetStaleAfter sets the age past which a sample is flagged with "stale" and its "age_ms" when a
+// sink writes it, e.g., after holding it while the destination was unreachable. 0, the default,
+// uses the interval, since a newer sample is due by then; a negative age never flags samples.
+func (m *RemoteStatsMonitor) SetStaleAfter(age time.Duration) {
+	m.staleAfter = age
+}
+
+// GetStaleAfter returns the age past which written samples are flagged as stale, 0 for never
+func (m *RemoteStatsMonitor) GetStaleAfter() time.Duration {
+	return m.staleAge()
+}
+
+func (m *RemoteStatsMonitor) staleAge() time.Duration {
+	switch {
+	case m.staleAfter < 0:
+		return 0
+	case m.staleAfter == 0:
+		return m.GetInterval()
+	}
+	return m.staleAfter
+}
+
 // GetInterval returns the current monitoring interval
 func (m *RemoteStatsMonitor) GetInterval() time.Duration {
+	m.settingsMu.Lock()
+	defer m.settingsMu.Unlock()
 	return m.interval
 }
 
-// SetSampleDelta updates the CPU sampling interval (only effective after restart)
+// SetSampleDelta updates the CPU sampling interval, applied from the next collection on
 func (m *RemoteStatsMonitor) SetSampleDelta(sampleDelta time.Duration) {
+	m.settingsMu.Lock()
 	m.sampleDelta = sampleDelta
-	m.collector.SetSampleDelta(sampleDelta)
+	m.settingsMu.Unlock()
+	m.statsSource().SetSampleDelta(sampleDelta)
 }
 
 // GetSampleDelta returns the current CPU sampling interval
 func (m *RemoteStatsMonitor) GetSampleDelta() time.Duration {
+	m.settingsMu.Lock()
+	defer m.settingsMu.Unlock()
 	return m.sampleDelta
 }
 
+// SetUptimeEvery reports uptime only on every nth sample to keep log records small
+func (m *RemoteStatsMonitor) SetUptimeEvery(n int) {
+	m.collector.SetUptimeEvery(n)
+}
+
+// GetUptimeEvery returns how often uptime is reported
+func (m *RemoteStatsMonitor) GetUptimeEvery() int {
+	return m.collector.GetUptimeEvery()
+}
+
+// SetThermalEnabled enables or disables temperature collection from thermal zones and hwmon sensors
+func (m *RemoteStatsMonitor) SetThermalEnabled(enabled bool) {
+	m.collector.SetThermalEnabled(enabled)
+}
+
+// IsThermalEnabled returns whether temperatures are collected
+func (m *RemoteStatsMonitor) IsThermalEnabled() bool {
+	return m.collector.IsThermalEnabled()
+}
+
+// SetSocketStatsEnabled enables or disables collection of TCP/UDP socket counts
+func (m *RemoteStatsMonitor) SetSocketStatsEnabled(enabled bool) {
+	m.collector.SetSocketStatsEnabled(enabled)
+}
+
+// IsSocketStatsEnabled returns whether socket counts are collected
+func (m *RemoteStatsMonitor) IsSocketStatsEnabled() bool {
+	return m.collector.IsSocketStatsEnabled()
+}
+
+// SetFDWatchPIDs sets the PIDs whose open file descriptors are counted on every sample
+func (m *RemoteStatsMonitor) SetFDWatchPIDs(pids []int) {
+	m.collector.SetFDWatchPIDs(pids)
+}
+
+// GetFDWatchPIDs returns the PIDs whose open file descriptors are counted
+func (m *RemoteStatsMonitor) GetFDWatchPIDs() []int {
+	return m.collector.GetFDWatchPIDs()
+}
+
+// SetEntropyEnabled enables or disables collection of the kernel entropy pool size
+func (m *RemoteStatsMonitor) SetEntropyEnabled(enabled bool) {
+	m.collector.SetEntropyEnabled(enabled)
+}
+
+// IsEntropyEnabled returns whether the entropy pool size is collected
+func (m *RemoteStatsMonitor) IsEntropyEnabled() bool {
+	return m.collector.IsEntropyEnabled()
+}
+
+// SetCgroupPaths sets the cgroup v2 paths whose usage is reported on every sample
+func (m *RemoteStatsMonitor) SetCgroupPaths(paths []string) {
+	m.collector.SetCgroupPaths(paths)
+}
+
+// GetCgroupPaths returns the cgroup v2 paths whose usage is reported
+func (m *RemoteStatsMonitor) GetCgroupPaths() []string {
+	return m.collector.GetCgroupPaths()
+}
+
+// SetGPUEnabled enables or disables NVIDIA GPU collection via nvidia-smi
+func (m *RemoteStatsMonitor) SetGPUEnabled(enabled bool) {
+	m.collector.SetGPUEnabled(enabled)
+}
+
+// IsGPUEnabled returns whether GPUs are collected
+func (m *RemoteStatsMonitor) IsGPUEnabled() bool {
+	return m.collector.IsGPUEnabled()
+}
+
+// SetSystemdEnabled enables or disables systemd unit health collection
+func (m *RemoteStatsMonitor) SetSystemdEnabled(enabled bool) {
+	m.collector.SetSystemdEnabled(enabled)
+}
+
+// IsSystemdEnabled returns whether systemd unit health is collected
+func (m *RemoteStatsMonitor) IsSystemdEnabled() bool {
+	return m.collector.IsSystemdEnabled()
+}
+
+// SetSystemdUnits sets the units whose state is recorded on every sample
+func (m *RemoteStatsMonitor) SetSystemdUnits(units []string) {
+	m.collector.SetSystemdUnits(units)
+}
+
+// GetSystemdUnits returns the units whose state is recorded
+func (m *RemoteStatsMonitor) GetSystemdUnits() []string {
+	return m.collector.GetSystemdUnits()
+}
+
+// SetIPMIEnabled enables collecting BMC sensor readings (fans, voltages, temperatures, power) with ipmitool
+func (m *RemoteStatsMonitor) SetIPMIEnabled(enabled bool) {
+	m.collector.SetIPMIEnabled(enabled)
+}
+
+// IsIPMIEnabled returns whether IPMI sensors are collected
+func (m *RemoteStatsMonitor) IsIPMIEnabled() bool {
+	return m.collector.IsIPMIEnabled()
+}
+
+// SetIPMIInterval sets how often ipmitool is run (defaults to 30 seconds)
+func (m *RemoteStatsMonitor) SetIPMIInterval(interval time.Duration) {
+	m.collector.SetIPMIInterval(interval)
+}
+
+// GetIPMIInterval returns how often ipmitool is run
+func (m *RemoteStatsMonitor) GetIPMIInterval() time.Duration {
+	return m.collector.GetIPMIInterval()
+}
+
+// SetSMARTDevices sets the devices whose SMART attributes are collected with smartctl
+func (m *RemoteStatsMonitor) SetSMARTDevices(devices []string) {
+	m.collector.SetSMARTDevices(devices)
+}
+
+// GetSMARTDevices returns the devices whose SMART attributes are collected
+func (m *RemoteStatsMonitor) GetSMARTDevices() []string {
+	return m.collector.GetSMARTDevices()
+}
+
+// SetSMARTInterval sets how often smartctl is run (defaults to 10 minutes)
+func (m *RemoteStatsMonitor) SetSMARTInterval(interval time.Duration) {
+	m.collector.SetSMARTInterval(interval)
+}
+
+// GetSMARTInterval returns how often smartctl is run
+func (m *RemoteStatsMonitor) GetSMARTInterval() time.Duration {
+	return m.collector.GetSMARTInterval()
+}
+
+// SetPerCoreCPUModesEnabled enables or disables the per-mode CPU time breakdown for every core
+func (m *RemoteStatsMonitor) SetPerCoreCPUModesEnabled(enabled bool) {
+	m.collector.SetPerCoreCPUModesEnabled(enabled)
+}
+
+// IsPerCoreCPUModesEnabled returns whether the per-mode breakdown is reported for every core
+func (m *RemoteStatsMonitor) IsPerCoreCPUModesEnabled() bool {
+	return m.collector.IsPerCoreCPUModesEnabled()
+}
+
+// SetExtraMeminfoFields sets additional /proc/meminfo keys reported on every sample
+func (m *RemoteStatsMonitor) SetExtraMeminfoFields(fields []string) {
+	m.collector.SetExtraMeminfoFields(fields)
+}
+
+// GetExtraMeminfoFields returns the additional /proc/meminfo keys reported
+func (m *RemoteStatsMonitor) GetExtraMeminfoFields() []string {
+	return m.collector.GetExtraMeminfoFields()
+}
+
+// SetNetErrorAlertsEnabled makes every sample in which an interface's error or drop counters
+// increased also be reported through the monitoring error path
+func (m *RemoteStatsMonitor) SetNetErrorAlertsEnabled(enabled bool) {
+	m.alertOnNetErrors = enabled
+}
+
+// IsNetErrorAlertsEnabled returns whether increased interface errors/drops are reported as errors
+func (m *RemoteStatsMonitor) IsNetErrorAlertsEnabled() bool {
+	return m.alertOnNetErrors
+}
+
+// SetSlabTopCaches sets how many of the largest slab caches are reported (0 disables, needs root)
+func (m *RemoteStatsMonitor) SetSlabTopCaches(n int) {
+	m.collector.SetSlabTopCaches(n)
+}
+
+// GetSlabTopCaches returns how many of the largest slab caches are reported
+func (m *RemoteStatsMonitor) GetSlabTopCaches() int {
+	return m.collector.GetSlabTopCaches()
+}
+
+// SetBuddyinfoEnabled enables or disables collection of free pages per order from /proc/buddyinfo
+func (m *RemoteStatsMonitor) SetBuddyinfoEnabled(enabled bool) {
+	m.collector.SetBuddyinfoEnabled(enabled)
+}
+
+// IsBuddyinfoEnabled returns whether /proc/buddyinfo is collected
+func (m *RemoteStatsMonitor) IsBuddyinfoEnabled() bool {
+	return m.collector.IsBuddyinfoEnabled()
+}
+
+// SetProcessStatesEnabled enables or disables counting zombie and uninterruptible (D-state) processes
+func (m *RemoteStatsMonitor) SetProcessStatesEnabled(enabled bool) {
+	m.collector.SetProcessStatesEnabled(enabled)
+}
+
+// IsProcessStatesEnabled returns whether zombie and D-state processes are counted
+func (m *RemoteStatsMonitor) IsProcessStatesEnabled() bool {
+	return m.collector.IsProcessStatesEnabled()
+}
+
+// SetOOMDetectionEnabled enables or disables flagging OOM kill events in the samples
+func (m *RemoteStatsMonitor) SetOOMDetectionEnabled(enabled bool) {
+	m.collector.SetOOMDetectionEnabled(enabled)
+}
+
+// IsOOMDetectionEnabled returns whether OOM kill events are detected
+func (m *RemoteStatsMonitor) IsOOMDetectionEnabled() bool {
+	return m.collector.IsOOMDetectionEnabled()
+}
+
+// SetOOMCheckInterval sets how often the host is checked for new OOM kills (defaults to 30 seconds)
+func (m *RemoteStatsMonitor) SetOOMCheckInterval(interval time.Duration) {
+	m.collector.SetOOMCheckInterval(interval)
+}
+
+// GetOOMCheckInterval returns how often the host is checked for new OOM kills
+func (m *RemoteStatsMonitor) GetOOMCheckInterval() time.Duration {
+	return m.collector.GetOOMCheckInterval()
+}
+
+// WatchProcess reports every process whose command name or command line matches pattern
+func (m *RemoteStatsMonitor) WatchProcess(pattern string) error {
+	return m.collector.WatchProcess(pattern)
+}
+
+// WatchPID reports the process with the given PID
+func (m *RemoteStatsMonitor) WatchPID(pid int) {
+	m.collector.WatchPID(pid)
+}
+
+// ClearWatchedProcesses stops reporting every watched process and PID
+func (m *RemoteStatsMonitor) ClearWatchedProcesses() {
+	m.collector.ClearWatchedProcesses()
+}
+
+// SetPerUserStatsEnabled enables or disables aggregating CPU and RSS usage per user
+func (m *RemoteStatsMonitor) SetPerUserStatsEnabled(enabled bool) {
+	m.collector.SetPerUserStatsEnabled(enabled)
+}
+
+// IsPerUserStatsEnabled returns whether usage is aggregated per user
+func (m *RemoteStatsMonitor) IsPerUserStatsEnabled() bool {
+	return m.collector.IsPerUserStatsEnabled()
+}
+
+// SetBatchedReadsEnabled fetches the files of every snapshot with a single command instead of one request per file
+func (m *RemoteStatsMonitor) SetBatchedReadsEnabled(enabled bool) {
+	m.collector.SetBatchedReadsEnabled(enabled)
+}
+
+// IsBatchedReadsEnabled returns whether snapshot files are fetched with a single command
+func (m *RemoteStatsMonitor) IsBatchedReadsEnabled() bool {
+	return m.collector.IsBatchedReadsEnabled()
+}
+
+// SetStreamingInterval computes samples from a shell loop printing the files every interval over one
+// SSH session instead of reading them on demand, 0 to stop it
+func (m *RemoteStatsMonitor) SetStreamingInterval(interval time.Duration) {
+	m.collector.SetStreamingInterval(interval)
+}
+
+// GetStreamingInterval returns the period of the streaming agent frames, 0 if disabled
+func (m *RemoteStatsMonitor) GetStreamingInterval() time.Duration {
+	return m.collector.GetStreamingInterval()
+}
+
+// SetCollectionModes sets an ordered list of modes to fall back on when collecting with the current one fails
+func (m *RemoteStatsMonitor) SetCollectionModes(modes ...CollectionMode) error {
+	return m.collector.SetCollectionModes(modes...)
+}
+
+// GetCollectionMode returns the mode samples are currently collected with
+func (m *RemoteStatsMonitor) GetCollectionMode() CollectionMode {
+	return m.collector.GetCollectionMode()
+}
+
+// AddCommandMetric reports the value parser extracts from the output of cmd, run on every sample, as the metric name
+func (m *RemoteStatsMonitor) AddCommandMetric(name, cmd string, parser func(string) (float64, error)) error {
+	return m.collector.AddCommandMetric(name, cmd, parser)
+}
+
+// RemoveCommandMetric stops running the command of the metric name
+func (m *RemoteStatsMonitor) RemoveCommandMetric(name string) {
+	m.collector.RemoveCommandMetric(name)
+}
+
+// SetSlogLogger makes the monitor also emit every sample (message "system stats") and event
+// as structured slog records. The *log.Logger passed to the constructor may then be nil.
+func (m *RemoteStatsMonitor) SetSlogLogger(logger *slog.Logger) {
+	m.slogger = logger
+}
+
+// SetSink makes the monitor write samples (and events, if sink is an EventSink) to sink instead of
+// the *log.Logger passed to the constructor, which may then be nil. The monitor does not close sink.
+func (m *RemoteStatsMonitor) SetSink(sink Sink) {
+	m.sink = sink
+}
+
+// AddSink adds a sink that receives every sample in addition to the output. Added sinks are written
+// concurrently, each from its own queue, so a slow or failing sink cannot hold up collection or the
+// other sinks. Close flushes and closes them.
+func (m *RemoteStatsMonitor) AddSink(sink Sink) {
+	if m.sinks == nil {
+		m.sinks = NewFanOutSink(0)
+		m.sinks.SetErrorHandler(func(sink Sink, err error) {
+			fmt.Printf("Error writing to sink %T: %v\n", sink, err)
+		})
+	}
+	m.sinks.Add(sink)
+}
+
+// SetSinkErrorHandler replaces the function called when a sink added with AddSink fails to write
+// (by default the error is printed)
+func (m *RemoteStatsMonitor) SetSinkErrorHandler(handler func(sink Sink, err error)) {
+	if m.sinks == nil {
+		m.sinks = NewFanOutSink(0)
+	}
+	m.sinks.SetErrorHandler(handler)
+}
+
+// GetSinkStats returns the queued, dropped and failed sample counts of every sink added with AddSink
+func (m *RemoteStatsMonitor) GetSinkStats() []FanOutSinkStats {
+	if m.sinks == nil {
+		return nil
+	}
+	return m.sinks.Stats()
+}
+
+// output returns the sink samples are written to
+func (m *RemoteStatsMonitor) output() Sink {
+	if m.sink != nil {
+		return m.sink
+	}
+	return loggerSink{m}
+}
+
 // SetLogFile sets the logger to write to the specified file
+//
+// Deprecated: use SetSink(NewWriterSink(file, nil)), which also allows other formats and destinations.
 func (m *RemoteStatsMonitor) SetLogFile(filename string) error {
 	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -234,3 +913,15 @@ func (m *RemoteStatsMonitor) SetLogFile(filename string) error {
 	m.logger = log.New(file, "", 0)
 	return nil
 }
+
+// SetRotatingLogFile sets the logger to write to filename, rotating it once it reaches maxSizeMB
+// and keeping at most maxBackups rotated files no older than maxAge (0 for no limit)
+func (m *RemoteStatsMonitor) SetRotatingLogFile(filename string, maxSizeMB int, maxAge time.Duration, maxBackups int) error {
+	file, err := NewRotatingFile(filename, maxSizeMB, maxAge, maxBackups)
+	if err != nil {
+		return err
+	}
+
+	m.logger = log.New(file, "", 0)
+	return nil
+}
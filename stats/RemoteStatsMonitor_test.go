@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubCollector returns an empty sample on every collection
+type stubCollector struct{}
+
+func (stubCollector) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	return &SystemStats{}, nil
+}
+
+func (stubCollector) Close() error { return nil }
+
+func (stubCollector) SetSampleDelta(sampleDelta time.Duration) {}
+
+// Run with -race: the interval and sampleDelta are changed while the monitor collects
+func TestSetIntervalWhileRunning(t *testing.T) {
+	m := NewStatsMonitor(stubCollector{}, time.Millisecond, time.Millisecond, log.New(io.Discard, "", 0))
+	if err := m.StartAsync(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 20; i++ {
+		m.SetInterval(time.Duration(i%3+1) * time.Millisecond)
+		m.SetSampleDelta(time.Duration(i) * time.Millisecond)
+		time.Sleep(time.Millisecond)
+	}
+	m.Stop()
+	if got := m.GetInterval(); got != 3*time.Millisecond {
+		t.Errorf("GetInterval() = %v, want 3ms", got)
+	}
+	if got := m.GetSampleDelta(); got != 20*time.Millisecond {
+		t.Errorf("GetSampleDelta() = %v, want 20ms", got)
+	}
+}
+
+// Run with -race: the sample delta is changed while snapshots are being taken
+func TestSetSampleDeltaDuringCollection(t *testing.T) {
+	r := &remoteStatsCollector{
+		sampleDelta: time.Millisecond,
+		transport:   procFiles(sampleProcStat, sampleMeminfo, sampleDiskstats, sampleNetDev, sampleSnmp, sampleVmstat),
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			if _, _, err := r.cycleSnapshots(context.Background(), nil); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		r.SetSampleDelta(time.Duration(i+1) * time.Millisecond)
+	}
+	wg.Wait()
+}
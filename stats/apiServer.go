@@ -0,0 +1,277 @@
+package stats
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const defaultAPIHistorySize = 3600
+
+// dashboardAssets is the single-page web dashboard served on /
+//
+//go:embed web
+var dashboardAssets embed.FS
+
+// APIServer keeps the recent samples of every host and serves them as JSON:
+//
+//	GET /stats/current[?host=h]          latest sample per host
+//	GET /stats/history?since=...[&host=h] samples since an RFC 3339 time, unix seconds or a duration ago (e.g., "5m")
+//	GET /hosts                           monitored hosts with their last sample time
+//	GET /stats/stream[?host=h]           WebSocket pushing every new sample as a JSON message
+//	GET /                                web dashboard charting CPU and memory of every host
+type APIServer struct {
+	historySize int
+	mu          sync.RWMutex
+	history     map[string][]*Sample // host -> samples, oldest first
+	streams     map[*apiStream]struct{}
+	mux         *http.ServeMux
+	server      *http.Server
+}
+
+// NewAPIServer creates an API server keeping up to historySize samples per host (0 uses 3600)
+func NewAPIServer(historySize int) *APIServer {
+	if historySize <= 0 {
+		historySize = defaultAPIHistorySize
+	}
+	a := &APIServer{
+		historySize: historySize,
+		history:     make(map[string][]*Sample),
+		streams:     make(map[*apiStream]struct{}),
+		mux:         http.NewServeMux(),
+	}
+	a.mux.HandleFunc("/stats/current", a.handleCurrent)
+	a.mux.HandleFunc("/stats/history", a.handleHistory)
+	a.mux.HandleFunc("/hosts", a.handleHosts)
+	a.mux.Handle("/stats/stream", websocket.Handler(a.handleStream))
+	web, _ := fs.Sub(dashboardAssets, "web")
+	a.mux.Handle("/", http.FileServer(http.FS(web)))
+	return a
+}
+
+// WriteSample appends the sample to its host's history, dropping the oldest one when full
+func (a *APIServer) WriteSample(sample *Sample) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	samples := append(a.history[sample.Host], sample)
+	if len(samples) > a.historySize {
+		samples = samples[len(samples)-a.historySize:]
+	}
+	a.history[sample.Host] = samples
+
+	for stream := range a.streams {
+		if stream.host != "" && stream.host != sample.Host {
+			continue
+		}
+		select {
+		case stream.samples <- sample:
+		default:
+			// The client is not keeping up, it misses this sample rather than slowing the monitor
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the API, so it can be mounted on an existing server
+func (a *APIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API at addr (e.g., ":8080") until Close is called
+func (a *APIServer) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	a.mu.Lock()
+	a.server = &http.Server{Handler: a, ReadHeaderTimeout: 10 * time.Second}
+	server := a.server
+	a.mu.Unlock()
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Close ends every stream and stops the HTTP server started by ListenAndServe
+func (a *APIServer) Close() error {
+	a.mu.Lock()
+	server := a.server
+	a.server = nil
+	for stream := range a.streams {
+		close(stream.samples)
+		delete(a.streams, stream)
+	}
+	a.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeAPIJSON(w, status, map[string]any{"error": fmt.Sprintf(format, args...)})
+}
+
+// sortedHosts returns the hosts to answer for: the requested one, or all of them sorted by name
+func (a *APIServer) sortedHosts(host string) []string {
+	if host != "" {
+		if _, ok := a.history[host]; !ok {
+			return nil
+		}
+		return []string{host}
+	}
+	hosts := make([]string, 0, len(a.history))
+	for h := range a.history {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func (a *APIServer) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Query().Get("host")
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	hosts := a.sortedHosts(host)
+	if host != "" && hosts == nil {
+		writeAPIError(w, http.StatusNotFound, "unknown host %q", host)
+		return
+	}
+	samples := make([]map[string]any, 0, len(hosts))
+	for _, h := range hosts {
+		history := a.history[h]
+		samples = append(samples, SampleToJSON(history[len(history)-1]))
+	}
+	writeAPIJSON(w, http.StatusOK, samples)
+}
+
+// parseSince accepts an RFC 3339 time, unix seconds, or a duration before now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Unix(0, int64(secs*float64(time.Second))), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q: expected an RFC 3339 time, unix seconds or a duration", value)
+}
+
+func (a *APIServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	since, err := parseSince(query.Get("since"), time.Now())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	host := query.Get("host")
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	hosts := a.sortedHosts(host)
+	if host != "" && hosts == nil {
+		writeAPIError(w, http.StatusNotFound, "unknown host %q", host)
+		return
+	}
+	samples := []map[string]any{}
+	for _, h := range hosts {
+		history := a.history[h]
+		// History is in time order, so skip straight to the first sample after since
+		start := sort.Search(len(history), func(i int) bool { return !history[i].Timestamp.Before(since) })
+		for _, sample := range history[start:] {
+			samples = append(samples, sampleJSON(sample))
+		}
+	}
+	writeAPIJSON(w, http.StatusOK, samples)
+}
+
+func (a *APIServer) handleHosts(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	hosts := []map[string]any{}
+	for _, h := range a.sortedHosts("") {
+		history := a.history[h]
+		hosts = append(hosts, map[string]any{
+			"host":      h,
+			"last_seen": history[len(history)-1].Timestamp.Format(time.RFC3339Nano),
+			"samples":   len(history),
+		})
+	}
+	writeAPIJSON(w, http.StatusOK, hosts)
+}
+
+// apiStreamBuffer is the number of samples buffered per WebSocket client
+const apiStreamBuffer = 16
+
+// apiStream is a connected /stats/stream client
+type apiStream struct {
+	host    string // "" for all hosts
+	samples chan *Sample
+}
+
+func (a *APIServer) handleStream(ws *websocket.Conn) {
+	defer ws.Close()
+	stream := &apiStream{
+		host:    ws.Request().URL.Query().Get("host"),
+		samples: make(chan *Sample, apiStreamBuffer),
+	}
+	a.mu.Lock()
+	a.streams[stream] = struct{}{}
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.streams, stream)
+		a.mu.Unlock()
+	}()
+
+	// Clients only listen, so a read returning means the connection was closed
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case sample, ok := <-stream.samples:
+			if !ok {
+				return
+			}
+			if err := websocket.JSON.Send(ws, SampleToJSON(sample)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// batchedFile is the content of a file fetched by a batch, or the reason it could not be read
+type batchedFile struct {
+	data []byte
+	err  error
+}
+
+// batchingTransport serves files fetched together in a single command, so a snapshot costs one
+// round trip instead of one per file. Files not in the batch are read through the wrapped transport.
+type batchingTransport struct {
+	transport
+	marker string       // separates the files in the command output
+	mu     sync.RWMutex // Protects files, read by the metric groups while a snapshot is fetched
+	files  map[string]batchedFile
+}
+
+func newBatchingTransport(inner transport) *batchingTransport {
+	return &batchingTransport{
+		transport: inner,
+		marker:    newOutputMarker(),
+		files:     make(map[string]batchedFile),
+	}
+}
+
+// newOutputMarker returns a random line prefix that no file content is expected to contain
+func newOutputMarker() string {
+	token := make([]byte, 8)
+	rand.Read(token)
+	return "==sysstats-" + hex.EncodeToString(token) + "=="
+}
+
+func (t *batchingTransport) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	t.mu.RLock()
+	file, ok := t.files[path]
+	t.mu.RUnlock()
+	if ok {
+		if file.err != nil {
+			return nil, file.err
+		}
+		return io.NopCloser(bytes.NewReader(file.data)), nil
+	}
+	return t.transport.Open(ctx, path)
+}
+
+// catFilesScript returns a shell loop printing every file of paths preceded by "\n<marker> <path>\n".
+// A missing file is only announced by "\n<marker>! <path>\n"; a file cat fails on is followed by
+// "\n<marker>- <path>\n" when it is not readable, or "\n<marker>? <path>\n" for any other error.
+func catFilesScript(marker string, paths []string) string {
+	var cmd strings.Builder
+	cmd.WriteString("for f in")
+	for _, path := range paths {
+		cmd.WriteByte(' ')
+		cmd.WriteString(shellQuote(path))
+	}
+	fmt.Fprintf(&cmd, `; do if [ -e "$f" ]; then printf '\n%[1]s %%s\n' "$f"; `+
+		`cat -- "$f" 2>/dev/null || if [ -r "$f" ]; then printf '\n%[1]s? %%s\n' "$f"; else printf '\n%[1]s- %%s\n' "$f"; fi; `+
+		`else printf '\n%[1]s! %%s\n' "$f"; fi; done`, marker)
+	return cmd.String()
+}
+
+// fetch reads paths with one command, replacing their earlier content. The earlier content is
+// dropped first, so that paths are read one by one if the command fails.
+func (t *batchingTransport) fetch(ctx context.Context, paths []string) error {
+	t.mu.Lock()
+	for _, path := range paths {
+		delete(t.files, path)
+	}
+	t.mu.Unlock()
+
+	output, err := t.transport.Run(ctx, catFilesScript(t.marker, paths))
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, part := range strings.Split(output, "\n"+t.marker)[1:] {
+		header, content, _ := strings.Cut(part, "\n")
+		if len(header) < 2 {
+			continue
+		}
+		// A content header is "<marker> <path>", a failure marker "<marker>X <path>"; the failure
+		// markers follow the content cat may have printed, replacing it
+		if header[0] == ' ' {
+			t.files[header[1:]] = batchedFile{data: []byte(content)}
+			continue
+		}
+		switch path := header[2:]; header[0] {
+		case '!':
+			t.files[path] = batchedFile{err: fmt.Errorf("%s: %w", path, os.ErrNotExist)}
+		case '-':
+			t.files[path] = batchedFile{err: fmt.Errorf("%s: %w", path, os.ErrPermission)}
+		case '?':
+			t.files[path] = batchedFile{err: fmt.Errorf("failed to read %s", path)}
+		}
+	}
+	return nil
+}
+
+// reset drops the fetched files, so nothing stale is served outside of a collection
+func (t *batchingTransport) reset() {
+	// Replaced rather than cleared, as the map may be a frame shared with a streaming agent
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.files = make(map[string]batchedFile)
+}
+
+// SetBatchedReadsEnabled fetches the files of every snapshot with a single command over SSH instead
+// of one SFTP request per file, so that short sample deltas are not skewed by the round trips.
+// Directory listings (e.g., for process counts) and disk usage are still separate requests.
+func (r *remoteStatsCollector) SetBatchedReadsEnabled(enabled bool) {
+	batching, isBatching := r.transport.(*batchingTransport)
+	switch {
+	case enabled && !isBatching:
+		r.transport = newBatchingTransport(r.transport)
+	case !enabled && isBatching:
+		r.transport = batching.transport
+	}
+}
+
+// IsBatchedReadsEnabled returns whether snapshot files are fetched with a single command
+func (r *remoteStatsCollector) IsBatchedReadsEnabled() bool {
+	_, ok := r.transport.(*batchingTransport)
+	return ok
+}
+
+// prefetch fetches paths in one batch when batched reads are enabled. On failure (e.g., a collector
+// without an SSH client) the files are read one by one, which reports any real error.
+func (r *remoteStatsCollector) prefetch(ctx context.Context, paths []string) {
+	if batching, ok := r.transport.(*batchingTransport); ok {
+		batching.fetch(ctx, paths)
+	}
+}
+
+// resetBatch drops the files fetched for the current collection
+func (r *remoteStatsCollector) resetBatch() {
+	if batching, ok := r.transport.(*batchingTransport); ok {
+		batching.reset()
+	}
+}
+
+// snapshotFiles lists the files read by takeSnapshot
+func snapshotFiles(watched map[int]string) []string {
+	paths := []string{"/proc/stat", "/proc/diskstats", "/proc/net/snmp", "/proc/net/dev", "/proc/vmstat"}
+	for pid := range watched {
+		paths = append(paths, fmt.Sprintf("/proc/%d/stat", pid))
+	}
+	return paths
+}
+
+// sampleFiles lists the files read once per sample, depending on the enabled metric groups
+func (r *remoteStatsCollector) sampleFiles(watched map[int]string) []string {
+	paths := []string{
+		"/proc/meminfo", "/proc/loadavg", "/proc/mounts", "/proc/uptime", "/proc/sys/fs/file-nr",
+		"/proc/pressure/cpu", "/proc/pressure/memory", "/proc/pressure/io",
+		"/proc/sys/net/netfilter/nf_conntrack_count", "/proc/sys/net/netfilter/nf_conntrack_max",
+		"/proc/mdstat",
+	}
+	if r.slabTopCaches > 0 {
+		paths = append(paths, "/proc/slabinfo")
+	}
+	if r.socketStatsEnabled {
+		paths = append(paths, "/proc/net/sockstat")
+	}
+	if r.entropyEnabled {
+		paths = append(paths, "/proc/sys/kernel/random/entropy_avail")
+	}
+	if r.buddyinfoEnabled {
+		paths = append(paths, "/proc/buddyinfo")
+	}
+	for pid := range watched {
+		paths = append(paths, fmt.Sprintf("/proc/%d/status", pid))
+	}
+	return paths
+}
@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// scriptedTransport serves files like fileTransport and answers every command with output and err
+type scriptedTransport struct {
+	fileTransport
+	output string
+	err    error
+}
+
+func (t *scriptedTransport) Run(ctx context.Context, cmd string) (string, error) {
+	return t.output, t.err
+}
+
+func readBatched(t *testing.T, batching *batchingTransport, path string) (string, error) {
+	t.Helper()
+	file, err := batching.Open(context.Background(), path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	return string(data), err
+}
+
+func TestFailedFetchFallsBackToPerFileReads(t *testing.T) {
+	inner := &scriptedTransport{fileTransport: fileTransport{"/proc/stat": "cpu  2 0 0 0\n"}}
+	batching := newBatchingTransport(inner)
+	ctx := context.Background()
+
+	inner.output = "\n" + batching.marker + " /proc/stat\ncpu  1 0 0 0\n"
+	if err := batching.fetch(ctx, []string{"/proc/stat"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := readBatched(t, batching, "/proc/stat"); got != "cpu  1 0 0 0\n" {
+		t.Errorf("after the first fetch /proc/stat = %q, want the fetched content", got)
+	}
+
+	inner.err = errors.New("session closed")
+	if err := batching.fetch(ctx, []string{"/proc/stat"}); err == nil {
+		t.Fatal("fetch succeeded without output")
+	}
+	if got, _ := readBatched(t, batching, "/proc/stat"); got != "cpu  2 0 0 0\n" {
+		t.Errorf("after a failed fetch /proc/stat = %q, want the content read by the inner transport", got)
+	}
+}
+
+func TestFetchMapsFailureMarkers(t *testing.T) {
+	inner := &scriptedTransport{}
+	batching := newBatchingTransport(inner)
+	marker := "\n" + batching.marker
+	inner.output = marker + " /proc/loadavg\n0.10 0.20 0.30 1/100 42\n" +
+		marker + "! /proc/pressure/cpu\n" +
+		marker + " /proc/slabinfo\n" + marker + "- /proc/slabinfo\n" +
+		marker + " /proc/mdstat\npartial" + marker + "? /proc/mdstat\n"
+	if err := batching.fetch(context.Background(), []string{"/proc/loadavg", "/proc/pressure/cpu", "/proc/slabinfo", "/proc/mdstat"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr error // nil for content, errAny for any error
+	}{
+		{"/proc/loadavg", "0.10 0.20 0.30 1/100 42\n", nil},
+		{"/proc/pressure/cpu", "", os.ErrNotExist},
+		{"/proc/slabinfo", "", os.ErrPermission},
+		{"/proc/mdstat", "", errAny},
+	}
+	for _, tt := range tests {
+		got, err := readBatched(t, batching, tt.path)
+		switch {
+		case tt.wantErr == nil && (err != nil || got != tt.want):
+			t.Errorf("%s = %q, %v, want %q", tt.path, got, err, tt.want)
+		case tt.wantErr == errAny && err == nil:
+			t.Errorf("%s = %q, want an error", tt.path, got)
+		case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+			t.Errorf("%s error = %v, want %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+var errAny = errors.New("any error")
+
+func TestCatFilesScript(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "it's present")
+	if err := os.WriteFile(present, []byte("a 1\nb 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	batching := newBatchingTransport(localTransport{})
+	if err := batching.fetch(context.Background(), []string{present, missing}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readBatched(t, batching, present); err != nil || got != "a 1\nb 2\n" {
+		t.Errorf("present file = %q, %v, want its content", got, err)
+	}
+	if _, err := readBatched(t, batching, missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file error = %v, want os.ErrNotExist", err)
+	}
+}
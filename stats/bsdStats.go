@@ -0,0 +1,396 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// bsdCommonScript prints the sections shared by macOS and FreeBSD, each preceded by "@@ <name>".
+// It is part of the format strings below, hence the escaped %.
+const bsdCommonScript = `echo '@@ loadavg'; sysctl -n vm.loadavg
+echo '@@ boottime'; sysctl -n kern.boottime
+echo '@@ now'; date +%%s
+echo '@@ df'; df -kP
+echo '@@ ps'; ps -axo stat=
+`
+
+// darwinStatsScript samples the CPU with top, whose interval is in whole seconds (%d)
+const darwinStatsScript = `echo '@@ top'; top -l 2 -s %d -n 0 | grep '^CPU usage'
+echo '@@ memsize'; sysctl -n hw.memsize
+echo '@@ vm_stat'; vm_stat
+echo '@@ swap'; sysctl -n vm.swapusage
+` + bsdCommonScript
+
+// freeBSDStatsScript takes two snapshots of the CPU tick counters %s seconds apart
+const freeBSDStatsScript = `echo '@@ cp_time1'; sysctl -n kern.cp_time
+echo '@@ cp_times1'; sysctl -n kern.cp_times
+sleep %s
+echo '@@ cp_time2'; sysctl -n kern.cp_time
+echo '@@ cp_times2'; sysctl -n kern.cp_times
+echo '@@ physmem'; sysctl -n hw.physmem
+echo '@@ vm'; sysctl hw.pagesize vm.stats.vm.v_free_count vm.stats.vm.v_inactive_count vm.stats.vm.v_cache_count vm.stats.vm.v_laundry_count
+echo '@@ swap'; swapctl -sk
+` + bsdCommonScript
+
+// BSDStatsCollector collects the stats of a macOS or FreeBSD host, which have no /proc, by running
+// sysctl, vm_stat, top, df and ps over SSH. It reports CPU usage (per core on FreeBSD only), memory,
+// swap, load average, uptime, disk usage and process counts in the same SystemStats shape as the
+// /proc based collectors; the other fields stay zero. On macOS the sample delta is rounded up to
+// whole seconds, the resolution of top.
+type BSDStatsCollector struct {
+	conn   *remoteStatsCollector // holds the SSH connection, commands are run with its exec transport
+	system string                // "Darwin" or "FreeBSD", as reported by uname -s
+}
+
+var _ StatsCollector = (*BSDStatsCollector)(nil)
+
+// NewBSDStatsCollectorFromSSH creates a collector for the macOS or FreeBSD host of sshClient,
+// detected with uname
+func NewBSDStatsCollectorFromSSH(sshClient *ssh.Client, sampleDelta time.Duration) (*BSDStatsCollector, error) {
+	conn := NewRemoteStatsCollectorFromSSHExec(sshClient, sampleDelta)
+	system, err := detectSystem(context.Background(), conn)
+	if err != nil {
+		return nil, err
+	}
+	if system != "Darwin" && system != "FreeBSD" {
+		return nil, fmt.Errorf("unsupported system %q", system)
+	}
+	return &BSDStatsCollector{conn: conn, system: system}, nil
+}
+
+// detectSystem returns the kernel name of the host (e.g., "Linux", "Darwin"), or "Windows" if it
+// has no uname but answers to ver
+func detectSystem(ctx context.Context, conn *remoteStatsCollector) (string, error) {
+	output, err := conn.runRemoteCommand(ctx, "uname -s")
+	if err == nil {
+		return strings.TrimSpace(output), nil
+	}
+	if ver, verErr := conn.runRemoteCommand(ctx, "cmd /c ver"); verErr == nil && strings.Contains(ver, "Windows") {
+		return "Windows", nil
+	}
+	return "", fmt.Errorf("failed to detect the remote system: %w", err)
+}
+
+// NewStatsCollectorFromSSHConfig connects to serverAddress and returns the collector matching the
+// system of the host, detected with uname: the /proc based collector for Linux (as set up by the
+// options, e.g., WithExecMode), a BSDStatsCollector for macOS and FreeBSD, or a WindowsStatsCollector.
+func NewStatsCollectorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, sampleDelta time.Duration, opts ...SSHOption) (StatsCollector, error) {
+	o, err := applySSHOptions(config, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH options: %w", err)
+	}
+	dial := func() (*ssh.Client, error) {
+		return o.dialSSH(serverAddress)
+	}
+	sshClient, err := dial()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH server: %w", err)
+	}
+
+	conn := NewRemoteStatsCollectorFromSSHExec(sshClient, sampleDelta)
+	conn.ownsSSHClient = true
+	conn.redial = dial
+	system, err := detectSystem(context.Background(), conn)
+	if err != nil {
+		sshClient.Close()
+		return nil, err
+	}
+	switch system {
+	case "Linux":
+		return newRemoteStatsCollectorFromClient(sshClient, dial, o, sampleDelta)
+	case "Darwin", "FreeBSD":
+		return &BSDStatsCollector{conn: conn, system: system}, nil
+	case "Windows":
+		return &WindowsStatsCollector{conn: conn}, nil
+	}
+	sshClient.Close()
+	return nil, fmt.Errorf("unsupported system %q", system)
+}
+
+// NewStatsMonitorFromSSHConfig creates a monitor of serverAddress, named after it, with the collector
+// matching the system of the host (see NewStatsCollectorFromSSHConfig)
+func NewStatsMonitorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, interval time.Duration, sampleDelta time.Duration, logger *log.Logger, opts ...SSHOption) (*RemoteStatsMonitor, error) {
+	collector, err := NewStatsCollectorFromSSHConfig(serverAddress, config, sampleDelta, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m := NewStatsMonitor(collector, interval, sampleDelta, logger)
+	m.host = serverAddress
+	return m, nil
+}
+
+// GetSystem returns the system of the host, "Darwin" or "FreeBSD"
+func (b *BSDStatsCollector) GetSystem() string {
+	return b.system
+}
+
+func (b *BSDStatsCollector) SetSampleDelta(sampleDelta time.Duration) {
+	b.conn.SetSampleDelta(sampleDelta)
+}
+
+func (b *BSDStatsCollector) Close() error {
+	return b.conn.Close()
+}
+
+func (b *BSDStatsCollector) CanReconnect() bool { return b.conn.CanReconnect() }
+func (b *BSDStatsCollector) IsConnected() bool  { return b.conn.IsConnected() }
+func (b *BSDStatsCollector) Reconnect() error   { return b.conn.Reconnect() }
+
+func (b *BSDStatsCollector) Ping(timeout time.Duration) (time.Duration, error) {
+	return b.conn.Ping(timeout)
+}
+
+func (b *BSDStatsCollector) dropConnection() {
+	b.conn.dropConnection()
+}
+
+func (b *BSDStatsCollector) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	delta := b.conn.GetSampleDelta()
+	var script string
+	if b.system == "Darwin" {
+		seconds := max(1, int((delta+time.Second-1)/time.Second))
+		script = fmt.Sprintf(darwinStatsScript, seconds)
+	} else {
+		script = fmt.Sprintf(freeBSDStatsScript, strconv.FormatFloat(delta.Seconds(), 'f', 3, 64))
+	}
+	// Failing commands leave their section empty rather than failing the sample
+	output, err := b.conn.runRemoteCommand(ctx, "LC_ALL=C; export LC_ALL; { "+script+"} 2>/dev/null; true")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run stats commands: %w", err)
+	}
+	sections := parseSections(output)
+	stats := &SystemStats{CollectionMode: CollectionSysctl}
+	if b.system == "Darwin" {
+		err = parseDarwinStats(sections, stats)
+	} else {
+		err = parseFreeBSDStats(sections, stats)
+	}
+	if err != nil {
+		return nil, err
+	}
+	parseBSDCommonStats(sections, stats)
+	return stats, nil
+}
+
+// parseSections splits output on its "@@ <name>" lines
+func parseSections(output string) map[string]string {
+	sections := make(map[string]string)
+	var name string
+	var content strings.Builder
+	for _, line := range strings.Split(output, "\n") {
+		if header, ok := strings.CutPrefix(line, "@@ "); ok {
+			if name != "" {
+				sections[name] = content.String()
+			}
+			name = header
+			content.Reset()
+			continue
+		}
+		content.WriteString(line)
+		content.WriteByte('\n')
+	}
+	if name != "" {
+		sections[name] = content.String()
+	}
+	return sections
+}
+
+var (
+	darwinCPUPattern  = regexp.MustCompile(`([\d.]+)% user, ([\d.]+)% sys, ([\d.]+)% idle`)
+	vmStatPagePattern = regexp.MustCompile(`page size of (\d+) bytes`)
+	swapUsagePattern  = regexp.MustCompile(`total = ([\d.]+)M\s+used = ([\d.]+)M`)
+)
+
+func parseDarwinStats(sections map[string]string, stats *SystemStats) error {
+	// The first sample of top covers the time since boot, only the last one is kept
+	matches := darwinCPUPattern.FindAllStringSubmatch(sections["top"], -1)
+	if len(matches) == 0 {
+		return fmt.Errorf("unexpected top output: %q", sections["top"])
+	}
+	last := matches[len(matches)-1]
+	user, _ := strconv.ParseFloat(last[1], 64)
+	system, _ := strconv.ParseFloat(last[2], 64)
+	idle, _ := strconv.ParseFloat(last[3], 64)
+	stats.CPUModes = CPUModeBreakdown{User: user, System: system, Idle: idle}
+	stats.TotalCPUPercentage = clampPercent(100 - idle)
+
+	memsize, err := strconv.ParseFloat(strings.TrimSpace(sections["memsize"]), 64)
+	if err != nil {
+		return fmt.Errorf("unexpected hw.memsize: %q", sections["memsize"])
+	}
+	pageSize := 4096.0
+	if m := vmStatPagePattern.FindStringSubmatch(sections["vm_stat"]); m != nil {
+		pageSize, _ = strconv.ParseFloat(m[1], 64)
+	}
+	pages := make(map[string]float64)
+	for _, line := range strings.Split(sections["vm_stat"], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "."), 64); err == nil {
+			pages[strings.TrimSpace(key)] = v
+		}
+	}
+	// Free, inactive and speculative pages can be reclaimed without swapping, like MemAvailable
+	available := (pages["Pages free"] + pages["Pages inactive"] + pages["Pages speculative"]) * pageSize
+	setBSDMemory(stats, memsize, memsize-available)
+
+	if m := swapUsagePattern.FindStringSubmatch(sections["swap"]); m != nil {
+		total, _ := strconv.ParseFloat(m[1], 64)
+		used, _ := strconv.ParseFloat(m[2], 64)
+		setBSDSwap(stats, total, used)
+	}
+	return nil
+}
+
+func parseFreeBSDStats(sections map[string]string, stats *SystemStats) error {
+	before, after := parseTicks(sections["cp_time1"]), parseTicks(sections["cp_time2"])
+	if len(before) != 5 || len(after) != 5 {
+		return fmt.Errorf("unexpected kern.cp_time: %q", sections["cp_time2"])
+	}
+	stats.TotalCPUPercentage, stats.CPUModes = computeBSDTicks(before, after)
+	// kern.cp_times has the same 5 counters for every core in turn
+	coresBefore, coresAfter := parseTicks(sections["cp_times1"]), parseTicks(sections["cp_times2"])
+	if len(coresBefore) == len(coresAfter) && len(coresAfter)%5 == 0 {
+		for i := 0; i < len(coresAfter); i += 5 {
+			usage, _ := computeBSDTicks(coresBefore[i:i+5], coresAfter[i:i+5])
+			stats.CPUStats = append(stats.CPUStats, CPUStat{Core: fmt.Sprintf("cpu%d", i/5), UsagePct: usage})
+		}
+	}
+
+	physmem, err := strconv.ParseFloat(strings.TrimSpace(sections["physmem"]), 64)
+	if err != nil {
+		return fmt.Errorf("unexpected hw.physmem: %q", sections["physmem"])
+	}
+	vm := make(map[string]float64)
+	for _, line := range strings.Split(sections["vm"], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			vm[strings.TrimPrefix(strings.TrimSpace(key), "vm.stats.vm.")] = v
+		}
+	}
+	available := (vm["v_free_count"] + vm["v_inactive_count"] + vm["v_cache_count"] + vm["v_laundry_count"]) * vm["hw.pagesize"]
+	setBSDMemory(stats, physmem, physmem-available)
+
+	// swapctl -sk prints "Total: <kB> <kB used>"
+	for _, line := range strings.Split(sections["swap"], "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && strings.EqualFold(fields[0], "total:") {
+			total, _ := strconv.ParseFloat(fields[1], 64)
+			used, _ := strconv.ParseFloat(fields[2], 64)
+			setBSDSwap(stats, total/1024, used/1024)
+		}
+	}
+	return nil
+}
+
+// parseTicks parses space separated tick counters
+func parseTicks(s string) []float64 {
+	var ticks []float64
+	for _, field := range strings.Fields(s) {
+		if v, err := strconv.ParseFloat(field, 64); err == nil {
+			ticks = append(ticks, v)
+		}
+	}
+	return ticks
+}
+
+// computeBSDTicks computes the usage and modes from two snapshots of user, nice, sys, intr and idle ticks
+func computeBSDTicks(before, after []float64) (float64, CPUModeBreakdown) {
+	deltas := make([]float64, 5)
+	var total float64
+	for i := range deltas {
+		deltas[i] = after[i] - before[i]
+		total += deltas[i]
+	}
+	if total <= 0 {
+		return 0, CPUModeBreakdown{}
+	}
+	modes := CPUModeBreakdown{
+		User:   deltas[0] / total * 100,
+		Nice:   deltas[1] / total * 100,
+		System: deltas[2] / total * 100,
+		IRQ:    deltas[3] / total * 100,
+		Idle:   deltas[4] / total * 100,
+	}
+	return clampPercent(100 - modes.Idle), modes
+}
+
+func setBSDMemory(stats *SystemStats, totalBytes, usedBytes float64) {
+	stats.TotalMemoryMB = totalBytes / 1024 / 1024
+	stats.UsedMemoryMB = max(0, usedBytes) / 1024 / 1024
+	if stats.TotalMemoryMB > 0 {
+		stats.UsedMemoryPercent = stats.UsedMemoryMB / stats.TotalMemoryMB * 100
+	}
+}
+
+func setBSDSwap(stats *SystemStats, totalMB, usedMB float64) {
+	stats.SwapTotalMB = totalMB
+	stats.SwapUsedMB = usedMB
+	if totalMB > 0 {
+		stats.SwapUsedPercent = usedMB / totalMB * 100
+	}
+}
+
+var boottimePattern = regexp.MustCompile(`sec = (\d+)`)
+
+// parseBSDCommonStats parses the load average, uptime, disk usage and process states
+func parseBSDCommonStats(sections map[string]string, stats *SystemStats) {
+	// vm.loadavg is "{ 1.23 1.45 1.67 }"
+	if loads := parseTicks(strings.Trim(strings.TrimSpace(sections["loadavg"]), "{}")); len(loads) >= 3 {
+		stats.LoadAvg.Load1, stats.LoadAvg.Load5, stats.LoadAvg.Load15 = loads[0], loads[1], loads[2]
+	}
+
+	now, nowErr := strconv.ParseFloat(strings.TrimSpace(sections["now"]), 64)
+	if m := boottimePattern.FindStringSubmatch(sections["boottime"]); m != nil && nowErr == nil {
+		boot, _ := strconv.ParseFloat(m[1], 64)
+		stats.Uptime = &UptimeStats{UptimeSeconds: now - boot}
+	}
+
+	// df -kP: filesystem, 1024-blocks, used, available, capacity, mount point (which may contain spaces)
+	for _, line := range strings.Split(sections["df"], "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		total, _ := strconv.ParseFloat(fields[1], 64)
+		used, _ := strconv.ParseFloat(fields[2], 64)
+		free, _ := strconv.ParseFloat(fields[3], 64)
+		disk := DiskUsage{
+			MountPoint: strings.Join(fields[5:], " "),
+			Device:     fields[0],
+			TotalMB:    total / 1024,
+			UsedMB:     used / 1024,
+			FreeMB:     free / 1024,
+		}
+		if used+free > 0 {
+			disk.UsedPercent = used / (used + free) * 100
+		}
+		stats.DiskUsage = append(stats.DiskUsage, disk)
+	}
+
+	for _, state := range strings.Fields(sections["ps"]) {
+		stats.Processes.Processes++
+		switch state[0] {
+		case 'R':
+			stats.Processes.Running++
+		case 'Z':
+			stats.Processes.Zombie++
+		case 'D', 'U': // disk wait on FreeBSD, uninterruptible on macOS
+			stats.Processes.Uninterruptible++
+		}
+	}
+	stats.LoadAvg.RunnableProcs = stats.Processes.Running
+	stats.LoadAvg.TotalProcs = stats.Processes.Processes
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BuddyZoneStat holds the free page counts of a memory zone from /proc/buddyinfo
+type BuddyZoneStat struct {
+	Node      int
+	Zone      string   // e.g., "DMA32", "Normal"
+	FreePages []uint64 // free blocks per order: index n counts blocks of 2^n pages
+}
+
+// SetBuddyinfoEnabled enables or disables collection of /proc/buddyinfo
+func (r *remoteStatsCollector) SetBuddyinfoEnabled(enabled bool) {
+	r.buddyinfoEnabled = enabled
+}
+
+// IsBuddyinfoEnabled returns whether /proc/buddyinfo is collected
+func (r *remoteStatsCollector) IsBuddyinfoEnabled() bool {
+	return r.buddyinfoEnabled
+}
+
+func (r *remoteStatsCollector) getBuddyinfo(ctx context.Context) ([]BuddyZoneStat, error) {
+	content, err := r.readRemoteFile(ctx, "/proc/buddyinfo")
+	if err != nil {
+		return nil, err
+	}
+	return parseBuddyinfo(content)
+}
+
+// parseBuddyinfo parses lines like "Node 0, zone   Normal   216   143    92 ..."
+func parseBuddyinfo(content string) ([]BuddyZoneStat, error) {
+	var zones []BuddyZoneStat
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "Node" || fields[2] != "zone" {
+			continue
+		}
+		node, err := strconv.Atoi(strings.TrimSuffix(fields[1], ","))
+		if err != nil {
+			return nil, fmt.Errorf("invalid node in buddyinfo: %q", line)
+		}
+		zone := BuddyZoneStat{Node: node, Zone: fields[3]}
+		for _, f := range fields[4:] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid free page count in buddyinfo: %q", line)
+			}
+			zone.FreePages = append(zone.FreePages, v)
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
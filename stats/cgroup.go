@@ -0,0 +1,183 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// CgroupStats holds the resource usage of a single cgroup v2
+type CgroupStats struct {
+	Path          string // relative to /sys/fs/cgroup, e.g., "system.slice/nginx.service"
+	CPUUsageUsec  uint64 // cumulative, from cpu.stat
+	CPUUserUsec   uint64
+	CPUSystemUsec uint64
+	CPUPercent    float64 // since the previous collection, 100% is one full core
+	NrThrottled   uint64
+	ThrottledUsec uint64
+	MemoryCurrent uint64 // bytes
+	MemoryMax     uint64 // bytes, zero when unlimited
+	MemoryPercent float64
+	IOReadBytes   uint64 // cumulative over all devices, from io.stat
+	IOWriteBytes  uint64
+	IOReadOps     uint64
+	IOWriteOps    uint64
+}
+
+// CgroupCollector reads per-cgroup usage under /sys/fs/cgroup over SFTP
+type CgroupCollector struct {
+	readFile  func(ctx context.Context, path string) (string, error)
+	paths     []string
+	prevUsage map[string]uint64
+	prevTime  map[string]time.Time
+}
+
+// NewCgroupCollector creates a collector for the given cgroup paths,
+// either absolute ("/sys/fs/cgroup/system.slice") or relative to /sys/fs/cgroup
+func NewCgroupCollector(sftpClient *sftp.Client, paths []string) *CgroupCollector {
+	normalized := make([]string, 0, len(paths))
+	for _, p := range paths {
+		p = strings.TrimPrefix(path.Clean("/"+p), cgroupRoot)
+		normalized = append(normalized, strings.TrimPrefix(p, "/"))
+	}
+	return &CgroupCollector{
+		readFile: func(ctx context.Context, path string) (string, error) {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			return readSFTPFile(sftpClient, path)
+		},
+		paths:     normalized,
+		prevUsage: make(map[string]uint64),
+		prevTime:  make(map[string]time.Time),
+	}
+}
+
+// Paths returns the monitored cgroup paths, relative to /sys/fs/cgroup
+func (c *CgroupCollector) Paths() []string {
+	return append([]string(nil), c.paths...)
+}
+
+// Collect reads the usage of every configured cgroup
+func (c *CgroupCollector) Collect(ctx context.Context) ([]CgroupStats, error) {
+	stats := make([]CgroupStats, 0, len(c.paths))
+	for _, p := range c.paths {
+		s, err := c.collectOne(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("cgroup %q: %w", p, err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func (c *CgroupCollector) collectOne(ctx context.Context, p string) (CgroupStats, error) {
+	dir := path.Join(cgroupRoot, p)
+	stats := CgroupStats{Path: p}
+
+	cpuStat, err := c.readFile(ctx, path.Join(dir, "cpu.stat"))
+	if err != nil {
+		return CgroupStats{}, fmt.Errorf("failed to read cpu.stat: %w", err)
+	}
+	cpu := parseFlatKeyed(cpuStat)
+	stats.CPUUsageUsec = cpu["usage_usec"]
+	stats.CPUUserUsec = cpu["user_usec"]
+	stats.CPUSystemUsec = cpu["system_usec"]
+	stats.NrThrottled = cpu["nr_throttled"]
+	stats.ThrottledUsec = cpu["throttled_usec"]
+
+	now := time.Now()
+	if prevTime, ok := c.prevTime[p]; ok {
+		elapsed := now.Sub(prevTime)
+		if elapsed > 0 {
+			delta := counterDelta(c.prevUsage[p], stats.CPUUsageUsec)
+			stats.CPUPercent = float64(delta) / float64(elapsed.Microseconds()) * 100.0
+		}
+	}
+	c.prevUsage[p] = stats.CPUUsageUsec
+	c.prevTime[p] = now
+
+	current, err := c.readFile(ctx, path.Join(dir, "memory.current"))
+	if err != nil {
+		return CgroupStats{}, fmt.Errorf("failed to read memory.current: %w", err)
+	}
+	if stats.MemoryCurrent, err = strconv.ParseUint(strings.TrimSpace(current), 10, 64); err != nil {
+		return CgroupStats{}, fmt.Errorf("failed to parse memory.current: %w", err)
+	}
+
+	// memory.max does not exist on the root cgroup
+	if max, err := c.readFile(ctx, path.Join(dir, "memory.max")); err == nil {
+		if max = strings.TrimSpace(max); max != "max" {
+			if stats.MemoryMax, err = strconv.ParseUint(max, 10, 64); err != nil {
+				return CgroupStats{}, fmt.Errorf("failed to parse memory.max: %w", err)
+			}
+		}
+	}
+	if stats.MemoryMax > 0 {
+		stats.MemoryPercent = float64(stats.MemoryCurrent) / float64(stats.MemoryMax) * 100.0
+	}
+
+	// io.stat only exists when the io controller is enabled
+	if ioStat, err := c.readFile(ctx, path.Join(dir, "io.stat")); err == nil {
+		for _, line := range strings.Split(ioStat, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			// First field is the "major:minor" device
+			io := parseFlatKeyed(strings.Join(fields[1:], "\n"))
+			stats.IOReadBytes += io["rbytes"]
+			stats.IOWriteBytes += io["wbytes"]
+			stats.IOReadOps += io["rios"]
+			stats.IOWriteOps += io["wios"]
+		}
+	}
+
+	return stats, nil
+}
+
+// parseFlatKeyed parses cgroup files made of "key value" lines or "key=value" tokens
+func parseFlatKeyed(content string) map[string]uint64 {
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			key, value = fields[0], fields[1]
+		}
+		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+			values[key] = v
+		}
+	}
+	return values
+}
+
+// SetCgroupPaths sets the cgroups whose usage is reported on every sample (nil disables cgroup collection)
+func (r *remoteStatsCollector) SetCgroupPaths(paths []string) {
+	if len(paths) == 0 {
+		r.cgroups = nil
+		return
+	}
+	r.cgroups = NewCgroupCollector(r.sftpClient, paths)
+	// Read through the collector, which follows reconnects and other transports
+	r.cgroups.readFile = r.readRemoteFile
+}
+
+// GetCgroupPaths returns the cgroups whose usage is reported
+func (r *remoteStatsCollector) GetCgroupPaths() []string {
+	if r.cgroups == nil {
+		return nil
+	}
+	return r.cgroups.Paths()
+}
@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// circuitBreaker stops a monitor from collecting from a host that keeps failing: after threshold
+// consecutive failures the circuit opens and only probe collections are made, backing off from
+// the interval up to maxBackoff, until one succeeds
+type circuitBreaker struct {
+	mu         sync.Mutex
+	threshold  int // consecutive failures opening the circuit, 0 disables the breaker
+	maxBackoff time.Duration
+	open       bool
+	openedAt   time.Time
+	backoff    time.Duration // delay between the current probes
+	nextProbe  time.Time
+}
+
+// SetCircuitBreaker opens the circuit of the host after threshold consecutive failed collections
+// (0, the default, disables it). While it is open a single "host_down" event is emitted instead of
+// an error every cycle, and the host is only probed: first after an interval, then after twice the
+// previous delay, up to maxBackoff. The first successful probe closes the circuit with a "host_up"
+// event. Monitors of a MonitorPool are probed on the cycles of the pool.
+func (m *RemoteStatsMonitor) SetCircuitBreaker(threshold int, maxBackoff time.Duration) {
+	m.breaker.mu.Lock()
+	defer m.breaker.mu.Unlock()
+	m.breaker.threshold = threshold
+	m.breaker.maxBackoff = maxBackoff
+}
+
+// GetCircuitBreaker returns the failures opening the circuit and the longest delay between probes
+func (m *RemoteStatsMonitor) GetCircuitBreaker() (threshold int, maxBackoff time.Duration) {
+	m.breaker.mu.Lock()
+	defer m.breaker.mu.Unlock()
+	return m.breaker.threshold, m.breaker.maxBackoff
+}
+
+// CircuitOpen returns whether the host is considered down and only probed
+func (m *RemoteStatsMonitor) CircuitOpen() bool {
+	m.breaker.mu.Lock()
+	defer m.breaker.mu.Unlock()
+	return m.breaker.open
+}
+
+// collectionDue returns whether a collection should be attempted at now, false while the circuit
+// is open and the next probe is not due
+func (m *RemoteStatsMonitor) collectionDue(now time.Time) bool {
+	m.breaker.mu.Lock()
+	defer m.breaker.mu.Unlock()
+	return !m.breaker.open || !now.Before(m.breaker.nextProbe)
+}
+
+// updateBreaker opens or closes the circuit after a collection that returned err, returning the
+// error to report: the one opening the circuit, none while it stays open
+func (m *RemoteStatsMonitor) updateBreaker(err error) error {
+	health := m.GetHealth()
+	b := &m.breaker
+	b.mu.Lock()
+	if b.threshold <= 0 {
+		b.mu.Unlock()
+		return err
+	}
+	now := time.Now()
+	switch {
+	case health.ConsecutiveFailures == 0:
+		if !b.open {
+			b.mu.Unlock()
+			return err
+		}
+		b.open = false
+		downFor := now.Sub(b.openedAt)
+		b.mu.Unlock()
+		m.logEvent("host_up", map[string]any{"down_seconds": downFor.Seconds()})
+		return err
+	case health.ConsecutiveFailures < uint64(b.threshold):
+		b.mu.Unlock()
+		return err
+	case b.open:
+		// A failed probe
+		b.backoff = min(2*b.backoff, max(b.maxBackoff, m.GetInterval()))
+		b.nextProbe = now.Add(b.backoff)
+		b.mu.Unlock()
+		return nil
+	}
+	b.open = true
+	b.openedAt = now
+	b.backoff = m.GetInterval()
+	b.nextProbe = now.Add(b.backoff)
+	b.mu.Unlock()
+	m.logEvent("host_down", map[string]any{
+		"consecutive_failures": health.ConsecutiveFailures,
+		"error":                health.LastError,
+	})
+	return fmt.Errorf("host is down after %d consecutive failures, probing until it recovers: %w", health.ConsecutiveFailures, err)
+}
+
+// collectScheduled runs the collection of a cycle unless the circuit is open, returning the error to report
+func (m *RemoteStatsMonitor) collectScheduled(ctx context.Context) error {
+	if !m.collectionDue(time.Now()) {
+		return nil
+	}
+	err := m.collectCycle(ctx)
+	if ctx.Err() != nil {
+		return err
+	}
+	return m.updateBreaker(err)
+}
@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"context"
+	"time"
+)
+
+// StatsCollector is a source of SystemStats for a monitor. The remote collectors returned by the
+// NewRemoteStatsCollector* functions and LocalStatsCollector implement it; other implementations
+// can be monitored with NewStatsMonitor. A collection gives up, returning the context error, once
+// ctx is cancelled or its deadline passes, including while waiting on the host. A collector may
+// return a sample together with a *PartialError when only some of its metric groups failed.
+type StatsCollector interface {
+	GetSystemStats(ctx context.Context) (*SystemStats, error)
+	Close() error
+	SetSampleDelta(sampleDelta time.Duration)
+}
+
+var (
+	_ StatsCollector = (*remoteStatsCollector)(nil)
+	_ StatsCollector = (*LocalStatsCollector)(nil)
+)
+
+// reconnector is implemented by collectors that can detect and recover from a lost connection
+type reconnector interface {
+	CanReconnect() bool
+	IsConnected() bool
+	Reconnect() error
+}
+
+// pinger is implemented by collectors whose connection can be probed by keepalives
+type pinger interface {
+	Ping(timeout time.Duration) (time.Duration, error)
+	dropConnection()
+}
+
+// linuxCollector is implemented by the /proc based collectors (also through embedding), whose
+// optional metric groups are configured through the monitor
+type linuxCollector interface {
+	linux() *remoteStatsCollector
+}
+
+func (r *remoteStatsCollector) linux() *remoteStatsCollector {
+	return r
+}
+
+// LocalStatsCollector collects the stats of the machine the program runs on from the local /proc
+// and /sys, without an SSH connection. It supports the same optional metric groups as the remote
+// collector; commands (e.g., for GPUs or systemd) are run locally.
+type LocalStatsCollector struct {
+	*remoteStatsCollector
+}
+
+// NewLocalStatsCollector creates a collector for the local machine
+func NewLocalStatsCollector(sampleDelta time.Duration) *LocalStatsCollector {
+	collector := NewRemoteStatsCollectorFromSFTP(nil, sampleDelta)
+	collector.transport = localTransport{}
+	return &LocalStatsCollector{collector}
+}
@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CommandMetricStat is the value parsed from the output of a command set with AddCommandMetric
+type CommandMetricStat struct {
+	Name  string
+	Value float64
+	Error string // set when the command failed or its output could not be parsed
+}
+
+// commandMetric is a command run on every sample and the parser of its output
+type commandMetric struct {
+	name   string
+	cmd    string
+	parser func(string) (float64, error)
+}
+
+// AddCommandMetric runs cmd on the host on every sample (e.g., "redis-cli info | grep ^connected_clients")
+// and reports the value parser extracts from its output as the metric name. A nil parser parses the
+// trimmed output as a number, see ParseFloatOutput. A failing command is reported in the Error field of
+// its CommandMetricStat without failing the sample.
+func (r *remoteStatsCollector) AddCommandMetric(name, cmd string, parser func(string) (float64, error)) error {
+	if name == "" {
+		return fmt.Errorf("command metric name must not be empty")
+	}
+	for _, metric := range r.commandMetrics {
+		if metric.name == name {
+			return fmt.Errorf("command metric %q already exists", name)
+		}
+	}
+	if parser == nil {
+		parser = ParseFloatOutput
+	}
+	r.commandMetrics = append(r.commandMetrics, commandMetric{name: name, cmd: cmd, parser: parser})
+	return nil
+}
+
+// RemoveCommandMetric stops running the command of the metric name
+func (r *remoteStatsCollector) RemoveCommandMetric(name string) {
+	for i, metric := range r.commandMetrics {
+		if metric.name == name {
+			r.commandMetrics = append(r.commandMetrics[:i:i], r.commandMetrics[i+1:]...)
+			return
+		}
+	}
+}
+
+// ParseFloatOutput parses the whole output of a command, surrounding whitespace aside, as a number
+func ParseFloatOutput(output string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(output), 64)
+}
+
+// getCommandMetrics runs the commands of the metrics in the order they were added
+func (r *remoteStatsCollector) getCommandMetrics(ctx context.Context) []CommandMetricStat {
+	results := make([]CommandMetricStat, 0, len(r.commandMetrics))
+	for _, metric := range r.commandMetrics {
+		stat := CommandMetricStat{Name: metric.name}
+		output, err := r.runRemoteCommand(ctx, metric.cmd)
+		if err == nil {
+			stat.Value, err = metric.parser(output)
+		}
+		if err != nil {
+			stat.Error = err.Error()
+		}
+		results = append(results, stat)
+	}
+	return results
+}
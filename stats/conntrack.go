@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ConntrackStats holds the netfilter connection tracking table usage
+type ConntrackStats struct {
+	Count       uint64
+	Max         uint64
+	UsedPercent float64
+}
+
+// getConntrackStats reads nf_conntrack_count and nf_conntrack_max.
+// It returns nil without an error when the nf_conntrack module is not loaded.
+func (r *remoteStatsCollector) getConntrackStats(ctx context.Context) (*ConntrackStats, error) {
+	read := func(name string) (uint64, error) {
+		content, err := r.readRemoteFile(ctx, "/proc/sys/net/netfilter/"+name)
+		if err != nil {
+			return 0, err
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(content), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		return v, nil
+	}
+
+	count, err := read("nf_conntrack_count")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	max, err := read("nf_conntrack_max")
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ConntrackStats{Count: count, Max: max}
+	if max > 0 {
+		stats.UsedPercent = float64(count) / float64(max) * 100.0
+	}
+	return stats, nil
+}
@@ -0,0 +1,75 @@
+package stats
+
+// CPUModeBreakdown holds the percentage of CPU time spent in each /proc/stat mode
+type CPUModeBreakdown struct {
+	User      float64
+	Nice      float64
+	System    float64
+	Idle      float64
+	IOWait    float64
+	IRQ       float64
+	SoftIRQ   float64
+	Steal     float64
+	Guest     float64 // already included in User
+	GuestNice float64 // already included in Nice
+}
+
+// Column order of the cpu lines in /proc/stat
+const (
+	cpuUser = iota
+	cpuNice
+	cpuSystem
+	cpuIdle
+	cpuIOWait
+	cpuIRQ
+	cpuSoftIRQ
+	cpuSteal
+	cpuGuest
+	cpuGuestNice
+)
+
+// SetPerCoreCPUModesEnabled enables or disables the per-mode breakdown for every core (the total is always reported)
+func (r *remoteStatsCollector) SetPerCoreCPUModesEnabled(enabled bool) {
+	r.perCoreModesEnabled = enabled
+}
+
+// IsPerCoreCPUModesEnabled returns whether the per-mode breakdown is reported for every core
+func (r *remoteStatsCollector) IsPerCoreCPUModesEnabled() bool {
+	return r.perCoreModesEnabled
+}
+
+// computeCPUModes converts two readings of a /proc/stat cpu line into per-mode percentages.
+// It returns false when no time elapsed between the readings.
+func computeCPUModes(values1, values2 []float64) (CPUModeBreakdown, bool) {
+	delta := func(i int) float64 {
+		if i >= len(values1) || values2[i] < values1[i] {
+			return 0
+		}
+		return values2[i] - values1[i]
+	}
+
+	// Guest time is already accounted in user and nice, so it is left out of the total
+	var deltaTotal float64
+	for i := cpuUser; i <= cpuSteal && i < len(values1); i++ {
+		deltaTotal += delta(i)
+	}
+	if deltaTotal == 0 {
+		return CPUModeBreakdown{}, false
+	}
+
+	pct := func(i int) float64 {
+		return delta(i) / deltaTotal * 100.0
+	}
+	return CPUModeBreakdown{
+		User:      pct(cpuUser),
+		Nice:      pct(cpuNice),
+		System:    pct(cpuSystem),
+		Idle:      pct(cpuIdle),
+		IOWait:    pct(cpuIOWait),
+		IRQ:       pct(cpuIRQ),
+		SoftIRQ:   pct(cpuSoftIRQ),
+		Steal:     pct(cpuSteal),
+		Guest:     pct(cpuGuest),
+		GuestNice: pct(cpuGuestNice),
+	}, true
+}
@@ -0,0 +1,164 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// csvBaseColumns are the fixed leading columns of every CSV row, followed by one column per core
+var csvBaseColumns = []string{
+	"timestamp",
+	"total_memory_mb",
+	"used_memory_mb",
+	"used_memory_percent",
+	"total_cpu_percentage",
+}
+
+// CSVHeader returns the CSV column names for the given number of cores (cpu0_percent, cpu1_percent, ...)
+func CSVHeader(cores int) []string {
+	header := append([]string{}, csvBaseColumns...)
+	for i := 0; i < cores; i++ {
+		header = append(header, fmt.Sprintf("cpu%d_percent", i))
+	}
+	return header
+}
+
+// csvCoreCount returns the number of per-core columns needed for stats (highest core index + 1)
+func csvCoreCount(stats *SystemStats) int {
+	cores := 0
+	for _, cpu := range stats.CPUStats {
+		if idx, ok := csvCoreIndex(cpu.Core); ok && idx+1 > cores {
+			cores = idx + 1
+		}
+	}
+	return cores
+}
+
+func csvCoreIndex(core string) (int, bool) {
+	idx, err := strconv.Atoi(strings.TrimPrefix(core, "cpu"))
+	if err != nil || idx < 0 {
+		return 0, false
+	}
+	return idx, true
+}
+
+func csvFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// csvRecord builds a row with exactly len(CSVHeader(cores)) fields. Cores missing from stats are left
+// empty and cores beyond the column count are dropped, so rows always line up with the header.
+func csvRecord(stats *SystemStats, timestamp time.Time, cores int) []string {
+	record := []string{
+		timestamp.Format("15:04:05.000000"),
+		csvFloat(stats.TotalMemoryMB),
+		csvFloat(stats.UsedMemoryMB),
+		csvFloat(stats.UsedMemoryPercent),
+		csvFloat(stats.TotalCPUPercentage),
+	}
+	perCore := make([]string, cores)
+	for _, cpu := range stats.CPUStats {
+		if idx, ok := csvCoreIndex(cpu.Core); ok && idx < cores {
+			perCore[idx] = csvFloat(cpu.UsagePct)
+		}
+	}
+	return append(record, perCore...)
+}
+
+func encodeCSV(records ...[]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	// The logger adds its own newline
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// NewCSVLogLine returns a logLineFunc that formats stats as CSV rows, for use with SetLogLineFunc.
+// The first line it returns is preceded by the header. cores fixes the number of per-core columns;
+// if it is 0, the core count of the first sample is used.
+func NewCSVLogLine(cores int) func(*SystemStats) ([]byte, error) {
+	var mu sync.Mutex
+	headerWritten := false
+	return func(stats *SystemStats) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		record := func() []string { return csvRecord(stats, time.Now(), cores) }
+		if headerWritten {
+			return encodeCSV(record())
+		}
+		if cores <= 0 {
+			cores = csvCoreCount(stats)
+		}
+		headerWritten = true
+		return encodeCSV(CSVHeader(cores), record())
+	}
+}
+
+// CSVWriter writes samples as CSV rows to an io.Writer, emitting the header once before the first row
+type CSVWriter struct {
+	mu            sync.Mutex
+	w             *csv.Writer
+	closer        io.Closer
+	cores         int
+	headerWritten bool
+}
+
+// NewCSVWriter creates a CSVWriter. cores fixes the number of per-core columns; if it is 0,
+// the core count of the first sample is used. If w is an io.Closer, Close closes it.
+func NewCSVWriter(w io.Writer, cores int) *CSVWriter {
+	writer := &CSVWriter{w: csv.NewWriter(w), cores: cores}
+	if closer, ok := w.(io.Closer); ok {
+		writer.closer = closer
+	}
+	return writer
+}
+
+// Write writes stats as a single CSV row stamped with timestamp
+func (c *CSVWriter) Write(stats *SystemStats, timestamp time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.headerWritten {
+		if c.cores <= 0 {
+			c.cores = csvCoreCount(stats)
+		}
+		if err := c.w.Write(CSVHeader(c.cores)); err != nil {
+			return fmt.Errorf("failed to write csv header: %w", err)
+		}
+		c.headerWritten = true
+	}
+	if err := c.w.Write(csvRecord(stats, timestamp, c.cores)); err != nil {
+		return fmt.Errorf("failed to write csv row: %w", err)
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// WriteSample writes a sample as a CSV row, so a CSVWriter can be used as a sample handler
+func (c *CSVWriter) WriteSample(sample *Sample) error {
+	return c.Write(sample.Stats, sample.Timestamp)
+}
+
+// Close flushes the writer and closes the underlying writer if it is an io.Closer
+func (c *CSVWriter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return err
+	}
+	if c.closer != nil {
+		return c.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	defaultDashboardRefresh = time.Second
+	dashboardHistory        = 120 // samples kept per host for the sparklines
+	dashboardDefaultWidth   = 100
+)
+
+// sparkLevels are the block characters of a sparkline, from lowest to highest
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// dashboardHost is the recent history of one host
+type dashboardHost struct {
+	latest *Sample
+	cpu    []float64
+	memory []float64
+}
+
+// TerminalDashboard is an htop-like live view of one or more hosts, redrawn in place on a terminal:
+// per-core CPU bars, memory and swap gauges and CPU/memory sparklines. Add it to a monitor
+// (or several) with AddSink as an alternative to PrintSystemStats.
+type TerminalDashboard struct {
+	mu       sync.Mutex
+	w        io.Writer
+	refresh  time.Duration
+	hosts    map[string]*dashboardHost
+	drawnAt  time.Time
+	started  bool
+	colorful bool
+}
+
+// NewTerminalDashboard creates a dashboard drawing to w, usually os.Stdout
+func NewTerminalDashboard(w io.Writer) *TerminalDashboard {
+	return &TerminalDashboard{
+		w:        w,
+		refresh:  defaultDashboardRefresh,
+		hosts:    make(map[string]*dashboardHost),
+		colorful: true,
+	}
+}
+
+// SetRefreshInterval limits how often the screen is redrawn
+func (d *TerminalDashboard) SetRefreshInterval(interval time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.refresh = interval
+}
+
+// SetColorEnabled enables or disables colored bars (on by default)
+func (d *TerminalDashboard) SetColorEnabled(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.colorful = enabled
+}
+
+// WriteSample records the sample and redraws the screen if the refresh interval has passed
+func (d *TerminalDashboard) WriteSample(sample *Sample) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	host, ok := d.hosts[sample.Host]
+	if !ok {
+		host = &dashboardHost{}
+		d.hosts[sample.Host] = host
+	}
+	host.latest = sample
+	host.cpu = appendHistory(host.cpu, sample.Stats.TotalCPUPercentage)
+	host.memory = appendHistory(host.memory, sample.Stats.UsedMemoryPercent)
+
+	if time.Since(d.drawnAt) < d.refresh {
+		return nil
+	}
+	d.drawnAt = time.Now()
+	return d.draw()
+}
+
+func appendHistory(history []float64, value float64) []float64 {
+	history = append(history, value)
+	if len(history) > dashboardHistory {
+		history = history[len(history)-dashboardHistory:]
+	}
+	return history
+}
+
+func (d *TerminalDashboard) width() int {
+	if f, ok := d.w.(*os.File); ok {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	return dashboardDefaultWidth
+}
+
+// draw renders every host, sorted by name, into a single write
+func (d *TerminalDashboard) draw() error {
+	var buf bytes.Buffer
+	if !d.started {
+		// Switch to the alternate screen and hide the cursor, restored by Close
+		buf.WriteString("\x1b[?1049h\x1b[?25l")
+		d.started = true
+	}
+	buf.WriteString("\x1b[H\x1b[2J")
+
+	width := d.width()
+	names := make([]string, 0, len(d.hosts))
+	for name := range d.hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d.drawHost(&buf, name, d.hosts[name], width)
+	}
+
+	_, err := d.w.Write(buf.Bytes())
+	return err
+}
+
+func (d *TerminalDashboard) drawHost(buf *bytes.Buffer, name string, host *dashboardHost, width int) {
+	stats := host.latest.Stats
+	fmt.Fprintf(buf, "\x1b[1m%s\x1b[0m  %s  load %.2f %.2f %.2f\r\n", name,
+		host.latest.Timestamp.Format("15:04:05"), stats.LoadAvg.Load1, stats.LoadAvg.Load5, stats.LoadAvg.Load15)
+
+	// label (6) + " [" + bar + "] " + value (7)
+	barWidth := max(10, width-18)
+	fmt.Fprintf(buf, "%-6s [%s] %6.1f%%\r\n", "CPU", d.bar(stats.TotalCPUPercentage, barWidth), stats.TotalCPUPercentage)
+	fmt.Fprintf(buf, "%-6s [%s] %6.1f%%  %.0f/%.0f MB\r\n", "Mem", d.bar(stats.UsedMemoryPercent, barWidth-14),
+		stats.UsedMemoryPercent, stats.UsedMemoryMB, stats.TotalMemoryMB)
+	if stats.SwapTotalMB > 0 {
+		fmt.Fprintf(buf, "%-6s [%s] %6.1f%%  %.0f/%.0f MB\r\n", "Swap", d.bar(stats.SwapUsedPercent, barWidth-14),
+			stats.SwapUsedPercent, stats.SwapUsedMB, stats.SwapTotalMB)
+	}
+
+	// Per-core bars, as many columns as fit
+	cores := append([]CPUStat{}, stats.CPUStats...)
+	sort.Slice(cores, func(i, j int) bool {
+		a, _ := csvCoreIndex(cores[i].Core)
+		b, _ := csvCoreIndex(cores[j].Core)
+		return a < b
+	})
+	const coreCell = 32 // "cpu12 [" + 16 + "] 100.0% "
+	columns := max(1, width/coreCell)
+	for i, core := range cores {
+		fmt.Fprintf(buf, "%-5s [%s] %5.1f%% ", core.Core, d.bar(core.UsagePct, 16), core.UsagePct)
+		if (i+1)%columns == 0 || i == len(cores)-1 {
+			buf.WriteString("\r\n")
+		}
+	}
+
+	sparkWidth := max(10, width-8)
+	fmt.Fprintf(buf, "%-6s %s\r\n", "cpu", sparkline(host.cpu, sparkWidth))
+	fmt.Fprintf(buf, "%-6s %s\r\n\r\n", "mem", sparkline(host.memory, sparkWidth))
+}
+
+// bar renders percent as a bar of width cells, green/yellow/red by level when colors are enabled
+func (d *TerminalDashboard) bar(percent float64, width int) string {
+	width = max(1, width)
+	filled := int(percent / 100 * float64(width))
+	filled = min(max(filled, 0), width)
+	bar := strings.Repeat("|", filled) + strings.Repeat(" ", width-filled)
+	if !d.colorful {
+		return bar
+	}
+	color := "32"
+	switch {
+	case percent >= 90:
+		color = "31"
+	case percent >= 70:
+		color = "33"
+	}
+	return "\x1b[" + color + "m" + bar + "\x1b[0m"
+}
+
+// sparkline renders the last width percentages (0-100) as block characters
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		level := int(v / 100 * float64(len(sparkLevels)-1))
+		level = min(max(level, 0), len(sparkLevels)-1)
+		sb.WriteRune(sparkLevels[level])
+	}
+	return sb.String()
+}
+
+// Close restores the terminal (main screen and cursor)
+func (d *TerminalDashboard) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.started {
+		return nil
+	}
+	d.started = false
+	_, err := io.WriteString(d.w, "\x1b[?25h\x1b[?1049l")
+	return err
+}
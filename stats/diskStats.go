@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+)
+
+// DiskIOStat holds the I/O activity of a block device between two snapshots
+type DiskIOStat struct {
+	Device           string // e.g., "sda", "nvme0n1"
+	ReadsCompleted   uint64
+	WritesCompleted  uint64
+	SectorsRead      uint64
+	SectorsWritten   uint64
+	IOTimeMs         uint64 // time spent doing I/O
+	ReadIOPS         float64
+	WriteIOPS        float64
+	ReadBytesPerSec  float64
+	WriteBytesPerSec float64
+	UtilPercent      float64 // share of wall time the device was busy, like iostat %util
+	AvgAwaitMs       float64 // average time per completed request, queueing included
+	AvgQueueSize     float64 // average number of requests in flight
+	IOsInProgress    uint64  // requests in flight at the second snapshot
+}
+
+// Indexes into the /proc/diskstats counters following the device name
+const (
+	diskReadsCompleted  = 0
+	diskSectorsRead     = 2
+	diskReadTimeMs      = 3
+	diskWritesCompleted = 4
+	diskSectorsWritten  = 6
+	diskWriteTimeMs     = 7
+	diskIOsInProgress   = 8
+	diskIOTimeMs        = 9
+	diskWeightedTimeMs  = 10
+	diskMinFields       = 11
+
+	// diskstats always counts 512-byte sectors regardless of the device's sector size
+	diskSectorBytes = 512
+)
+
+func (r *remoteStatsCollector) readDiskSnapshot(ctx context.Context) (map[string][]uint64, error) {
+	file, err := r.transport.Open(ctx, "/proc/diskstats")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stats := make(map[string][]uint64)
+	// The counters of every device share one backing array
+	counters := make([]uint64, 0, 512)
+	err = scanProcLines(file, func(line []byte) error {
+		_, rest := nextField(line) // major
+		_, rest = nextField(rest)  // minor
+		device, rest := nextField(rest)
+		// Loop and ram devices only add noise
+		if device == nil || bytes.HasPrefix(device, []byte("loop")) || bytes.HasPrefix(device, []byte("ram")) {
+			return nil
+		}
+		start := len(counters)
+		for field, rest := nextField(rest); field != nil; field, rest = nextField(rest) {
+			v, ok := parseUintBytes(field)
+			if !ok {
+				break
+			}
+			counters = append(counters, v)
+		}
+		if len(counters)-start < diskMinFields {
+			counters = counters[:start]
+			return nil
+		}
+		stats[internName(device)] = counters[start:len(counters):len(counters)]
+		return nil
+	})
+	return stats, err
+}
+
+// counterDelta returns b - a, treating a counter reset or wrap as no activity
+func counterDelta(a, b uint64) uint64 {
+	if b < a {
+		return 0
+	}
+	return b - a
+}
+
+func computeDiskIOStats(stat1, stat2 *procSnapshot) []DiskIOStat {
+	elapsed := stat2.taken.Sub(stat1.taken)
+	elapsedMs := float64(elapsed.Milliseconds())
+	var disks []DiskIOStat
+	for device, values1 := range stat1.disks {
+		values2, ok := stat2.disks[device]
+		if !ok {
+			continue
+		}
+		delta := func(i int) uint64 {
+			return counterDelta(values1[i], values2[i])
+		}
+		reads, writes := delta(diskReadsCompleted), delta(diskWritesCompleted)
+		sectorsRead, sectorsWritten := delta(diskSectorsRead), delta(diskSectorsWritten)
+		ioTime := delta(diskIOTimeMs)
+
+		disk := DiskIOStat{
+			Device:           device,
+			ReadsCompleted:   reads,
+			WritesCompleted:  writes,
+			SectorsRead:      sectorsRead,
+			SectorsWritten:   sectorsWritten,
+			IOTimeMs:         ioTime,
+			ReadIOPS:         ratePerSecond(reads, elapsed),
+			WriteIOPS:        ratePerSecond(writes, elapsed),
+			ReadBytesPerSec:  ratePerSecond(sectorsRead*diskSectorBytes, elapsed),
+			WriteBytesPerSec: ratePerSecond(sectorsWritten*diskSectorBytes, elapsed),
+			IOsInProgress:    values2[diskIOsInProgress],
+		}
+		if elapsedMs > 0 {
+			disk.UtilPercent = min(float64(ioTime)/elapsedMs*100.0, 100.0)
+			disk.AvgQueueSize = float64(delta(diskWeightedTimeMs)) / elapsedMs
+		}
+		if ios := reads + writes; ios > 0 {
+			disk.AvgAwaitMs = float64(delta(diskReadTimeMs)+delta(diskWriteTimeMs)) / float64(ios)
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"strings"
+)
+
+// DiskUsage holds the space usage of a single mounted filesystem
+type DiskUsage struct {
+	MountPoint  string // e.g., "/", "/home"
+	Device      string // e.g., "/dev/sda1"
+	FSType      string // e.g., "ext4"
+	TotalMB     float64
+	UsedMB      float64
+	FreeMB      float64 // space available to unprivileged users
+	UsedPercent float64
+}
+
+// mountEntry is a single line of /proc/mounts
+type mountEntry struct {
+	device     string
+	mountPoint string
+	fsType     string
+}
+
+// getMounts reads /proc/mounts and returns the block-device backed mounts, one entry per mountpoint
+func (r *remoteStatsCollector) getMounts(ctx context.Context) ([]mountEntry, error) {
+	file, err := r.transport.Open(ctx, "/proc/mounts")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []mountEntry
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		// Skip pseudo filesystems (proc, sysfs, tmpfs, cgroup, ...) like df does
+		if !strings.HasPrefix(fields[0], "/") {
+			continue
+		}
+		mountPoint := unescapeMountField(fields[1])
+		if seen[mountPoint] {
+			continue
+		}
+		seen[mountPoint] = true
+		mounts = append(mounts, mountEntry{
+			device:     unescapeMountField(fields[0]),
+			mountPoint: mountPoint,
+			fsType:     fields[2],
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountField decodes the octal escapes (e.g., "\040" for space) used in /proc/mounts
+func unescapeMountField(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			b.WriteByte((s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0'))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// getDiskUsage returns the usage of every mounted filesystem using the SFTP statvfs extension
+func (r *remoteStatsCollector) getDiskUsage(ctx context.Context) ([]DiskUsage, error) {
+	mounts, err := r.getMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]DiskUsage, 0, len(mounts))
+	for _, m := range mounts {
+		vfs, err := r.transport.StatVFS(ctx, m.mountPoint)
+		if err != nil {
+			// Mountpoint may be unreadable for the SSH user, skip it
+			continue
+		}
+		total := float64(vfs.Blocks*vfs.Frsize) / (1024 * 1024)
+		used := float64((vfs.Blocks-vfs.Bfree)*vfs.Frsize) / (1024 * 1024)
+		free := float64(vfs.Bavail*vfs.Frsize) / (1024 * 1024)
+
+		// Same formula as df: used / (used + available to non-root)
+		var percent float64
+		if used+free > 0 {
+			percent = used / (used + free) * 100.0
+		}
+		usage = append(usage, DiskUsage{
+			MountPoint:  m.mountPoint,
+			Device:      m.device,
+			FSType:      m.fsType,
+			TotalMB:     total,
+			UsedMB:      used,
+			FreeMB:      free,
+			UsedPercent: percent,
+		})
+	}
+	return usage, nil
+}
@@ -0,0 +1,212 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultElasticsearchIndex     = "sysstats-{2006.01.02}"
+	defaultElasticsearchBatchSize = 100
+	defaultElasticsearchFlushTime = 10 * time.Second
+)
+
+// indexDatePattern matches the "{<Go time layout>}" placeholders of an index pattern
+var indexDatePattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// ElasticsearchSink batches samples and indexes them through the Elasticsearch bulk API
+type ElasticsearchSink struct {
+	url           string // base URL, e.g., "http://localhost:9200"
+	indexPattern  string
+	batchSize     int
+	flushInterval time.Duration
+	headers       map[string]string
+	client        *http.Client
+	mu            sync.Mutex
+	pending       []*Sample // serialized when sent, so that their staleness is current
+	lastFlush     time.Time
+}
+
+// NewElasticsearchSink creates a sink indexing into the cluster at url. indexPattern may contain
+// Go time layouts in braces, expanded with the UTC sample time, so "sysstats-{2006.01.02}"
+// indexes into daily indices like "sysstats-2024.06.01". An empty pattern uses that default.
+func NewElasticsearchSink(url, indexPattern string) *ElasticsearchSink {
+	if indexPattern == "" {
+		indexPattern = defaultElasticsearchIndex
+	}
+	return &ElasticsearchSink{
+		url:           strings.TrimRight(url, "/"),
+		indexPattern:  indexPattern,
+		batchSize:     defaultElasticsearchBatchSize,
+		flushInterval: defaultElasticsearchFlushTime,
+		headers:       make(map[string]string),
+		client:        &http.Client{Timeout: 30 * time.Second},
+		lastFlush:     time.Now(),
+	}
+}
+
+// SetBatchSize sets the number of samples buffered before a bulk request is sent
+func (e *ElasticsearchSink) SetBatchSize(size int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batchSize = size
+}
+
+// SetFlushInterval sets the maximum time samples are buffered before a bulk request is sent
+func (e *ElasticsearchSink) SetFlushInterval(interval time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.flushInterval = interval
+}
+
+// SetBasicAuth authenticates bulk requests with a username and password
+func (e *ElasticsearchSink) SetBasicAuth(username, password string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	req.SetBasicAuth(username, password)
+	e.headers["Authorization"] = req.Header.Get("Authorization")
+}
+
+// SetAPIKey authenticates bulk requests with an Elasticsearch API key (the base64 encoded id:key)
+func (e *ElasticsearchSink) SetAPIKey(apiKey string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.headers["Authorization"] = "ApiKey " + apiKey
+}
+
+// SetHTTPClient replaces the HTTP client, e.g., to configure TLS
+func (e *ElasticsearchSink) SetHTTPClient(client *http.Client) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.client = client
+}
+
+// IndexName returns the index a sample taken at t is written to
+func (e *ElasticsearchSink) IndexName(t time.Time) string {
+	t = t.UTC()
+	return indexDatePattern.ReplaceAllStringFunc(e.indexPattern, func(layout string) string {
+		return t.Format(strings.Trim(layout, "{}"))
+	})
+}
+
+// WriteSample buffers a sample and sends the batch when it is full or the flush interval has passed
+func (e *ElasticsearchSink) WriteSample(sample *Sample) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pending = append(e.pending, sample)
+	if len(e.pending) >= e.batchSize || time.Since(e.lastFlush) >= e.flushInterval {
+		return e.flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered samples immediately
+func (e *ElasticsearchSink) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.flush()
+}
+
+// bulkResponse is the part of the bulk API response needed to detect per-document failures
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulkBody returns the bulk API body indexing samples
+func (e *ElasticsearchSink) bulkBody(samples []*Sample) ([]byte, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, sample := range samples {
+		doc := SampleToJSON(sample)
+		// Kibana expects the time field under @timestamp
+		doc["@timestamp"] = sample.Timestamp.UTC().Format(time.RFC3339Nano)
+		delete(doc, "timestamp")
+		action := map[string]any{"index": map[string]any{"_index": e.IndexName(sample.Timestamp)}}
+		if err := enc.Encode(action); err != nil {
+			return nil, fmt.Errorf("failed to encode bulk action: %w", err)
+		}
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode sample: %w", err)
+		}
+	}
+	return body.Bytes(), nil
+}
+
+func (e *ElasticsearchSink) flush() error {
+	e.lastFlush = time.Now()
+	if len(e.pending) == 0 {
+		return nil
+	}
+	// The batch is dropped whether or not the request succeeds, so a down cluster does not grow it without bound
+	samples := e.pending
+	count := len(samples)
+	e.pending = nil
+
+	body, err := e.bulkBody(samples)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url+"/_bulk", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %d samples to elasticsearch: %w", count, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read bulk response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("elasticsearch bulk request failed with status %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var result bulkResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to parse bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	failed := 0
+	var firstErr string
+	for _, item := range result.Items {
+		for _, status := range item {
+			if status.Error != nil {
+				failed++
+				if firstErr == "" {
+					firstErr = status.Error.Type + ": " + status.Error.Reason
+				}
+			}
+		}
+	}
+	return fmt.Errorf("elasticsearch rejected %d of %d samples (first error: %s)", failed, count, firstErr)
+}
+
+// Close sends the remaining buffered samples
+func (e *ElasticsearchSink) Close() error {
+	return e.Flush()
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestElasticsearchFlagsBufferedSamplesWhenSent(t *testing.T) {
+	var docs []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for line := 0; scanner.Scan(); line++ {
+			if line%2 == 1 {
+				var doc map[string]any
+				if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+					t.Errorf("invalid document %s: %v", scanner.Bytes(), err)
+				}
+				docs = append(docs, doc)
+			}
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	sink := NewElasticsearchSink(server.URL, "")
+	sink.SetBatchSize(2)
+	old := &Sample{Host: "db1", Timestamp: time.Now(), Stats: &SystemStats{}, StaleAfter: 20 * time.Millisecond}
+	if err := sink.WriteSample(old); err != nil {
+		t.Fatal(err)
+	}
+	// Buffered past its expected age, e.g., while the cluster was unreachable
+	time.Sleep(30 * time.Millisecond)
+	fresh := &Sample{Host: "db1", Timestamp: time.Now(), Stats: &SystemStats{}, StaleAfter: 20 * time.Millisecond}
+	if err := sink.WriteSample(fresh); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2", len(docs))
+	}
+	if docs[0]["stale"] != true {
+		t.Errorf("buffered sample is not flagged as stale: %v", docs[0])
+	}
+	if _, stale := docs[1]["stale"]; stale {
+		t.Errorf("fresh sample is flagged as stale: %v", docs[1])
+	}
+}
@@ -0,0 +1,31 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetEntropyEnabled enables or disables collection of the kernel entropy pool size
+func (r *remoteStatsCollector) SetEntropyEnabled(enabled bool) {
+	r.entropyEnabled = enabled
+}
+
+// IsEntropyEnabled returns whether the entropy pool size is collected
+func (r *remoteStatsCollector) IsEntropyEnabled() bool {
+	return r.entropyEnabled
+}
+
+// getEntropyAvail reads /proc/sys/kernel/random/entropy_avail (in bits)
+func (r *remoteStatsCollector) getEntropyAvail(ctx context.Context) (int, error) {
+	content, err := r.readRemoteFile(ctx, "/proc/sys/kernel/random/entropy_avail")
+	if err != nil {
+		return 0, err
+	}
+	entropy, err := strconv.Atoi(strings.TrimSpace(content))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse entropy_avail: %w", err)
+	}
+	return entropy, nil
+}
@@ -0,0 +1,23 @@
+package stats
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logEvent writes an event record (e.g., a reboot or reconnect) to the output, separate from the samples
+func (m *RemoteStatsMonitor) logEvent(event string, fields map[string]any) {
+	if m.slogger != nil {
+		attrs := append([]slog.Attr{slog.String("host", m.host)}, mapToSlogAttrs(fields)...)
+		m.slogger.LogAttrs(context.Background(), slog.LevelInfo, event, attrs...)
+	}
+	if eventSink, ok := m.output().(EventSink); ok {
+		eventSink.WriteEvent(event, fields)
+	}
+	if m.sinks != nil {
+		m.sinks.WriteEvent(event, fields)
+	}
+	if m.poolEvents != nil {
+		m.poolEvents.WriteEvent(event, fields)
+	}
+}
@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// execTransport reads files by running cat, ls and stat in SSH sessions, for hosts where the
+// SFTP subsystem is disabled but commands may be executed (as on many embedded systems)
+type execTransport struct {
+	sshTransport
+}
+
+// run runs a file command with the C locale, mapping a missing file to os.ErrNotExist
+func (t execTransport) run(ctx context.Context, cmd, path string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	if err := t.runSession(ctx, "LC_ALL=C "+cmd, &stdout, &stderr); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "No such file") {
+			return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+		}
+		if msg != "" {
+			return nil, fmt.Errorf("%q failed: %w: %s", cmd, err, msg)
+		}
+		return nil, fmt.Errorf("%q failed: %w", cmd, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (t execTransport) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	data, err := t.run(ctx, "cat -- "+shellQuote(path), path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (t execTransport) ReadDir(ctx context.Context, path string) ([]os.FileInfo, error) {
+	// -p marks directories with a trailing slash
+	data, err := t.run(ctx, "ls -1p -- "+shellQuote(path), path)
+	if err != nil {
+		return nil, err
+	}
+	var infos []os.FileInfo
+	for _, name := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if name == "" {
+			continue
+		}
+		infos = append(infos, execFileInfo{name: strings.TrimSuffix(name, "/"), dir: strings.HasSuffix(name, "/")})
+	}
+	return infos, nil
+}
+
+func (t execTransport) Glob(ctx context.Context, pattern string) ([]string, error) {
+	// The pattern is left unquoted for the shell to expand; unmatched patterns stay literal and are dropped
+	data, err := t.run(ctx, `for f in `+pattern+`; do [ -e "$f" ] && printf '%s\n' "$f"; done; true`, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+func (t execTransport) StatVFS(ctx context.Context, path string) (*sftp.StatVFS, error) {
+	data, err := t.run(ctx, "stat -f -c '%S %s %b %f %a %c %d' -- "+shellQuote(path), path)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("unexpected stat output for %s: %q", path, data)
+	}
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		if values[i], err = strconv.ParseUint(field, 10, 64); err != nil {
+			return nil, fmt.Errorf("unexpected stat output for %s: %q", path, data)
+		}
+	}
+	return &sftp.StatVFS{
+		Frsize: values[0],
+		Bsize:  values[1],
+		Blocks: values[2],
+		Bfree:  values[3],
+		Bavail: values[4],
+		Files:  values[5],
+		Ffree:  values[6],
+		Favail: values[6],
+	}, nil
+}
+
+// execFileInfo is a directory entry listed by ls, which only provides the name and whether it is a directory
+type execFileInfo struct {
+	name string
+	dir  bool
+}
+
+func (fi execFileInfo) Name() string       { return fi.name }
+func (fi execFileInfo) Size() int64        { return 0 }
+func (fi execFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi execFileInfo) IsDir() bool        { return fi.dir }
+func (fi execFileInfo) Sys() any           { return nil }
+
+func (fi execFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// NewRemoteStatsCollectorFromSSHExec creates a collector that reads the remote files by running
+// commands (cat, ls, stat) over sshClient instead of using SFTP
+func NewRemoteStatsCollectorFromSSHExec(sshClient *ssh.Client, sampleDelta time.Duration) *remoteStatsCollector {
+	collector := NewRemoteStatsCollectorFromSFTP(nil, sampleDelta)
+	collector.sshClient = sshClient
+	collector.transport = execTransport{sshTransport{collector}}
+	collector.execMode = true
+	return collector
+}
+
+// WithExecMode reads the remote files by running commands over SSH instead of using the SFTP
+// subsystem, which some hosts (often embedded ones) disable while still allowing exec
+func WithExecMode() SSHOption {
+	return func(o *sshOptions) error {
+		o.execMode = true
+		return nil
+	}
+}
@@ -0,0 +1,175 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// CollectionMode is how a collector reads the files of the host
+type CollectionMode string
+
+const (
+	CollectionSFTP  CollectionMode = "sftp"  // SFTP requests, the default
+	CollectionExec  CollectionMode = "exec"  // commands (cat, ls, stat) over SSH, see WithExecMode
+	CollectionAgent CollectionMode = "agent" // a streaming agent, see SetStreamingInterval
+	CollectionLocal CollectionMode = "local" // the machine the program runs on, see NewLocalStatsCollector
+
+	CollectionPowerShell   CollectionMode = "powershell"    // PowerShell over SSH, see WindowsStatsCollector
+	CollectionSysctl       CollectionMode = "sysctl"        // sysctl and other commands over SSH, see BSDStatsCollector
+	CollectionNodeExporter CollectionMode = "node_exporter" // scrapes of node_exporter, see NodeExporterCollector
+)
+
+// defaultStreamingInterval is the period of the streaming agent started by a fallback chain
+// when none was set with SetStreamingInterval
+const defaultStreamingInterval = 100 * time.Millisecond
+
+// collectionMode returns the mode the next sample is collected with
+func (r *remoteStatsCollector) collectionMode() CollectionMode {
+	base := r.transport
+	if batching, ok := base.(*batchingTransport); ok {
+		base = batching.transport
+	}
+	switch {
+	case r.streamInterval > 0:
+		return CollectionAgent
+	case r.execMode:
+		return CollectionExec
+	}
+	if _, ok := base.(localTransport); ok {
+		return CollectionLocal
+	}
+	return CollectionSFTP
+}
+
+// setBaseTransport replaces the transport, keeping batched reads if enabled
+func (r *remoteStatsCollector) setBaseTransport(t transport) {
+	if batching, ok := r.transport.(*batchingTransport); ok {
+		batching.transport = t
+		return
+	}
+	r.transport = t
+}
+
+// useCollectionMode switches the collector to mode, creating the SFTP client if needed
+func (r *remoteStatsCollector) useCollectionMode(mode CollectionMode) error {
+	r.connMu.Lock()
+	sshClient := r.sshClient
+	r.connMu.Unlock()
+	if sshClient == nil && (mode != CollectionSFTP || r.sftpClient == nil) {
+		return fmt.Errorf("%s collection requires an SSH client", mode)
+	}
+
+	switch mode {
+	case CollectionSFTP:
+		if r.sftpClient == nil {
+			sftpClient, err := sftp.NewClient(sshClient)
+			if err != nil {
+				return fmt.Errorf("failed to create SFTP client: %w", err)
+			}
+			r.connMu.Lock()
+			r.sftpClient = sftpClient
+			r.connMu.Unlock()
+			r.ownsSftpClient = true
+		}
+		r.setBaseTransport(sshTransport{r})
+		r.execMode = false
+		r.SetStreamingInterval(0)
+	case CollectionExec:
+		r.setBaseTransport(execTransport{sshTransport{r}})
+		r.execMode = true
+		r.SetStreamingInterval(0)
+	case CollectionAgent:
+		// Directory listings and disk usage still go through SFTP if it is available
+		if r.sftpClient == nil {
+			r.setBaseTransport(execTransport{sshTransport{r}})
+			r.execMode = true
+		}
+		if r.streamInterval == 0 {
+			r.SetStreamingInterval(r.agentInterval)
+		}
+	}
+	return nil
+}
+
+// SetCollectionModes sets an ordered list of modes to collect with, e.g., CollectionSFTP,
+// CollectionExec then CollectionAgent. When a collection fails, the other modes are tried in order
+// and the first that succeeds is kept for the next samples; a reconnection starts over from the
+// first mode. The mode used is reported in SystemStats.CollectionMode. No modes, the default,
+// collects with the mode the collector was created with.
+func (r *remoteStatsCollector) SetCollectionModes(modes ...CollectionMode) error {
+	for _, mode := range modes {
+		switch mode {
+		case CollectionSFTP, CollectionExec, CollectionAgent:
+		default:
+			return fmt.Errorf("unsupported collection mode %q", mode)
+		}
+	}
+	r.collectionModes = modes
+	r.modeIndex = -1
+	if r.agentInterval == 0 {
+		r.agentInterval = defaultStreamingInterval
+		if r.streamInterval > 0 {
+			r.agentInterval = r.streamInterval
+		}
+	}
+	return nil
+}
+
+// GetCollectionModes returns the modes set with SetCollectionModes
+func (r *remoteStatsCollector) GetCollectionModes() []CollectionMode {
+	return r.collectionModes
+}
+
+// GetCollectionMode returns the mode samples are currently collected with
+func (r *remoteStatsCollector) GetCollectionMode() CollectionMode {
+	return r.collectionMode()
+}
+
+// collectWithFallback collects with the current mode of the chain, then with the other modes in order
+func (r *remoteStatsCollector) collectWithFallback(ctx context.Context) (*SystemStats, error) {
+	order := make([]int, 0, len(r.collectionModes))
+	if r.modeIndex >= 0 {
+		order = append(order, r.modeIndex)
+	}
+	for i := range r.collectionModes {
+		if i != r.modeIndex {
+			order = append(order, i)
+		}
+	}
+
+	var errs []error
+	for _, i := range order {
+		mode := r.collectionModes[i]
+		if i != r.modeIndex {
+			if err := r.useCollectionMode(mode); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", mode, err))
+				continue
+			}
+			r.modeIndex = i
+		}
+		stats, err := r.collectSystemStats(ctx)
+		if err == nil || isPartial(stats, err) {
+			// A partial sample shows the mode works
+			return stats, err
+		}
+		// A cancelled or timed out collection says nothing about the mode
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("%s: %w", mode, ctxErr)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", mode, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// WithCollectionModes connects without requiring SFTP and collects with the first of modes that
+// works on the host (see SetCollectionModes)
+func WithCollectionModes(modes ...CollectionMode) SSHOption {
+	return func(o *sshOptions) error {
+		o.collectionModes = modes
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultFanOutQueueSize = 64
+
+// fanOutItem is a sample or an event queued for a sink
+type fanOutItem struct {
+	sample *Sample
+	event  string
+	fields map[string]any
+}
+
+// fanOutWorker delivers queued items to a single sink from its own goroutine
+type fanOutWorker struct {
+	sink    Sink
+	queue   chan fanOutItem
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	done    chan struct{}
+}
+
+// FanOutSink writes samples to several sinks. Each sink has its own queue and goroutine, so a slow
+// or failing sink never delays or drops samples for the others; when a sink's queue is full, new
+// samples are dropped for that sink only and counted.
+type FanOutSink struct {
+	mu           sync.RWMutex
+	workers      []*fanOutWorker
+	queueSize    int
+	errorHandler func(sink Sink, err error)
+	closed       bool
+}
+
+// NewFanOutSink creates a fan-out sink; queueSize is the number of samples buffered per sink
+// (0 uses a default of 64)
+func NewFanOutSink(queueSize int, sinks ...Sink) *FanOutSink {
+	if queueSize <= 0 {
+		queueSize = defaultFanOutQueueSize
+	}
+	f := &FanOutSink{queueSize: queueSize}
+	for _, sink := range sinks {
+		f.Add(sink)
+	}
+	return f
+}
+
+// SetErrorHandler sets a function called (from the sink's goroutine) whenever a sink fails to write
+func (f *FanOutSink) SetErrorHandler(handler func(sink Sink, err error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errorHandler = handler
+}
+
+// Add starts delivering samples to sink
+func (f *FanOutSink) Add(sink Sink) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	w := &fanOutWorker{
+		sink:  sink,
+		queue: make(chan fanOutItem, f.queueSize),
+		done:  make(chan struct{}),
+	}
+	f.workers = append(f.workers, w)
+	go f.run(w)
+}
+
+func (f *FanOutSink) run(w *fanOutWorker) {
+	defer close(w.done)
+	for item := range w.queue {
+		var err error
+		if item.sample != nil {
+			err = w.sink.WriteSample(item.sample)
+		} else if eventSink, ok := w.sink.(EventSink); ok {
+			err = eventSink.WriteEvent(item.event, item.fields)
+		}
+		if err != nil {
+			w.failed.Add(1)
+			f.mu.RLock()
+			handler := f.errorHandler
+			f.mu.RUnlock()
+			if handler != nil {
+				handler(w.sink, err)
+			}
+		}
+	}
+}
+
+// enqueue hands item to every sink without blocking
+func (f *FanOutSink) enqueue(item fanOutItem) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return errors.New("fan-out sink is closed")
+	}
+	for _, w := range f.workers {
+		select {
+		case w.queue <- item:
+		default:
+			w.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// WriteSample queues the sample for every sink. Write errors are reported to the error handler, not returned.
+func (f *FanOutSink) WriteSample(sample *Sample) error {
+	return f.enqueue(fanOutItem{sample: sample})
+}
+
+// WriteEvent queues the event for every sink that is an EventSink
+func (f *FanOutSink) WriteEvent(event string, fields map[string]any) error {
+	return f.enqueue(fanOutItem{event: event, fields: fields})
+}
+
+// FanOutSinkStats are the delivery counters of a single sink
+type FanOutSinkStats struct {
+	Sink    Sink
+	Queued  int    // samples waiting to be written
+	Dropped uint64 // samples dropped because the queue was full
+	Failed  uint64 // writes that returned an error
+}
+
+// Stats returns the delivery counters of every sink, in the order they were added
+func (f *FanOutSink) Stats() []FanOutSinkStats {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	result := make([]FanOutSinkStats, 0, len(f.workers))
+	for _, w := range f.workers {
+		result = append(result, FanOutSinkStats{
+			Sink:    w.sink,
+			Queued:  len(w.queue),
+			Dropped: w.dropped.Load(),
+			Failed:  w.failed.Load(),
+		})
+	}
+	return result
+}
+
+// Close waits for every sink to write its queued samples, then closes the sinks
+func (f *FanOutSink) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	workers := f.workers
+	for _, w := range workers {
+		close(w.queue)
+	}
+	f.mu.Unlock()
+
+	var errs []error
+	for _, w := range workers {
+		<-w.done
+		if err := w.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close sink: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
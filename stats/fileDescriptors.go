@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FileDescriptorStats holds system-wide file handle usage from /proc/sys/fs/file-nr
+type FileDescriptorStats struct {
+	Allocated   uint64
+	Max         uint64
+	UsedPercent float64
+	PerProcess  map[int]int // PID -> open fd count, for the PIDs set with SetFDWatchPIDs
+}
+
+// SetFDWatchPIDs sets the PIDs whose open file descriptors are counted on every sample
+func (r *remoteStatsCollector) SetFDWatchPIDs(pids []int) {
+	r.fdWatchPIDs = append([]int(nil), pids...)
+}
+
+// GetFDWatchPIDs returns the PIDs whose open file descriptors are counted
+func (r *remoteStatsCollector) GetFDWatchPIDs() []int {
+	return append([]int(nil), r.fdWatchPIDs...)
+}
+
+// parseFileNr parses a line like "2048	0	9223372036854775807"
+func parseFileNr(content string) (allocated, max uint64, err error) {
+	fields := strings.Fields(content)
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf("invalid file-nr: %q", content)
+	}
+	if allocated, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse allocated handles: %w", err)
+	}
+	if max, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse max handles: %w", err)
+	}
+	return allocated, max, nil
+}
+
+// countProcessFDs counts the entries of /proc/<pid>/fd
+func (r *remoteStatsCollector) countProcessFDs(ctx context.Context, pid int) (int, error) {
+	entries, err := r.transport.ReadDir(ctx, fmt.Sprintf("/proc/%d/fd", pid))
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func (r *remoteStatsCollector) getFileDescriptorStats(ctx context.Context) (FileDescriptorStats, error) {
+	content, err := r.readRemoteFile(ctx, "/proc/sys/fs/file-nr")
+	if err != nil {
+		return FileDescriptorStats{}, err
+	}
+	allocated, max, err := parseFileNr(content)
+	if err != nil {
+		return FileDescriptorStats{}, err
+	}
+
+	fds := FileDescriptorStats{
+		Allocated: allocated,
+		Max:       max,
+	}
+	if max > 0 {
+		fds.UsedPercent = float64(allocated) / float64(max) * 100.0
+	}
+
+	if len(r.fdWatchPIDs) > 0 {
+		fds.PerProcess = make(map[int]int)
+		for _, pid := range r.fdWatchPIDs {
+			count, err := r.countProcessFDs(ctx, pid)
+			if err != nil {
+				// Process exited or belongs to another user
+				continue
+			}
+			fds.PerProcess[pid] = count
+		}
+	}
+	return fds, nil
+}
@@ -0,0 +1,153 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// reusedFiles are read on every collection through handles kept open between collections, saving
+// the open and close round trips. Reading procfs files again from offset 0 returns fresh content.
+var reusedFiles = map[string]bool{
+	"/proc/stat":    true,
+	"/proc/meminfo": true,
+}
+
+// reusedHandle is an SFTP handle kept open for one of reusedFiles
+type reusedHandle struct {
+	mu     sync.Mutex   // held by the reader of the file, from openReused until its Close
+	client *sftp.Client // client the handle belongs to, replaced by a reconnect
+	file   *sftp.File
+}
+
+// openReused returns a reader of path through its kept handle, opening it on first use or after
+// the SFTP client was replaced
+func (r *remoteStatsCollector) openReused(ctx context.Context, path string) (io.ReadCloser, error) {
+	r.handlesMu.Lock()
+	if r.handles == nil {
+		r.handles = make(map[string]*reusedHandle)
+	}
+	h, ok := r.handles[path]
+	if !ok {
+		h = &reusedHandle{}
+		r.handles[path] = h
+	}
+	r.handlesMu.Unlock()
+
+	h.mu.Lock()
+	client := r.sftpClient
+	fresh := false
+	if h.file != nil && h.client != client {
+		h.close()
+	}
+	if h.file != nil {
+		if _, err := h.file.Seek(0, io.SeekStart); err != nil {
+			h.close() // e.g., closed along with its client
+		}
+	}
+	if h.file == nil {
+		if err := h.open(ctx, client, path); err != nil {
+			h.mu.Unlock()
+			return nil, err
+		}
+		fresh = true
+	}
+	f := &reusedReader{h: h, path: path, ctx: ctx, fresh: fresh}
+	f.watch()
+	return f, nil
+}
+
+func (h *reusedHandle) open(ctx context.Context, client *sftp.Client, path string) error {
+	file, err := interruptible(ctx, func() (*sftp.File, error) {
+		return client.Open(path)
+	}, func(file *sftp.File) { file.Close() })
+	if err != nil {
+		return err
+	}
+	h.client, h.file = client, file
+	return nil
+}
+
+func (h *reusedHandle) close() {
+	if h.file != nil {
+		h.file.Close()
+	}
+	h.client, h.file = nil, nil
+}
+
+// reusedReader reads a kept handle from offset 0, reopening the file if the handle went stale
+// (e.g., the server closed it) before anything was read. Like sftpReader, it reads straight into
+// the buffers of its reader and closes the handle when ctx is done, which the next reader reopens.
+type reusedReader struct {
+	h      *reusedHandle
+	path   string
+	ctx    context.Context
+	stop   func() bool
+	fresh  bool // the handle was opened for this reader, so a failure is not staleness
+	read   bool // something was read, so the content cannot be started over
+	failed bool
+}
+
+// watch closes the current file of the handle when ctx is done
+func (f *reusedReader) watch() {
+	file := f.h.file
+	f.stop = context.AfterFunc(f.ctx, func() { file.Close() })
+}
+
+func (f *reusedReader) Read(p []byte) (int, error) {
+	if err := f.ctx.Err(); err != nil {
+		f.failed = true
+		return 0, err
+	}
+	if f.h.file == nil {
+		return 0, fmt.Errorf("%s: handle is closed", f.path)
+	}
+	n, err := f.h.file.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) && !f.read && !f.fresh && f.ctx.Err() == nil {
+		client := f.h.client
+		f.stop()
+		f.h.close()
+		if openErr := f.h.open(f.ctx, client, f.path); openErr != nil {
+			f.failed = true
+			return 0, fmt.Errorf("failed to reopen stale handle: %w", openErr)
+		}
+		f.watch()
+		f.fresh = true
+		n, err = f.h.file.Read(p)
+	}
+	if err != nil && f.ctx.Err() != nil {
+		err = f.ctx.Err()
+	}
+	if n > 0 {
+		f.read = true
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		f.failed = true
+	}
+	return n, err
+}
+
+// Close releases the handle for the next collection, closing it if a read failed or was abandoned
+func (f *reusedReader) Close() error {
+	if !f.stop() || f.failed {
+		f.h.close()
+	}
+	f.h.mu.Unlock()
+	return nil
+}
+
+// closeHandles closes the kept handles
+func (r *remoteStatsCollector) closeHandles() {
+	r.handlesMu.Lock()
+	defer r.handlesMu.Unlock()
+	for _, h := range r.handles {
+		h.mu.Lock()
+		h.close()
+		h.mu.Unlock()
+	}
+	r.handles = nil
+}
@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONOptions configures NewJSONLogLineWithOptions
+type JSONOptions struct {
+	// Flat emits a single-level object: nested keys are joined with "_" (e.g., "load_average_load1",
+	// "disk_usage_home_used_mb"), list elements are numbered and per-core usage becomes "cpu0_pct", ...
+	Flat bool
+	// Include keeps only the keys matching one of these path.Match patterns (e.g., "cpu*_pct"), if set.
+	// Patterns match flat keys in flat mode and top-level keys otherwise.
+	Include []string
+	// Exclude drops the keys matching one of these patterns, after Include
+	Exclude []string
+	// Rename renames keys (after Include and Exclude), e.g., {"total_cpu_percentage": "cpu"}
+	Rename map[string]string
+	// Timestamp and Location set the format and timezone of the "timestamp" field, see TimestampFormat
+	Timestamp TimestampFormat
+	Location  *time.Location
+}
+
+// NewJSONLogLineWithOptions returns a JSON logLineFunc with the output shaped by opts.
+// An invalid Include or Exclude pattern is reported here rather than on every sample.
+func NewJSONLogLineWithOptions(opts JSONOptions) (func(*SystemStats) ([]byte, error), error) {
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid field pattern %q: %w", pattern, err)
+		}
+	}
+	timestamps := timestampFormatter{format: opts.Timestamp, location: opts.Location}
+	return func(stats *SystemStats) ([]byte, error) {
+		data := SystemStatsToJSON(stats)
+		data["timestamp"] = timestamps.value(time.Now())
+		if opts.Flat {
+			data = FlattenJSON(data)
+		}
+		return json.Marshal(selectFields(data, opts))
+	}, nil
+}
+
+// flatKeyInvalid matches the characters replaced in flat key parts, such as the "/" of mount points
+var flatKeyInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+func flatKeyPart(key string) string {
+	part := strings.Trim(flatKeyInvalid.ReplaceAllString(key, "_"), "_")
+	if part == "" {
+		return "root" // e.g., the "/" mount point
+	}
+	return part
+}
+
+// FlattenJSON turns the nested map produced by SystemStatsToJSON into a single-level map
+// (see JSONOptions.Flat)
+func FlattenJSON(data map[string]any) map[string]any {
+	flat := make(map[string]any)
+	for key, value := range data {
+		if key == "per_core_cpu_percentages" {
+			if cores, ok := value.(map[string]float64); ok {
+				for core, pct := range cores {
+					flat[flatKeyPart(core)+"_pct"] = pct
+				}
+				continue
+			}
+		}
+		flattenValue(flat, flatKeyPart(key), value)
+	}
+	return flat
+}
+
+func flattenValue(flat map[string]any, prefix string, value any) {
+	rv := reflect.ValueOf(value)
+	switch {
+	case !rv.IsValid():
+		flat[prefix] = nil
+	case rv.Kind() == reflect.Pointer && rv.IsNil():
+		flat[prefix] = nil
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		iter := rv.MapRange()
+		for iter.Next() {
+			flattenValue(flat, prefix+"_"+flatKeyPart(iter.Key().String()), iter.Value().Interface())
+		}
+	case (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8:
+		for i := 0; i < rv.Len(); i++ {
+			flattenValue(flat, prefix+"_"+strconv.Itoa(i), rv.Index(i).Interface())
+		}
+	default:
+		flat[prefix] = value
+	}
+}
+
+func matchesAny(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFields applies Include, Exclude and Rename to the top-level keys of data
+func selectFields(data map[string]any, opts JSONOptions) map[string]any {
+	if len(opts.Include) == 0 && len(opts.Exclude) == 0 && len(opts.Rename) == 0 {
+		return data
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	selected := make(map[string]any, len(data))
+	for _, key := range keys {
+		if len(opts.Include) > 0 && !matchesAny(key, opts.Include) {
+			continue
+		}
+		if matchesAny(key, opts.Exclude) {
+			continue
+		}
+		name := key
+		if renamed, ok := opts.Rename[key]; ok {
+			name = renamed
+		}
+		selected[name] = data[key]
+	}
+	return selected
+}
@@ -0,0 +1,121 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// FleetStats aggregates the latest samples collected from the hosts of a pool
+type FleetStats struct {
+	Timestamp         time.Time
+	Hosts             int // hosts collected successfully
+	FailedHosts       int
+	MeanCPUPercentage float64
+	MaxCPUPercentage  float64
+	HottestHost       string // host with the highest CPU usage
+	TotalMemoryMB     float64
+	UsedMemoryMB      float64
+	UsedMemoryPercent float64 // of the memory of all hosts
+}
+
+// AggregateFleet combines the samples of several hosts; failed is the number of hosts without a sample
+func AggregateFleet(samples []*Sample, failed int) *FleetStats {
+	fleet := &FleetStats{Timestamp: time.Now(), Hosts: len(samples), FailedHosts: failed}
+	var totalCPU float64
+	for _, sample := range samples {
+		stats := sample.Stats
+		totalCPU += stats.TotalCPUPercentage
+		if fleet.HottestHost == "" || stats.TotalCPUPercentage > fleet.MaxCPUPercentage {
+			fleet.MaxCPUPercentage = stats.TotalCPUPercentage
+			fleet.HottestHost = sample.Host
+		}
+		fleet.TotalMemoryMB += stats.TotalMemoryMB
+		fleet.UsedMemoryMB += stats.UsedMemoryMB
+	}
+	if len(samples) > 0 {
+		fleet.MeanCPUPercentage = totalCPU / float64(len(samples))
+	}
+	if fleet.TotalMemoryMB > 0 {
+		fleet.UsedMemoryPercent = fleet.UsedMemoryMB / fleet.TotalMemoryMB * 100
+	}
+	return fleet
+}
+
+// FleetStatsToJSON renders the aggregate with snake_case keys
+func FleetStatsToJSON(fleet *FleetStats) map[string]any {
+	return map[string]any{
+		"hosts":               fleet.Hosts,
+		"failed_hosts":        fleet.FailedHosts,
+		"mean_cpu_percentage": fleet.MeanCPUPercentage,
+		"max_cpu_percentage":  fleet.MaxCPUPercentage,
+		"hottest_host":        fleet.HottestHost,
+		"total_memory_mb":     fleet.TotalMemoryMB,
+		"used_memory_mb":      fleet.UsedMemoryMB,
+		"used_memory_percent": fleet.UsedMemoryPercent,
+	}
+}
+
+// SetFleetStatsEnabled writes a "fleet" event with the FleetStats of every cycle to the shared sinks,
+// next to the per-host samples
+func (p *MonitorPool) SetFleetStatsEnabled(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fleetEnabled = enabled
+}
+
+// IsFleetStatsEnabled returns whether the fleet aggregate of every cycle is written to the sinks
+func (p *MonitorPool) IsFleetStatsEnabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fleetEnabled
+}
+
+// AddFleetHandler adds a function called with the FleetStats of every cycle
+func (p *MonitorPool) AddFleetHandler(handler func(*FleetStats)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fleetHandlers = append(p.fleetHandlers, handler)
+}
+
+// GetFleetStats returns the aggregate of the last cycle, nil before the first one
+func (p *MonitorPool) GetFleetStats() *FleetStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fleet
+}
+
+// recordFleet aggregates the latest result of every host after a cycle and reports it.
+// Hosts with a longer interval contribute their latest sample until they are collected again.
+func (p *MonitorPool) recordFleet() {
+	p.mu.Lock()
+	var samples []*Sample
+	failed := 0
+	for _, sample := range p.results {
+		if sample == nil {
+			failed++
+		} else {
+			samples = append(samples, sample)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Host < samples[j].Host })
+	fleet := AggregateFleet(samples, failed)
+	p.fleet = fleet
+	var comparison *HostComparison
+	if p.outlierThreshold > 0 {
+		comparison = CompareHosts(samples, p.outlierThreshold)
+		p.comparison = comparison
+	}
+	enabled := p.fleetEnabled
+	handlers := p.fleetHandlers
+	p.mu.Unlock()
+
+	if enabled {
+		p.sinks.WriteEvent("fleet", FleetStatsToJSON(fleet))
+	}
+	if comparison != nil {
+		p.sinks.WriteEvent("host_comparison", HostComparisonToJSON(comparison))
+	}
+	for _, handler := range handlers {
+		handler(fleet)
+	}
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GPUStat holds the state of a single NVIDIA GPU as reported by nvidia-smi
+type GPUStat struct {
+	Index              int
+	Name               string
+	UtilizationPercent float64
+	MemoryUtilPercent  float64 // memory controller utilization
+	MemoryUsedMB       float64
+	MemoryTotalMB      float64
+	TemperatureC       float64
+}
+
+const nvidiaSmiCommand = "nvidia-smi --query-gpu=index,name,utilization.gpu,utilization.memory,memory.used,memory.total,temperature.gpu --format=csv,noheader,nounits"
+
+// SetGPUEnabled enables or disables GPU collection via nvidia-smi (requires an SSH client)
+func (r *remoteStatsCollector) SetGPUEnabled(enabled bool) {
+	r.gpuEnabled = enabled
+}
+
+// IsGPUEnabled returns whether GPUs are collected
+func (r *remoteStatsCollector) IsGPUEnabled() bool {
+	return r.gpuEnabled
+}
+
+func (r *remoteStatsCollector) getGPUStats(ctx context.Context) ([]GPUStat, error) {
+	output, err := r.runRemoteCommand(ctx, nvidiaSmiCommand)
+	if err != nil {
+		return nil, err
+	}
+	return parseNvidiaSmi(output)
+}
+
+// parseNvidiaSmi parses lines like "0, NVIDIA A100-SXM4-40GB, 35, 10, 1024, 40960, 41"
+func parseNvidiaSmi(output string) ([]GPUStat, error) {
+	reader := csv.NewReader(strings.NewReader(output))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nvidia-smi output: %w", err)
+	}
+
+	// Fields nvidia-smi cannot report are "[N/A]" or "[Not Supported]" and are left at zero
+	number := func(s string) float64 {
+		v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return v
+	}
+
+	gpus := make([]GPUStat, 0, len(records))
+	for _, record := range records {
+		if len(record) < 7 {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPU index %q: %w", record[0], err)
+		}
+		gpus = append(gpus, GPUStat{
+			Index:              index,
+			Name:               strings.TrimSpace(record[1]),
+			UtilizationPercent: number(record[2]),
+			MemoryUtilPercent:  number(record[3]),
+			MemoryUsedMB:       number(record[4]),
+			MemoryTotalMB:      number(record[5]),
+			TemperatureC:       number(record[6]),
+		})
+	}
+	return gpus, nil
+}
@@ -0,0 +1,178 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GraphiteSink writes samples to a Graphite/carbon endpoint using the plaintext protocol
+// ("metric.path value timestamp" lines)
+type GraphiteSink struct {
+	network      string // "tcp" or "udp"
+	address      string
+	prefix       string
+	hostPrefixes map[string]string
+	mu           sync.Mutex
+	conn         net.Conn
+}
+
+// NewGraphiteSink creates a sink sending to address (e.g., "carbon:2003") over network ("tcp" or "udp").
+// Metric paths are "<prefix>.<host>.<metric>" unless a host has its own prefix set with SetHostPrefix.
+func NewGraphiteSink(network, address, prefix string) (*GraphiteSink, error) {
+	if network != "tcp" && network != "udp" {
+		return nil, fmt.Errorf("unsupported graphite network %q", network)
+	}
+	return &GraphiteSink{
+		network:      network,
+		address:      address,
+		prefix:       strings.Trim(prefix, "."),
+		hostPrefixes: make(map[string]string),
+	}, nil
+}
+
+// SetHostPrefix replaces "<prefix>.<host>" with prefix in the metric paths of host
+func (g *GraphiteSink) SetHostPrefix(host, prefix string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.hostPrefixes[host] = strings.Trim(prefix, ".")
+}
+
+// sanitizeGraphiteNode makes s usable as a single node of a metric path
+func sanitizeGraphiteNode(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ':', '/', ' ', '\t', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// sanitizeGraphiteTag makes s usable as a tag name or value
+func sanitizeGraphiteTag(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ';', '=', '~', '!', '^', ' ', '\t', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func (g *GraphiteSink) pathPrefix(host string) string {
+	if prefix, ok := g.hostPrefixes[host]; ok {
+		return prefix
+	}
+	node := sanitizeGraphiteNode(host)
+	if g.prefix == "" {
+		return node
+	}
+	return g.prefix + "." + node
+}
+
+// formatGraphite renders every metric of sample as plaintext protocol lines
+func (g *GraphiteSink) formatGraphite(sample *Sample) []byte {
+	prefix := g.pathPrefix(sample.Host)
+	timestamp := strconv.FormatInt(sample.Timestamp.Unix(), 10)
+
+	// Static labels are sent as Graphite tags (";role=db"), leaving the paths unchanged
+	var tags strings.Builder
+	labelNames := make([]string, 0, len(sample.Labels))
+	for k := range sample.Labels {
+		labelNames = append(labelNames, k)
+	}
+	sort.Strings(labelNames)
+	for _, k := range labelNames {
+		tags.WriteByte(';')
+		tags.WriteString(sanitizeGraphiteTag(k))
+		tags.WriteByte('=')
+		tags.WriteString(sanitizeGraphiteTag(sample.Labels[k]))
+	}
+
+	var buf bytes.Buffer
+	for _, point := range SystemStatsToMetrics(sample.Stats) {
+		buf.WriteString(prefix)
+		buf.WriteByte('.')
+		buf.WriteString(point.Name)
+		// Label values become extra path nodes, in label name order
+		keys := make([]string, 0, len(point.Labels))
+		for k := range point.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			buf.WriteByte('.')
+			buf.WriteString(sanitizeGraphiteNode(point.Labels[k]))
+		}
+		buf.WriteString(tags.String())
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatFloat(point.Value, 'f', -1, 64))
+		buf.WriteByte(' ')
+		buf.WriteString(timestamp)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// WriteSample sends every metric of sample, reconnecting once if the connection was lost
+func (g *GraphiteSink) WriteSample(sample *Sample) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	data := g.formatGraphite(sample)
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if g.conn == nil {
+			if g.conn, err = net.DialTimeout(g.network, g.address, 5*time.Second); err != nil {
+				return fmt.Errorf("failed to connect to graphite: %w", err)
+			}
+		}
+		if err = g.write(data); err == nil {
+			return nil
+		}
+		g.conn.Close()
+		g.conn = nil
+	}
+	return fmt.Errorf("failed to write to graphite: %w", err)
+}
+
+func (g *GraphiteSink) write(data []byte) error {
+	g.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if g.network == "tcp" {
+		_, err := g.conn.Write(data)
+		return err
+	}
+	// Keep UDP datagrams below a typical MTU by sending whole lines in chunks
+	for len(data) > 0 {
+		end := len(data)
+		if end > 1400 {
+			end = bytes.LastIndexByte(data[:1400], '\n') + 1
+			if end == 0 {
+				end = bytes.IndexByte(data, '\n') + 1
+			}
+		}
+		if _, err := g.conn.Write(data[:end]); err != nil {
+			return err
+		}
+		data = data[end:]
+	}
+	return nil
+}
+
+// Close closes the connection to the carbon endpoint
+func (g *GraphiteSink) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+)
+
+// MonitorHealth counts the collection cycles of a monitor
+type MonitorHealth struct {
+	Cycles              uint64 // collection attempts
+	Failures            uint64 // attempts that failed to collect
+	ConsecutiveFailures uint64 // failed attempts since the last sample
+	Reconnects          uint64 // successful reconnections after a lost connection
+	Overruns            uint64 // cycles that ran past the interval (see SetOverrunPolicy)
+	Partial             uint64 // samples written without the metric groups that failed (see PartialError)
+	LastSuccess         time.Time
+	LastError           string
+	LastErrorAt         time.Time
+}
+
+func (m *RemoteStatsMonitor) recordSample(sample *Sample) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.health.Cycles++
+	m.health.LastSuccess = sample.Timestamp
+	m.health.ConsecutiveFailures = 0
+	m.latest = sample
+	m.disconnected = false
+}
+
+func (m *RemoteStatsMonitor) recordFailure(err error) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.health.Cycles++
+	m.health.Failures++
+	m.health.ConsecutiveFailures++
+	m.health.LastError = err.Error()
+	m.health.LastErrorAt = time.Now()
+}
+
+// recordPartial counts a sample missing some groups, which is still a success
+func (m *RemoteStatsMonitor) recordPartial(err error) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.health.Partial++
+	m.health.LastError = err.Error()
+	m.health.LastErrorAt = time.Now()
+}
+
+func (m *RemoteStatsMonitor) recordOverrun() {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.health.Overruns++
+}
+
+func (m *RemoteStatsMonitor) recordReconnect() {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.health.Reconnects++
+	m.disconnected = false
+}
+
+// GetHealth returns the collection counters of the monitor
+func (m *RemoteStatsMonitor) GetHealth() MonitorHealth {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	return m.health
+}
+
+// GetLatestSample returns the most recently collected sample, nil before the first one
+func (m *RemoteStatsMonitor) GetLatestSample() *Sample {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	return m.latest
+}
+
+// healthToJSON renders the health counters with snake_case keys, times as RFC 3339 (empty if never)
+func healthToJSON(h MonitorHealth) map[string]any {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+	return map[string]any{
+		"cycles":               h.Cycles,
+		"failures":             h.Failures,
+		"consecutive_failures": h.ConsecutiveFailures,
+		"reconnects":           h.Reconnects,
+		"overruns":             h.Overruns,
+		"partial":              h.Partial,
+		"last_success":         formatTime(h.LastSuccess),
+		"last_error":           h.LastError,
+		"last_error_at":        formatTime(h.LastErrorAt),
+	}
+}
+
+// PublishExpvar publishes the latest sample and the health counters as the expvar variable name
+// (e.g., "remote_stats_web1"), so they show up on the host application's /debug/vars
+func (m *RemoteStatsMonitor) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		data := map[string]any{
+			"host":   m.host,
+			"health": healthToJSON(m.GetHealth()),
+		}
+		if sample := m.GetLatestSample(); sample != nil {
+			data["latest"] = SampleToJSON(sample)
+		}
+		return data
+	}))
+	return nil
+}
@@ -0,0 +1,22 @@
+package stats
+
+// HugePagesStats holds the huge page counters from /proc/meminfo
+type HugePagesStats struct {
+	Total           uint64  // HugePages_Total, in pages
+	Free            uint64  // HugePages_Free
+	Reserved        uint64  // HugePages_Rsvd
+	Surplus         uint64  // HugePages_Surp
+	PageSizeKB      uint64  // Hugepagesize
+	AnonHugePagesMB float64 // transparent huge pages backing anonymous memory
+}
+
+func getHugePagesStats(meminfo map[string]float64) HugePagesStats {
+	return HugePagesStats{
+		Total:           uint64(meminfo["HugePages_Total"]),
+		Free:            uint64(meminfo["HugePages_Free"]),
+		Reserved:        uint64(meminfo["HugePages_Rsvd"]),
+		Surplus:         uint64(meminfo["HugePages_Surp"]),
+		PageSizeKB:      uint64(meminfo["Hugepagesize"]),
+		AnonHugePagesMB: meminfo["AnonHugePages"] / 1024,
+	}
+}
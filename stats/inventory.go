@@ -0,0 +1,524 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// inventoryDialConcurrency limits the connections opened at once when adding an inventory
+const inventoryDialConcurrency = 16
+
+// InventoryHost is a host of an inventory file with its connection settings resolved from
+// the host and group variables
+type InventoryHost struct {
+	Name     string
+	Address  string // "host:port", from ansible_host and ansible_port (default the name and 22)
+	User     string // ansible_user
+	KeyFile  string // ansible_ssh_private_key_file
+	Password string // ansible_password
+	Groups   []string
+	Labels   map[string]string // variables not starting with "ansible_"
+}
+
+// inventoryGroup is a group as written in the file, before resolving
+type inventoryGroup struct {
+	hosts    []string
+	vars     map[string]string
+	children []string
+}
+
+// inventory gathers the groups and host variables of a parsed file
+type inventory struct {
+	groups   map[string]*inventoryGroup
+	hostVars map[string]map[string]string
+}
+
+func newInventory() *inventory {
+	return &inventory{
+		groups:   make(map[string]*inventoryGroup),
+		hostVars: make(map[string]map[string]string),
+	}
+}
+
+func (inv *inventory) group(name string) *inventoryGroup {
+	g, ok := inv.groups[name]
+	if !ok {
+		g = &inventoryGroup{vars: make(map[string]string)}
+		inv.groups[name] = g
+	}
+	return g
+}
+
+func (inv *inventory) addHost(group, host string, vars map[string]string) {
+	g := inv.group(group)
+	g.hosts = append(g.hosts, host)
+	if inv.hostVars[host] == nil {
+		inv.hostVars[host] = make(map[string]string)
+	}
+	for k, v := range vars {
+		inv.hostVars[host][k] = v
+	}
+}
+
+// LoadInventory reads an Ansible-style inventory in YAML (".yml" or ".yaml") or INI format
+// (any other extension). Supported are hosts, host variables, group variables ("[group:vars]"
+// or "vars:") and nested groups ("[group:children]" or "children:"), with more specific
+// variables overriding: all < parent groups < child groups < host.
+func LoadInventory(path string) ([]InventoryHost, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		return ParseInventoryYAML(data)
+	}
+	return ParseInventoryINI(bytes.NewReader(data))
+}
+
+// ParseInventoryINI parses an inventory in Ansible's INI format, including host ranges such as
+// "web[01:10].example.com" or "db-[a:c].example.com"
+func ParseInventoryINI(r io.Reader) ([]InventoryHost, error) {
+	inv := newInventory()
+	section, kind := "ungrouped", "hosts"
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section, kind = strings.TrimSpace(line[1:len(line)-1]), "hosts"
+			if name, suffix, ok := strings.Cut(section, ":"); ok {
+				if suffix != "vars" && suffix != "children" {
+					return nil, fmt.Errorf("inventory line %d: unknown section type %q", lineNumber, suffix)
+				}
+				section, kind = name, suffix
+			}
+			inv.group(section)
+			continue
+		}
+
+		switch kind {
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("inventory line %d: expected key=value", lineNumber)
+			}
+			inv.group(section).vars[strings.TrimSpace(key)] = unquoteInventoryValue(strings.TrimSpace(value))
+		case "children":
+			g := inv.group(section)
+			g.children = append(g.children, line)
+			inv.group(line)
+		default:
+			fields, err := splitInventoryFields(line)
+			if err != nil {
+				return nil, fmt.Errorf("inventory line %d: %w", lineNumber, err)
+			}
+			vars := make(map[string]string)
+			for _, field := range fields[1:] {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok {
+					return nil, fmt.Errorf("inventory line %d: expected key=value, got %q", lineNumber, field)
+				}
+				vars[key] = value
+			}
+			hosts, err := expandInventoryHostRange(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("inventory line %d: %w", lineNumber, err)
+			}
+			for _, host := range hosts {
+				inv.addHost(section, host, vars)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+	return inv.resolve()
+}
+
+// splitInventoryFields splits a host line on whitespace, keeping quoted values together
+func splitInventoryFields(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				field.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		case r == '#' && !inField:
+			// Trailing comment
+			return fields, nil
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+func unquoteInventoryValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// expandInventoryHostRange expands the first "[start:end]" range of a host pattern, numeric
+// keeping the zero padding of start (e.g., "[01:10]") or alphabetic (e.g., "[a:f]")
+func expandInventoryHostRange(pattern string) ([]string, error) {
+	open := strings.IndexByte(pattern, '[')
+	if open < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.IndexByte(pattern[open:], ']')
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated host range in %q", pattern)
+	}
+	end += open
+	startText, endText, ok := strings.Cut(pattern[open+1:end], ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid host range in %q", pattern)
+	}
+	var hosts []string
+	if isInventoryLetter(startText) && isInventoryLetter(endText) {
+		if endText[0] < startText[0] || isUpper(startText[0]) != isUpper(endText[0]) {
+			return nil, fmt.Errorf("invalid host range in %q", pattern)
+		}
+		for c := startText[0]; c <= endText[0]; c++ {
+			hosts = append(hosts, pattern[:open]+string(c)+pattern[end+1:])
+		}
+		return hosts, nil
+	}
+	first, err1 := strconv.Atoi(startText)
+	last, err2 := strconv.Atoi(endText)
+	if err1 != nil || err2 != nil || first < 0 || last < first {
+		return nil, fmt.Errorf("invalid host range in %q", pattern)
+	}
+	for i := first; i <= last; i++ {
+		hosts = append(hosts, fmt.Sprintf("%s%0*d%s", pattern[:open], len(startText), i, pattern[end+1:]))
+	}
+	return hosts, nil
+}
+
+// isInventoryLetter returns whether s is a single ASCII letter, a bound of an alphabetic range
+func isInventoryLetter(s string) bool {
+	return len(s) == 1 && (isUpper(s[0]) || ('a' <= s[0] && s[0] <= 'z'))
+}
+
+func isUpper(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+// yamlInventoryGroup is a group of the YAML inventory format
+type yamlInventoryGroup struct {
+	Hosts    map[string]map[string]any      `yaml:"hosts"`
+	Vars     map[string]any                 `yaml:"vars"`
+	Children map[string]*yamlInventoryGroup `yaml:"children"`
+}
+
+// ParseInventoryYAML parses an inventory in Ansible's YAML format
+func ParseInventoryYAML(data []byte) ([]InventoryHost, error) {
+	var groups map[string]*yamlInventoryGroup
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory: %w", err)
+	}
+	inv := newInventory()
+	var add func(name string, group *yamlInventoryGroup)
+	add = func(name string, group *yamlInventoryGroup) {
+		g := inv.group(name)
+		if group == nil {
+			return
+		}
+		for host, vars := range group.Hosts {
+			inv.addHost(name, host, stringifyInventoryVars(vars))
+		}
+		for k, v := range stringifyInventoryVars(group.Vars) {
+			g.vars[k] = v
+		}
+		for child, childGroup := range group.Children {
+			g.children = append(g.children, child)
+			add(child, childGroup)
+		}
+	}
+	for name, group := range groups {
+		add(name, group)
+	}
+	return inv.resolve()
+}
+
+func stringifyInventoryVars(vars map[string]any) map[string]string {
+	result := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if v == nil {
+			result[k] = ""
+			continue
+		}
+		result[k] = fmt.Sprint(v)
+	}
+	return result
+}
+
+// resolve merges the group and host variables of every host
+func (inv *inventory) resolve() ([]InventoryHost, error) {
+	// Groups that are nobody's child hang directly below "all"
+	parents := make(map[string][]string)
+	for name, g := range inv.groups {
+		for _, child := range g.children {
+			if child == name {
+				return nil, fmt.Errorf("inventory group %s contains itself", name)
+			}
+			parents[child] = append(parents[child], name)
+		}
+	}
+	for name := range inv.groups {
+		if name != "all" && len(parents[name]) == 0 {
+			parents[name] = []string{"all"}
+		}
+	}
+
+	// The depth of a group orders its variables: the deeper, the more specific
+	depths := map[string]int{"all": 0}
+	var depth func(name string, visiting map[string]bool) (int, error)
+	depth = func(name string, visiting map[string]bool) (int, error) {
+		if d, ok := depths[name]; ok {
+			return d, nil
+		}
+		if visiting[name] {
+			return 0, fmt.Errorf("inventory group %s is part of a cycle", name)
+		}
+		visiting[name] = true
+		deepest := 0
+		for _, parent := range parents[name] {
+			d, err := depth(parent, visiting)
+			if err != nil {
+				return 0, err
+			}
+			if d > deepest {
+				deepest = d
+			}
+		}
+		depths[name] = deepest + 1
+		return deepest + 1, nil
+	}
+	for name := range inv.groups {
+		if _, err := depth(name, make(map[string]bool)); err != nil {
+			return nil, err
+		}
+	}
+
+	memberOf := make(map[string]map[string]bool) // host -> direct and inherited groups
+	var addGroup func(host, group string)
+	addGroup = func(host, group string) {
+		if memberOf[host][group] {
+			return
+		}
+		memberOf[host][group] = true
+		for _, parent := range parents[group] {
+			addGroup(host, parent)
+		}
+	}
+	for name, g := range inv.groups {
+		for _, host := range g.hosts {
+			if memberOf[host] == nil {
+				memberOf[host] = map[string]bool{"all": true}
+			}
+			addGroup(host, name)
+		}
+	}
+
+	hosts := make([]InventoryHost, 0, len(memberOf))
+	for name, groupSet := range memberOf {
+		groups := make([]string, 0, len(groupSet))
+		for group := range groupSet {
+			groups = append(groups, group)
+		}
+		sort.Slice(groups, func(i, j int) bool {
+			if depths[groups[i]] != depths[groups[j]] {
+				return depths[groups[i]] < depths[groups[j]]
+			}
+			return groups[i] < groups[j]
+		})
+
+		vars := make(map[string]string)
+		for _, group := range groups {
+			for k, v := range inv.groups[group].varsOrNil() {
+				vars[k] = v
+			}
+		}
+		for k, v := range inv.hostVars[name] {
+			vars[k] = v
+		}
+		host, err := newInventoryHost(name, vars)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if group != "all" && group != "ungrouped" {
+				host.Groups = append(host.Groups, group)
+			}
+		}
+		sort.Strings(host.Groups)
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	return hosts, nil
+}
+
+func (g *inventoryGroup) varsOrNil() map[string]string {
+	if g == nil {
+		return nil
+	}
+	return g.vars
+}
+
+// newInventoryHost picks the connection settings out of the merged variables of a host
+func newInventoryHost(name string, vars map[string]string) (InventoryHost, error) {
+	lookup := func(keys ...string) string {
+		for _, key := range keys {
+			if v, ok := vars[key]; ok {
+				return v
+			}
+		}
+		return ""
+	}
+	address := lookup("ansible_host", "ansible_ssh_host")
+	if address == "" {
+		address = name
+	}
+	port := lookup("ansible_port", "ansible_ssh_port")
+	if port == "" {
+		port = "22"
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return InventoryHost{}, fmt.Errorf("inventory host %s: invalid port %q", name, port)
+	}
+	host := InventoryHost{
+		Name:     name,
+		Address:  net.JoinHostPort(address, port),
+		User:     lookup("ansible_user", "ansible_ssh_user"),
+		KeyFile:  lookup("ansible_ssh_private_key_file", "ansible_private_key_file"),
+		Password: lookup("ansible_password", "ansible_ssh_pass"),
+		Labels:   make(map[string]string),
+	}
+	for k, v := range vars {
+		if !strings.HasPrefix(k, "ansible_") {
+			host.Labels[k] = v
+		}
+	}
+	return host, nil
+}
+
+// NewMonitorPoolFromInventory loads the inventory at path and connects to all of its hosts.
+// config is shared by all hosts, with the user, key file and password of each host applied to it;
+// nil means hosts are verified against ~/.ssh/known_hosts and authenticated with the SSH agent.
+// Hosts that fail to connect are left out of the returned pool and reported in the error.
+func NewMonitorPoolFromInventory(path string, interval time.Duration, sampleDelta time.Duration, config *ssh.ClientConfig, opts ...SSHOption) (*MonitorPool, error) {
+	hosts, err := LoadInventory(path)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		// A single agent connection is shared by all hosts
+		auth, err := AuthFromAgent()
+		if err != nil {
+			return nil, err
+		}
+		config = &ssh.ClientConfig{Auth: []ssh.AuthMethod{auth}}
+		opts = append([]SSHOption{WithKnownHosts("")}, opts...)
+	}
+	pool := NewMonitorPool(interval)
+	return pool, pool.AddInventory(hosts, config, sampleDelta, opts...)
+}
+
+// AddInventory connects to every host of an inventory, adding each one under its inventory name
+// with its variables as labels. Hosts that fail to connect are skipped and reported in the error.
+func (p *MonitorPool) AddInventory(hosts []InventoryHost, config *ssh.ClientConfig, sampleDelta time.Duration, opts ...SSHOption) error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	limit := make(chan struct{}, inventoryDialConcurrency)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host InventoryHost) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			if err := p.addInventoryHost(host, config, sampleDelta, opts); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(host)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (p *MonitorPool) addInventoryHost(host InventoryHost, config *ssh.ClientConfig, sampleDelta time.Duration, opts []SSHOption) error {
+	hostConfig := *config
+	if host.User != "" {
+		hostConfig.User = host.User
+	}
+	if host.Password != "" {
+		hostConfig.Auth = append(append([]ssh.AuthMethod{}, hostConfig.Auth...), ssh.Password(host.Password))
+	}
+	hostOpts := opts
+	if host.KeyFile != "" {
+		hostOpts = append([]SSHOption{WithPrivateKeyFile(host.KeyFile, "")}, opts...)
+	}
+
+	monitor, err := NewRemoteStatsMonitorFromSSHConfig(host.Address, &hostConfig, p.interval, sampleDelta, nil, hostOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to add host %s: %w", host.Name, err)
+	}
+	if len(host.Labels) > 0 {
+		monitor.SetLabels(host.Labels)
+	}
+	if err := p.AddMonitor(host.Name, monitor); err != nil {
+		monitor.Close()
+		return err
+	}
+	return nil
+}
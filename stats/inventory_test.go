@@ -0,0 +1,181 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandInventoryHostRange(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string // nil for an error
+	}{
+		{"web.example.com", []string{"web.example.com"}},
+		{"web[1:3].example.com", []string{"web1.example.com", "web2.example.com", "web3.example.com"}},
+		{"web[08:11]", []string{"web08", "web09", "web10", "web11"}},
+		{"node[001:002]-a", []string{"node001-a", "node002-a"}},
+		{"db-[a:c]", []string{"db-a", "db-b", "db-c"}},
+		{"db-[X:Z].lan", []string{"db-X.lan", "db-Y.lan", "db-Z.lan"}},
+		{"web[5:5]", []string{"web5"}},
+		{"web[3:1]", nil},
+		{"web[c:a]", nil},
+		{"web[a:C]", nil},
+		{"web[a:3]", nil},
+		{"web[ab:cd]", nil},
+		{"web[1-3]", nil},
+		{"web[1:3", nil},
+		{"web[-1:3]", nil},
+	}
+	for _, tt := range tests {
+		got, err := expandInventoryHostRange(tt.pattern)
+		switch {
+		case tt.want == nil && err == nil:
+			t.Errorf("expandInventoryHostRange(%q) = %q, want an error", tt.pattern, got)
+		case tt.want != nil && (err != nil || !reflect.DeepEqual(got, tt.want)):
+			t.Errorf("expandInventoryHostRange(%q) = %q, %v, want %q", tt.pattern, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseInventoryINI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []InventoryHost
+	}{
+		{
+			name: "ungrouped and ranges",
+			input: `
+bastion ansible_host=10.0.0.1 ansible_port=2222
+[web]
+web[01:02] ansible_user=deploy # the frontends
+`,
+			want: []InventoryHost{
+				{Name: "bastion", Address: "10.0.0.1:2222", Labels: map[string]string{}},
+				{Name: "web01", Address: "web01:22", User: "deploy", Groups: []string{"web"}, Labels: map[string]string{}},
+				{Name: "web02", Address: "web02:22", User: "deploy", Groups: []string{"web"}, Labels: map[string]string{}},
+			},
+		},
+		{
+			name: "nested children",
+			input: `
+[eu:children]
+eu_web
+eu_db
+[prod:children]
+eu
+[eu_web]
+web1
+[eu_db]
+db-[a:b]
+`,
+			want: []InventoryHost{
+				{Name: "db-a", Address: "db-a:22", Groups: []string{"eu", "eu_db", "prod"}, Labels: map[string]string{}},
+				{Name: "db-b", Address: "db-b:22", Groups: []string{"eu", "eu_db", "prod"}, Labels: map[string]string{}},
+				{Name: "web1", Address: "web1:22", Groups: []string{"eu", "eu_web", "prod"}, Labels: map[string]string{}},
+			},
+		},
+		{
+			name: "variable precedence",
+			input: `
+[all:vars]
+env=all
+tier=all
+dc=all
+ansible_user=root
+[prod:vars]
+env=prod
+tier=prod
+[prod:children]
+web
+[web:vars]
+tier=web
+[web]
+web1 env=host
+web2 ansible_user="deploy user"
+`,
+			want: []InventoryHost{
+				{Name: "web1", Address: "web1:22", User: "root", Groups: []string{"prod", "web"},
+					Labels: map[string]string{"env": "host", "tier": "web", "dc": "all"}},
+				{Name: "web2", Address: "web2:22", User: "deploy user", Groups: []string{"prod", "web"},
+					Labels: map[string]string{"env": "prod", "tier": "web", "dc": "all"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseInventoryINI(strings.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseInventoryINIErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string // part of the error
+	}{
+		{"unknown section", "[web:hosts]\nweb1\n", "line 1: unknown section type"},
+		{"vars without value", "[web:vars]\nenv\n", "line 2: expected key=value"},
+		{"host var without value", "web1 ansible_user\n", "line 1: expected key=value"},
+		{"unterminated quote", "web1 ansible_user=\"deploy\n", "line 1: unterminated quote"},
+		{"invalid range", "web[3:1]\n", "line 1: invalid host range"},
+		{"unterminated range", "web[1:3\n", "line 1: unterminated host range"},
+		{"invalid port", "web1 ansible_port=ssh\n", "invalid port"},
+		{"group containing itself", "[web:children]\nweb\n", "contains itself"},
+		{"cycle", "[a:children]\nb\n[b:children]\nc\n[c:children]\na\n", "part of a cycle"},
+	}
+	for _, tt := range tests {
+		_, err := ParseInventoryINI(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseInventoryYAML(t *testing.T) {
+	input := `
+all:
+  vars:
+    env: all
+    ansible_user: root
+  children:
+    prod:
+      vars:
+        env: prod
+        replicas: 2
+      children:
+        db:
+          vars:
+            env: db
+          hosts:
+            db1:
+              ansible_host: 10.0.0.5
+            db2:
+              env: host
+              ansible_port: 2200
+`
+	got, err := ParseInventoryYAML([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []InventoryHost{
+		{Name: "db1", Address: "10.0.0.5:22", User: "root", Groups: []string{"db", "prod"},
+			Labels: map[string]string{"env": "db", "replicas": "2"}},
+		{Name: "db2", Address: "db2:2200", User: "root", Groups: []string{"db", "prod"},
+			Labels: map[string]string{"env": "host", "replicas": "2"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseInventoryYAML([]byte("all: [web1, web2]\n")); err == nil {
+		t.Error("ParseInventoryYAML accepted a list of hosts")
+	}
+}
@@ -0,0 +1,121 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultIPMIInterval is how often ipmitool is run unless set with SetIPMIInterval, as reading every
+// sensor through the BMC takes seconds
+const defaultIPMIInterval = 30 * time.Second
+
+// IPMISensorStat is a reading of a BMC sensor from ipmitool
+type IPMISensorStat struct {
+	Name   string  // e.g., "CPU1 Temp", "FAN1"
+	Type   string  // "temperature", "fan", "voltage", "power" or "current"
+	Value  float64 // in Unit
+	Unit   string  // as printed by ipmitool, e.g., "degrees C", "RPM", "Volts"
+	Status string  // e.g., "ok", "nc" (non-critical), "cr" (critical)
+}
+
+// ipmiSensorTypes maps the units of ipmitool to the sensor types
+var ipmiSensorTypes = map[string]string{
+	"degrees C": "temperature",
+	"RPM":       "fan",
+	"Volts":     "voltage",
+	"Watts":     "power",
+	"Amps":      "current",
+}
+
+// SetIPMIEnabled enables collecting fan speeds, voltages, temperatures and power readings with
+// "ipmitool sensor" on the host, which requires access to the local BMC (/dev/ipmi0, usually root)
+// and an SSH client. Discrete sensors and sensors without a reading are left out.
+func (r *remoteStatsCollector) SetIPMIEnabled(enabled bool) {
+	r.ipmiEnabled = enabled
+	r.ipmiCache = nil
+}
+
+// IsIPMIEnabled returns whether IPMI sensors are collected
+func (r *remoteStatsCollector) IsIPMIEnabled() bool {
+	return r.ipmiEnabled
+}
+
+// SetIPMIInterval sets how often ipmitool is run; samples in between reuse the last readings
+func (r *remoteStatsCollector) SetIPMIInterval(interval time.Duration) {
+	r.ipmiInterval = interval
+}
+
+// GetIPMIInterval returns how often ipmitool is run
+func (r *remoteStatsCollector) GetIPMIInterval() time.Duration {
+	return r.ipmiInterval
+}
+
+// getIPMIStats returns the cached sensor readings, refreshing them once the interval has elapsed
+func (r *remoteStatsCollector) getIPMIStats(ctx context.Context) ([]IPMISensorStat, error) {
+	interval := r.ipmiInterval
+	if interval <= 0 {
+		interval = defaultIPMIInterval
+	}
+	if r.ipmiCache != nil && time.Since(r.ipmiCollectedAt) < interval {
+		return r.ipmiCache, nil
+	}
+	output, err := r.runRemoteCommand(ctx, "ipmitool sensor")
+	if err != nil {
+		return nil, err
+	}
+	sensors := parseIPMISensors(output)
+	r.ipmiCache = sensors
+	r.ipmiCollectedAt = time.Now()
+	return sensors, nil
+}
+
+// parseIPMISensors parses lines like "FAN1 | 3200.000 | RPM | ok | na | 300.000 | ..."
+func parseIPMISensors(output string) []IPMISensorStat {
+	sensors := []IPMISensorStat{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 4 {
+			continue
+		}
+		unit := strings.TrimSpace(fields[2])
+		sensorType, ok := ipmiSensorTypes[unit]
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			continue // "na" for absent sensors
+		}
+		sensors = append(sensors, IPMISensorStat{
+			Name:   strings.TrimSpace(fields[0]),
+			Type:   sensorType,
+			Value:  value,
+			Unit:   unit,
+			Status: strings.TrimSpace(fields[3]),
+		})
+	}
+	return sensors
+}
+
+// ipmiMetricName returns the metric name of a sensor type
+func ipmiMetricName(sensorType string) string {
+	switch sensorType {
+	case "temperature":
+		return "ipmi_temperature_celsius"
+	case "fan":
+		return "ipmi_fan_rpm"
+	case "voltage":
+		return "ipmi_voltage_volts"
+	case "power":
+		return "ipmi_power_watts"
+	}
+	return "ipmi_current_amps"
+}
+
+// String renders a reading like "FAN1: 3200 RPM (ok)"
+func (s IPMISensorStat) String() string {
+	return fmt.Sprintf("%s: %g %s (%s)", s.Name, s.Value, s.Unit, s.Status)
+}
@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHHop is a single SSH server of a connection chain, e.g., a bastion
+type SSHHop struct {
+	Address string // host:port
+	Config  *ssh.ClientConfig
+}
+
+// DialSSHChain connects to the last hop by tunneling through every previous one, like ssh -J.
+// The intermediate connections are closed once the returned client is closed or its connection drops.
+func DialSSHChain(hops ...SSHHop) (*ssh.Client, error) {
+	if len(hops) == 0 {
+		return nil, errors.New("no SSH hosts to dial")
+	}
+	client, err := ssh.Dial("tcp", hops[0].Address, hops[0].Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", hops[0].Address, err)
+	}
+	chain := []*ssh.Client{client}
+	closeChain := func() {
+		for i := len(chain) - 1; i >= 0; i-- {
+			chain[i].Close()
+		}
+	}
+
+	for _, hop := range hops[1:] {
+		conn, err := client.Dial("tcp", hop.Address)
+		if err != nil {
+			closeChain()
+			return nil, fmt.Errorf("failed to reach %s through %s: %w", hop.Address, client.RemoteAddr(), err)
+		}
+		clientConn, chans, reqs, err := ssh.NewClientConn(conn, hop.Address, hop.Config)
+		if err != nil {
+			conn.Close()
+			closeChain()
+			return nil, fmt.Errorf("failed to connect to %s: %w", hop.Address, err)
+		}
+		client = ssh.NewClient(clientConn, chans, reqs)
+		chain = append(chain, client)
+	}
+
+	if len(chain) > 1 {
+		target := client
+		jumpHosts := chain[:len(chain)-1]
+		go func() {
+			target.Wait()
+			for i := len(jumpHosts) - 1; i >= 0; i-- {
+				jumpHosts[i].Close()
+			}
+		}()
+	}
+	return client, nil
+}
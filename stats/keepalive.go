@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultPingTimeout bounds connection checks that do not have their own timeout
+const defaultPingTimeout = 10 * time.Second
+
+// ConnectionState describes the SSH connection of a monitor, as seen by its keepalives and samples
+type ConnectionState struct {
+	Connected         bool          // false after a failed keepalive or reconnect, until the host answers again
+	LastSuccess       time.Time     // time of the last successfully collected sample
+	LastKeepalive     time.Time     // time of the last answered keepalive
+	RTT               time.Duration // round-trip time of the last answered keepalive
+	KeepaliveFailures uint64
+	LastError         string // error of the last failed keepalive or reconnect
+}
+
+// SetKeepalive sends an SSH keepalive every interval while the monitor runs (0 disables, the default).
+// A keepalive not answered within timeout (0 means interval) drops the connection, so reads hung on a
+// half-open connection fail right away and the next cycle reconnects.
+func (m *RemoteStatsMonitor) SetKeepalive(interval, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = interval
+	}
+	m.keepaliveInterval = interval
+	m.keepaliveTimeout = timeout
+}
+
+// GetKeepalive returns the keepalive interval and timeout
+func (m *RemoteStatsMonitor) GetKeepalive() (interval, timeout time.Duration) {
+	return m.keepaliveInterval, m.keepaliveTimeout
+}
+
+// ConnectionState returns the latest known state of the SSH connection
+func (m *RemoteStatsMonitor) ConnectionState() ConnectionState {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	state := m.conn
+	state.Connected = !m.disconnected
+	state.LastSuccess = m.health.LastSuccess
+	return state
+}
+
+// Healthz returns nil if the connection is up and a sample was collected within the last three
+// intervals, and an error describing the problem otherwise
+func (m *RemoteStatsMonitor) Healthz() error {
+	state := m.ConnectionState()
+	if !state.Connected {
+		return fmt.Errorf("connection is down: %s", state.LastError)
+	}
+	if state.LastSuccess.IsZero() {
+		return fmt.Errorf("no sample collected yet")
+	}
+	if age := time.Since(state.LastSuccess); age > 3*m.GetInterval()+m.GetSampleDelta() {
+		return fmt.Errorf("last successful sample was %v ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+// runKeepalive pings the host every keepalive interval until ctx is done
+func (m *RemoteStatsMonitor) runKeepalive(ctx context.Context) {
+	p, ok := m.statsSource().(pinger)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(m.keepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rtt, err := p.Ping(m.keepaliveTimeout)
+			if err != nil {
+				m.recordDisconnected(err)
+				p.dropConnection()
+				continue
+			}
+			m.recordKeepalive(rtt)
+		}
+	}
+}
+
+func (m *RemoteStatsMonitor) recordKeepalive(rtt time.Duration) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.disconnected = false
+	m.conn.LastKeepalive = time.Now()
+	m.conn.RTT = rtt
+}
+
+func (m *RemoteStatsMonitor) recordDisconnected(err error) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	if !m.disconnected {
+		m.conn.KeepaliveFailures++
+	}
+	m.disconnected = true
+	m.conn.LastError = err.Error()
+}
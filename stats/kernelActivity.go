@@ -0,0 +1,30 @@
+package stats
+
+import "time"
+
+// KernelActivityStats holds per-second rates derived from the /proc/stat counters
+type KernelActivityStats struct {
+	ContextSwitchesPerSec float64 // "ctxt"
+	InterruptsPerSec      float64 // "intr" total
+	ForksPerSec           float64 // "processes" (new processes and threads created)
+}
+
+// ratePerSecond converts a counter delta observed over elapsed into a per-second rate
+func ratePerSecond(delta uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(delta) / elapsed.Seconds()
+}
+
+func computeKernelActivity(stat1, stat2 *procSnapshot) KernelActivityStats {
+	elapsed := stat2.taken.Sub(stat1.taken)
+	rate := func(key string) float64 {
+		return ratePerSecond(counterDelta(stat1.procStat[key], stat2.procStat[key]), elapsed)
+	}
+	return KernelActivityStats{
+		ContextSwitchesPerSec: rate("ctxt"),
+		InterruptsPerSec:      rate("intr"),
+		ForksPerSec:           rate("processes"),
+	}
+}
@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultKnownHostsPath returns ~/.ssh/known_hosts
+func defaultKnownHostsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+// HostKeyFromKnownHosts returns a host key callback verifying servers against an OpenSSH
+// known_hosts file; an empty path uses ~/.ssh/known_hosts
+func HostKeyFromKnownHosts(path string) (ssh.HostKeyCallback, error) {
+	if path == "" {
+		var err error
+		if path, err = defaultKnownHostsPath(); err != nil {
+			return nil, err
+		}
+	}
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// HostKeyTOFU returns a trust-on-first-use host key callback: servers missing from the known_hosts
+// file at path (~/.ssh/known_hosts if empty) are accepted and recorded, while a server presenting a
+// different key than the recorded one is still rejected. The file is created if it does not exist.
+func HostKeyTOFU(path string) (ssh.HostKeyCallback, error) {
+	if path == "" {
+		var err error
+		if path, err = defaultKnownHostsPath(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create known hosts directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create known hosts file: %w", err)
+	}
+	file.Close()
+
+	var mu sync.Mutex
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Reload every time, so hosts recorded by earlier connections (or other processes) count
+		callback, err := knownhosts.New(path)
+		if err != nil {
+			return fmt.Errorf("failed to load known hosts: %w", err)
+		}
+		err = callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			// Known host (nil), a key mismatch, or a revoked key
+			return err
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to record host key: %w", err)
+		}
+		defer f.Close()
+		if _, err := fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)); err != nil {
+			return fmt.Errorf("failed to record host key: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// WithKnownHosts verifies the server key against a known_hosts file (see HostKeyFromKnownHosts),
+// replacing the HostKeyCallback of the config
+func WithKnownHosts(path string) SSHOption {
+	return func(o *sshOptions) error {
+		callback, err := HostKeyFromKnownHosts(path)
+		if err != nil {
+			return err
+		}
+		o.config.HostKeyCallback = callback
+		return nil
+	}
+}
+
+// WithTOFU verifies the server key trusting it on first use (see HostKeyTOFU),
+// replacing the HostKeyCallback of the config
+func WithTOFU(path string) SSHOption {
+	return func(o *sshOptions) error {
+		callback, err := HostKeyTOFU(path)
+		if err != nil {
+			return err
+		}
+		o.config.HostKeyCallback = callback
+		return nil
+	}
+}
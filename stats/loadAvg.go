@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LoadAvg holds the contents of /proc/loadavg
+type LoadAvg struct {
+	Load1         float64
+	Load5         float64
+	Load15        float64
+	RunnableProcs int // currently runnable scheduling entities
+	TotalProcs    int // scheduling entities that currently exist
+}
+
+func (r *remoteStatsCollector) getLoadAvg(ctx context.Context) (LoadAvg, error) {
+	content, err := r.readRemoteFile(ctx, "/proc/loadavg")
+	if err != nil {
+		return LoadAvg{}, err
+	}
+	return parseLoadAvg(content)
+}
+
+// parseLoadAvg parses a line like "0.20 0.18 0.12 1/80 11206"
+func parseLoadAvg(content string) (LoadAvg, error) {
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return LoadAvg{}, fmt.Errorf("invalid loadavg: %q", content)
+	}
+
+	var load LoadAvg
+	var err error
+	if load.Load1, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return LoadAvg{}, fmt.Errorf("failed to parse 1m load: %w", err)
+	}
+	if load.Load5, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return LoadAvg{}, fmt.Errorf("failed to parse 5m load: %w", err)
+	}
+	if load.Load15, err = strconv.ParseFloat(fields[2], 64); err != nil {
+		return LoadAvg{}, fmt.Errorf("failed to parse 15m load: %w", err)
+	}
+
+	runnable, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return LoadAvg{}, fmt.Errorf("invalid process counts in loadavg: %q", fields[3])
+	}
+	if load.RunnableProcs, err = strconv.Atoi(runnable); err != nil {
+		return LoadAvg{}, fmt.Errorf("failed to parse runnable processes: %w", err)
+	}
+	if load.TotalProcs, err = strconv.Atoi(total); err != nil {
+		return LoadAvg{}, fmt.Errorf("failed to parse total processes: %w", err)
+	}
+	return load, nil
+}
+
+// RunQueueStats holds the number of runnable tasks relative to the available CPUs
+type RunQueueStats struct {
+	Runnable       int     // procs_running from /proc/stat
+	CPUCount       int     // not in the JSON of a sample, see SystemInfo
+	RunnablePerCPU float64 // above 1 means tasks are queueing for CPU time
+}
+
+// computeRunQueue returns the run queue of snapshot over cpus, the cached CPU count, or over the
+// cores of snapshot when cpus is 0
+func computeRunQueue(snapshot *procSnapshot, cpus int) RunQueueStats {
+	if cpus == 0 {
+		for core := range snapshot.cpu {
+			if core != "cpu" {
+				cpus++
+			}
+		}
+	}
+	stats := RunQueueStats{
+		Runnable: int(snapshot.procStat["procs_running"]),
+		CPUCount: cpus,
+	}
+	if cpus > 0 {
+		stats.RunnablePerCPU = float64(stats.Runnable) / float64(cpus)
+	}
+	return stats
+}
@@ -3,15 +3,102 @@ package stats
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func PrintSystemStats(stats *SystemStats) {
 	fmt.Println("📊 System Stats Summary")
 	fmt.Println("───────────────────────────────")
+	if len(stats.Labels) > 0 {
+		keys := make([]string, 0, len(stats.Labels))
+		for k := range stats.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, k+"="+stats.Labels[k])
+		}
+		fmt.Printf("🏷️  Labels: %s\n", strings.Join(pairs, " "))
+	}
 	fmt.Printf("🧠 Memory Used: %.2f MB / %.2f MB (%.2f%%)\n",
 		stats.UsedMemoryMB, stats.TotalMemoryMB, stats.UsedMemoryPercent)
+	fmt.Printf("🔄 Swap Used: %.2f MB / %.2f MB (%.2f%%)\n",
+		stats.SwapUsedMB, stats.SwapTotalMB, stats.SwapUsedPercent)
+	fmt.Printf("📉 Paging: %.0f swap-in/s, %.0f swap-out/s, %.0f faults/s (%.0f major)\n",
+		stats.VMStat.SwapInPerSec, stats.VMStat.SwapOutPerSec, stats.VMStat.PageFaultsPerSec, stats.VMStat.MajorFaultsPerSec)
+	fmt.Printf("✍️  Dirty: %.2f MB, Writeback: %.2f MB\n", stats.DirtyMB, stats.WritebackMB)
+	fmt.Printf("🧱 Slab: %.2f MB reclaimable, %.2f MB unreclaimable\n",
+		stats.Slab.ReclaimableMB, stats.Slab.UnreclaimableMB)
+	for _, cache := range stats.Slab.TopCaches {
+		fmt.Printf("   • %-20s: %.2f MB\n", cache.Name, cache.SizeMB)
+	}
+	for key, value := range stats.MeminfoExtra {
+		fmt.Printf("   • %-14s: %.2f MB\n", key, value/1024)
+	}
+	if stats.HugePages.Total > 0 {
+		fmt.Printf("📄 Huge Pages: %d free / %d total (%d reserved, %d kB each)\n",
+			stats.HugePages.Free, stats.HugePages.Total, stats.HugePages.Reserved, stats.HugePages.PageSizeKB)
+	}
 
 	fmt.Printf("⚙️  Total CPU Usage: %.2f%%\n", stats.TotalCPUPercentage)
+	fmt.Printf("   user %.2f%%, system %.2f%%, iowait %.2f%%, irq %.2f%%, softirq %.2f%%, steal %.2f%%\n",
+		stats.CPUModes.User, stats.CPUModes.System, stats.CPUModes.IOWait,
+		stats.CPUModes.IRQ, stats.CPUModes.SoftIRQ, stats.CPUModes.Steal)
+	fmt.Printf("📈 Load Average: %.2f %.2f %.2f (%d/%d runnable)\n",
+		stats.LoadAvg.Load1, stats.LoadAvg.Load5, stats.LoadAvg.Load15,
+		stats.LoadAvg.RunnableProcs, stats.LoadAvg.TotalProcs)
+	if len(stats.Users) > 0 {
+		fmt.Println("👥 Per-User Usage:")
+		for _, user := range stats.Users {
+			fmt.Printf("   • %-12s: %d procs, CPU %.2f%%, RSS %.2f MB\n",
+				user.User, user.Processes, user.CPUPercent, user.RSSMB)
+		}
+	}
+	fmt.Printf("🚶 Run Queue: %d runnable on %d CPUs (%.2f per CPU)\n",
+		stats.RunQueue.Runnable, stats.RunQueue.CPUCount, stats.RunQueue.RunnablePerCPU)
+	fmt.Printf("🧵 Processes: %d (%d threads, %d running, %d blocked)\n",
+		stats.Processes.Processes, stats.Processes.Threads, stats.Processes.Running, stats.Processes.Blocked)
+	if stats.Processes.Zombie+stats.Processes.Uninterruptible > 0 {
+		fmt.Printf("   %d zombie, %d uninterruptible (D)\n", stats.Processes.Zombie, stats.Processes.Uninterruptible)
+	}
+	for _, proc := range stats.WatchedProcesses {
+		fmt.Printf("   • %s[%d]: CPU %.2f%%, RSS %.2f MB, %d threads, %d fds\n",
+			proc.Name, proc.PID, proc.CPUPercent, proc.RSSMB, proc.Threads, proc.FDCount)
+	}
+	fmt.Printf("🔀 Context Switches: %.0f/s, Interrupts: %.0f/s, Forks: %.0f/s\n",
+		stats.KernelActivity.ContextSwitchesPerSec, stats.KernelActivity.InterruptsPerSec, stats.KernelActivity.ForksPerSec)
+	fmt.Printf("🔁 TCP Retransmits: %.1f/s of %.1f/s sent (%.2f%%)\n",
+		stats.TCPRetrans.RetransSegsPerSec, stats.TCPRetrans.OutSegsPerSec, stats.TCPRetrans.RetransPercent)
+	fmt.Printf("📂 File Descriptors: %d / %d (%.2f%%)\n",
+		stats.FileDescriptors.Allocated, stats.FileDescriptors.Max, stats.FileDescriptors.UsedPercent)
+	for pid, count := range stats.FileDescriptors.PerProcess {
+		fmt.Printf("   • pid %-7d: %d fds\n", pid, count)
+	}
+	if stats.Sockets != nil {
+		fmt.Printf("🔌 Sockets: TCP %d in use (%d established, %d time-wait), UDP %d in use\n",
+			stats.Sockets.TCPInUse, stats.Sockets.TCPCurrEstab, stats.Sockets.TCPTimeWait, stats.Sockets.UDPInUse)
+	}
+	if stats.Conntrack != nil {
+		fmt.Printf("🧮 Conntrack: %d / %d (%.2f%%)\n",
+			stats.Conntrack.Count, stats.Conntrack.Max, stats.Conntrack.UsedPercent)
+	}
+	if stats.EntropyAvail != nil {
+		fmt.Printf("🎲 Entropy Available: %d bits\n", *stats.EntropyAvail)
+	}
+	if stats.Pressure != nil {
+		fmt.Printf("🚦 Pressure (some avg10): cpu %.2f%%, memory %.2f%%, io %.2f%%\n",
+			stats.Pressure.CPU.Some.Avg10, stats.Pressure.Memory.Some.Avg10, stats.Pressure.IO.Some.Avg10)
+	}
+	if stats.Reboot != nil {
+		fmt.Printf("🔃 Host rebooted (uptime went from %.0fs to %.0fs)\n",
+			stats.Reboot.PreviousUptimeSeconds, stats.Reboot.UptimeSeconds)
+	}
+	if stats.Uptime != nil {
+		fmt.Printf("⏱️  Uptime: %s\n", time.Duration(stats.Uptime.UptimeSeconds*float64(time.Second)).Round(time.Second))
+	}
 
 	if len(stats.CPUStats) > 0 {
 		fmt.Println("🔧 Per-Core CPU Usage:")
@@ -23,15 +110,216 @@ func PrintSystemStats(stats *SystemStats) {
 			fmt.Printf("   • %-5s: %.2f%%\n", cpu.Core, cpu.UsagePct)
 		}
 	}
+
+	if len(stats.DiskUsage) > 0 {
+		fmt.Println("💾 Disk Usage:")
+		for _, disk := range stats.DiskUsage {
+			fmt.Printf("   • %-12s: %.2f MB / %.2f MB (%.2f%%)\n",
+				disk.MountPoint, disk.UsedMB, disk.TotalMB, disk.UsedPercent)
+		}
+	}
+
+	if len(stats.DiskStats) > 0 {
+		fmt.Println("📀 Disk I/O:")
+		sort.Slice(stats.DiskStats, func(i, j int) bool {
+			return stats.DiskStats[i].Device < stats.DiskStats[j].Device
+		})
+		for _, disk := range stats.DiskStats {
+			fmt.Printf("   • %-8s: %.0f r/s, %.0f w/s, %.2f MB/s read, %.2f MB/s written, %.2f ms await, %.1f%% util\n",
+				disk.Device, disk.ReadIOPS, disk.WriteIOPS,
+				disk.ReadBytesPerSec/(1024*1024), disk.WriteBytesPerSec/(1024*1024), disk.AvgAwaitMs, disk.UtilPercent)
+		}
+	}
+
+	if len(stats.NetInterfaces) > 0 {
+		fmt.Println("🌐 Network Interfaces:")
+		for _, iface := range stats.NetInterfaces {
+			fmt.Printf("   • %-10s: rx %.2f Mbps (%.0f pkt/s), tx %.2f Mbps (%.0f pkt/s), errs %d/%d, drop %d/%d\n",
+				iface.Interface, iface.RxMbps, iface.RxPacketsPerSec, iface.TxMbps, iface.TxPacketsPerSec,
+				iface.RxErrors, iface.TxErrors, iface.RxDropped, iface.TxDropped)
+		}
+	}
+
+	if len(stats.RAIDArrays) > 0 {
+		fmt.Println("🗄️  RAID Arrays:")
+		for _, md := range stats.RAIDArrays {
+			status := md.State
+			if md.Degraded {
+				status += ", DEGRADED"
+			}
+			if md.SyncAction != "" {
+				status += fmt.Sprintf(", %s %.1f%%", md.SyncAction, md.SyncProgress)
+			}
+			fmt.Printf("   • %-6s %s [%d/%d]: %s\n", md.Device, md.Level, md.MemberDisks, md.ActiveDisks, status)
+		}
+	}
+
+	if len(stats.Cgroups) > 0 {
+		fmt.Println("📦 Cgroups:")
+		for _, cg := range stats.Cgroups {
+			fmt.Printf("   • %s: CPU %.2f%%, Memory %.2f MB\n",
+				cg.Path, cg.CPUPercent, float64(cg.MemoryCurrent)/(1024*1024))
+		}
+	}
+
+	if len(stats.GPUs) > 0 {
+		fmt.Println("🎮 GPUs:")
+		for _, gpu := range stats.GPUs {
+			fmt.Printf("   • gpu%d %s: %.0f%%, %.0f MB / %.0f MB, %.0f°C\n",
+				gpu.Index, gpu.Name, gpu.UtilizationPercent, gpu.MemoryUsedMB, gpu.MemoryTotalMB, gpu.TemperatureC)
+		}
+	}
+
+	if len(stats.IPMISensors) > 0 {
+		fmt.Println("🔌 IPMI Sensors:")
+		for _, sensor := range stats.IPMISensors {
+			fmt.Printf("   • %s\n", sensor)
+		}
+	}
+
+	if stats.Systemd != nil {
+		fmt.Printf("🛠️  Systemd: %d failed units\n", len(stats.Systemd.FailedUnits))
+		for unit, state := range stats.Systemd.Units {
+			fmt.Printf("   • %-24s: %s\n", unit, state)
+		}
+	}
+
+	if len(stats.SMART) > 0 {
+		fmt.Println("🩺 SMART:")
+		for _, disk := range stats.SMART {
+			if disk.Error != "" {
+				fmt.Printf("   • %-12s: error: %s\n", disk.Device, disk.Error)
+				continue
+			}
+			fmt.Printf("   • %-12s: %d reallocated, %.0f°C, %.0f%% wear\n",
+				disk.Device, disk.ReallocatedSectors, disk.TemperatureC, disk.WearPercentUsed)
+		}
+	}
+
+	if len(stats.CommandMetrics) > 0 {
+		fmt.Println("🧮 Command Metrics:")
+		for _, metric := range stats.CommandMetrics {
+			if metric.Error != "" {
+				fmt.Printf("   • %-20s: error: %s\n", metric.Name, metric.Error)
+				continue
+			}
+			fmt.Printf("   • %-20s: %g\n", metric.Name, metric.Value)
+		}
+	}
+
+	for _, kill := range stats.OOMKills {
+		fmt.Printf("💀 OOM Killer: killed %s (pid %d)\n", kill.Process, kill.PID)
+	}
+
+	if len(stats.Buddyinfo) > 0 {
+		fmt.Println("🧩 Free Pages per Order:")
+		for _, zone := range stats.Buddyinfo {
+			fmt.Printf("   • node %d %-8s: %v\n", zone.Node, zone.Zone, zone.FreePages)
+		}
+	}
+
+	if len(stats.Temperatures) > 0 {
+		fmt.Println("🌡️  Temperatures:")
+		for _, temp := range stats.Temperatures {
+			fmt.Printf("   • %-24s: %.1f°C\n", temp.Label, temp.Celsius)
+		}
+	}
 	fmt.Println("───────────────────────────────")
 }
 
+// SystemStatsToJSON returns the JSON form of stats. The static values of the host (total memory,
+// CPU count) are left out, they are reported once through the "system_info" event.
 func SystemStatsToJSON(stats *SystemStats) map[string]any {
 	data := map[string]any{
-		"total_memory_mb":      stats.TotalMemoryMB,
-		"used_memory_mb":       stats.UsedMemoryMB,
-		"used_memory_percent":  stats.UsedMemoryPercent,
+		"used_memory_mb":      stats.UsedMemoryMB,
+		"used_memory_percent": stats.UsedMemoryPercent,
+		"swap_total_mb":       stats.SwapTotalMB,
+		"swap_used_mb":        stats.SwapUsedMB,
+		"swap_used_percent":   stats.SwapUsedPercent,
+		"hugepages": map[string]any{
+			"total":             stats.HugePages.Total,
+			"free":              stats.HugePages.Free,
+			"reserved":          stats.HugePages.Reserved,
+			"surplus":           stats.HugePages.Surplus,
+			"page_size_kb":      stats.HugePages.PageSizeKB,
+			"anon_hugepages_mb": stats.HugePages.AnonHugePagesMB,
+		},
+		"dirty_mb":     stats.DirtyMB,
+		"writeback_mb": stats.WritebackMB,
+		"slab": func() map[string]any {
+			m := map[string]any{
+				"reclaimable_mb":   stats.Slab.ReclaimableMB,
+				"unreclaimable_mb": stats.Slab.UnreclaimableMB,
+			}
+			if len(stats.Slab.TopCaches) > 0 {
+				caches := make(map[string]float64)
+				for _, cache := range stats.Slab.TopCaches {
+					caches[cache.Name] = cache.SizeMB
+				}
+				m["top_caches_mb"] = caches
+			}
+			return m
+		}(),
 		"total_cpu_percentage": stats.TotalCPUPercentage,
+		"cpu_modes":            cpuModesToJSON(stats.CPUModes),
+		"load_average": map[string]any{
+			"load1":          stats.LoadAvg.Load1,
+			"load5":          stats.LoadAvg.Load5,
+			"load15":         stats.LoadAvg.Load15,
+			"runnable_procs": stats.LoadAvg.RunnableProcs,
+			"total_procs":    stats.LoadAvg.TotalProcs,
+		},
+		"run_queue": map[string]any{
+			"runnable":         stats.RunQueue.Runnable,
+			"runnable_per_cpu": stats.RunQueue.RunnablePerCPU,
+		},
+		"processes": map[string]any{
+			"total":           stats.Processes.Processes,
+			"threads":         stats.Processes.Threads,
+			"running":         stats.Processes.Running,
+			"blocked":         stats.Processes.Blocked,
+			"zombie":          stats.Processes.Zombie,
+			"uninterruptible": stats.Processes.Uninterruptible,
+		},
+		"net_interfaces": func() map[string]map[string]any {
+			m := make(map[string]map[string]any)
+			for _, iface := range stats.NetInterfaces {
+				m[iface.Interface] = map[string]any{
+					"rx_bytes_per_sec":   iface.RxBytesPerSec,
+					"tx_bytes_per_sec":   iface.TxBytesPerSec,
+					"rx_packets_per_sec": iface.RxPacketsPerSec,
+					"tx_packets_per_sec": iface.TxPacketsPerSec,
+					"rx_mbps":            iface.RxMbps,
+					"tx_mbps":            iface.TxMbps,
+					"rx_errors":          iface.RxErrors,
+					"tx_errors":          iface.TxErrors,
+					"rx_dropped":         iface.RxDropped,
+					"tx_dropped":         iface.TxDropped,
+				}
+			}
+			return m
+		}(),
+		"vmstat": map[string]any{
+			"swap_in_per_sec":      stats.VMStat.SwapInPerSec,
+			"swap_out_per_sec":     stats.VMStat.SwapOutPerSec,
+			"page_faults_per_sec":  stats.VMStat.PageFaultsPerSec,
+			"major_faults_per_sec": stats.VMStat.MajorFaultsPerSec,
+		},
+		"tcp_retrans": map[string]any{
+			"retrans_segs_per_sec": stats.TCPRetrans.RetransSegsPerSec,
+			"out_segs_per_sec":     stats.TCPRetrans.OutSegsPerSec,
+			"retrans_percent":      stats.TCPRetrans.RetransPercent,
+		},
+		"file_descriptors": map[string]any{
+			"allocated":    stats.FileDescriptors.Allocated,
+			"max":          stats.FileDescriptors.Max,
+			"used_percent": stats.FileDescriptors.UsedPercent,
+		},
+		"kernel_activity": map[string]any{
+			"context_switches_per_sec": stats.KernelActivity.ContextSwitchesPerSec,
+			"interrupts_per_sec":       stats.KernelActivity.InterruptsPerSec,
+			"forks_per_sec":            stats.KernelActivity.ForksPerSec,
+		},
 		"per_core_cpu_percentages": func() map[string]float64 {
 			m := make(map[string]float64)
 			for _, cpu := range stats.CPUStats {
@@ -39,6 +327,292 @@ func SystemStatsToJSON(stats *SystemStats) map[string]any {
 			}
 			return m
 		}(),
+		"disk_usage": func() map[string]map[string]any {
+			m := make(map[string]map[string]any)
+			for _, disk := range stats.DiskUsage {
+				m[disk.MountPoint] = map[string]any{
+					"device":       disk.Device,
+					"fs_type":      disk.FSType,
+					"total_mb":     disk.TotalMB,
+					"used_mb":      disk.UsedMB,
+					"free_mb":      disk.FreeMB,
+					"used_percent": disk.UsedPercent,
+				}
+			}
+			return m
+		}(),
+		"disk_stats": func() map[string]map[string]any {
+			m := make(map[string]map[string]any)
+			for _, disk := range stats.DiskStats {
+				m[disk.Device] = map[string]any{
+					"reads_completed":     disk.ReadsCompleted,
+					"writes_completed":    disk.WritesCompleted,
+					"sectors_read":        disk.SectorsRead,
+					"sectors_written":     disk.SectorsWritten,
+					"io_time_ms":          disk.IOTimeMs,
+					"read_iops":           disk.ReadIOPS,
+					"write_iops":          disk.WriteIOPS,
+					"read_bytes_per_sec":  disk.ReadBytesPerSec,
+					"write_bytes_per_sec": disk.WriteBytesPerSec,
+					"util_percent":        disk.UtilPercent,
+					"avg_await_ms":        disk.AvgAwaitMs,
+					"avg_queue_size":      disk.AvgQueueSize,
+					"ios_in_progress":     disk.IOsInProgress,
+				}
+			}
+			return m
+		}(),
+	}
+	if len(stats.Temperatures) > 0 {
+		temps := make(map[string]map[string]any)
+		for _, temp := range stats.Temperatures {
+			temps[temp.Sensor] = map[string]any{
+				"label":   temp.Label,
+				"celsius": temp.Celsius,
+			}
+		}
+		data["temperatures"] = temps
+	}
+	if len(stats.WatchedProcesses) > 0 {
+		watched := make(map[string]map[string]any)
+		for _, proc := range stats.WatchedProcesses {
+			watched[strconv.Itoa(proc.PID)] = map[string]any{
+				"name":        proc.Name,
+				"match":       proc.Match,
+				"cpu_percent": proc.CPUPercent,
+				"rss_mb":      proc.RSSMB,
+				"vsz_mb":      proc.VSZMB,
+				"threads":     proc.Threads,
+				"fd_count":    proc.FDCount,
+			}
+		}
+		data["watched_processes"] = watched
+	}
+	if len(stats.Users) > 0 {
+		users := make(map[string]map[string]any)
+		for _, user := range stats.Users {
+			users[user.User] = map[string]any{
+				"uid":         user.UID,
+				"processes":   user.Processes,
+				"cpu_percent": user.CPUPercent,
+				"rss_mb":      user.RSSMB,
+			}
+		}
+		data["users"] = users
+	}
+	if len(stats.FileDescriptors.PerProcess) > 0 {
+		perProcess := make(map[string]int)
+		for pid, count := range stats.FileDescriptors.PerProcess {
+			perProcess[strconv.Itoa(pid)] = count
+		}
+		data["file_descriptors"].(map[string]any)["per_process"] = perProcess
+	}
+	if stats.Sockets != nil {
+		data["sockets"] = map[string]any{
+			"tcp_inuse":      stats.Sockets.TCPInUse,
+			"tcp_orphan":     stats.Sockets.TCPOrphan,
+			"tcp_time_wait":  stats.Sockets.TCPTimeWait,
+			"tcp_alloc":      stats.Sockets.TCPAlloc,
+			"tcp_curr_estab": stats.Sockets.TCPCurrEstab,
+			"tcp_by_state":   stats.Sockets.TCPByState,
+			"udp_inuse":      stats.Sockets.UDPInUse,
+		}
+	}
+	if stats.Conntrack != nil {
+		data["conntrack"] = map[string]any{
+			"count":        stats.Conntrack.Count,
+			"max":          stats.Conntrack.Max,
+			"used_percent": stats.Conntrack.UsedPercent,
+		}
+	}
+	if len(stats.RAIDArrays) > 0 {
+		arrays := make(map[string]map[string]any)
+		for _, md := range stats.RAIDArrays {
+			arrays[md.Device] = map[string]any{
+				"state":         md.State,
+				"level":         md.Level,
+				"member_disks":  md.MemberDisks,
+				"active_disks":  md.ActiveDisks,
+				"degraded":      md.Degraded,
+				"sync_action":   md.SyncAction,
+				"sync_progress": md.SyncProgress,
+			}
+		}
+		data["raid_arrays"] = arrays
+	}
+	if stats.EntropyAvail != nil {
+		data["entropy_avail"] = *stats.EntropyAvail
+	}
+	if len(stats.Cgroups) > 0 {
+		cgroups := make(map[string]map[string]any)
+		for _, cg := range stats.Cgroups {
+			cgroups[cg.Path] = map[string]any{
+				"cpu_usage_usec":       cg.CPUUsageUsec,
+				"cpu_user_usec":        cg.CPUUserUsec,
+				"cpu_system_usec":      cg.CPUSystemUsec,
+				"cpu_percent":          cg.CPUPercent,
+				"nr_throttled":         cg.NrThrottled,
+				"throttled_usec":       cg.ThrottledUsec,
+				"memory_current_bytes": cg.MemoryCurrent,
+				"memory_max_bytes":     cg.MemoryMax,
+				"memory_percent":       cg.MemoryPercent,
+				"io_read_bytes":        cg.IOReadBytes,
+				"io_write_bytes":       cg.IOWriteBytes,
+				"io_read_ops":          cg.IOReadOps,
+				"io_write_ops":         cg.IOWriteOps,
+			}
+		}
+		data["cgroups"] = cgroups
+	}
+	if len(stats.GPUs) > 0 {
+		gpus := make(map[string]map[string]any)
+		for _, gpu := range stats.GPUs {
+			gpus[strconv.Itoa(gpu.Index)] = map[string]any{
+				"name":                gpu.Name,
+				"utilization_percent": gpu.UtilizationPercent,
+				"memory_util_percent": gpu.MemoryUtilPercent,
+				"memory_used_mb":      gpu.MemoryUsedMB,
+				"memory_total_mb":     gpu.MemoryTotalMB,
+				"temperature_c":       gpu.TemperatureC,
+			}
+		}
+		data["gpus"] = gpus
+	}
+	if stats.IPMISensors != nil {
+		sensors := make([]map[string]any, 0, len(stats.IPMISensors))
+		for _, sensor := range stats.IPMISensors {
+			sensors = append(sensors, map[string]any{
+				"name":   sensor.Name,
+				"type":   sensor.Type,
+				"value":  sensor.Value,
+				"unit":   sensor.Unit,
+				"status": sensor.Status,
+			})
+		}
+		data["ipmi_sensors"] = sensors
+	}
+	if stats.Systemd != nil {
+		failed := stats.Systemd.FailedUnits
+		if failed == nil {
+			failed = []string{}
+		}
+		data["systemd"] = map[string]any{
+			"units":        stats.Systemd.Units,
+			"failed_units": failed,
+		}
+	}
+	if len(stats.SMART) > 0 {
+		smart := make(map[string]map[string]any)
+		for _, disk := range stats.SMART {
+			entry := map[string]any{
+				"reallocated_sectors": disk.ReallocatedSectors,
+				"temperature_c":       disk.TemperatureC,
+				"wear_percent_used":   disk.WearPercentUsed,
+				"collected_at":        disk.CollectedAt.Format(time.RFC3339),
+			}
+			if disk.Error != "" {
+				entry["error"] = disk.Error
+			}
+			smart[disk.Device] = entry
+		}
+		data["smart"] = smart
+	}
+	if len(stats.CommandMetrics) > 0 {
+		values := make(map[string]float64)
+		errs := make(map[string]string)
+		for _, metric := range stats.CommandMetrics {
+			if metric.Error != "" {
+				errs[metric.Name] = metric.Error
+				continue
+			}
+			values[metric.Name] = metric.Value
+		}
+		data["command_metrics"] = values
+		if len(errs) > 0 {
+			data["command_metric_errors"] = errs
+		}
+	}
+	if len(stats.OOMKills) > 0 {
+		kills := make([]map[string]any, 0, len(stats.OOMKills))
+		for _, kill := range stats.OOMKills {
+			kills = append(kills, map[string]any{
+				"pid":     kill.PID,
+				"process": kill.Process,
+			})
+		}
+		data["oom_kills"] = kills
+	}
+	if len(stats.Buddyinfo) > 0 {
+		buddyinfo := make(map[string][]uint64)
+		for _, zone := range stats.Buddyinfo {
+			buddyinfo[fmt.Sprintf("node%d_%s", zone.Node, zone.Zone)] = zone.FreePages
+		}
+		data["buddyinfo"] = buddyinfo
+	}
+	if stats.Pressure != nil {
+		data["pressure"] = map[string]any{
+			"cpu":    pressureResourceToJSON(stats.Pressure.CPU),
+			"memory": pressureResourceToJSON(stats.Pressure.Memory),
+			"io":     pressureResourceToJSON(stats.Pressure.IO),
+		}
+	}
+	perCoreModes := make(map[string]map[string]float64)
+	for _, cpu := range stats.CPUStats {
+		if cpu.Modes != nil {
+			perCoreModes[cpu.Core] = cpuModesToJSON(*cpu.Modes)
+		}
+	}
+	if len(perCoreModes) > 0 {
+		data["per_core_cpu_modes"] = perCoreModes
+	}
+	if len(stats.MeminfoExtra) > 0 {
+		data["meminfo_extra_kb"] = stats.MeminfoExtra
+	}
+	if stats.Uptime != nil {
+		data["uptime_seconds"] = stats.Uptime.UptimeSeconds
+		data["idle_seconds"] = stats.Uptime.IdleSeconds
+	}
+	if len(stats.Labels) > 0 {
+		data["labels"] = stats.Labels
+	}
+	if len(stats.Custom) > 0 {
+		data["custom"] = stats.Custom
+	}
+	if stats.CollectionMode != "" {
+		data["collection_mode"] = string(stats.CollectionMode)
+	}
+	if len(stats.Errors) > 0 {
+		data["errors"] = groupErrorsToJSON(stats.Errors)
 	}
 	return data
 }
+
+func pressureResourceToJSON(res PressureResource) map[string]any {
+	line := func(l PressureLine) map[string]any {
+		return map[string]any{
+			"avg10":    l.Avg10,
+			"avg60":    l.Avg60,
+			"avg300":   l.Avg300,
+			"total_us": l.TotalUs,
+		}
+	}
+	return map[string]any{
+		"some": line(res.Some),
+		"full": line(res.Full),
+	}
+}
+
+func cpuModesToJSON(modes CPUModeBreakdown) map[string]float64 {
+	return map[string]float64{
+		"user":       modes.User,
+		"nice":       modes.Nice,
+		"system":     modes.System,
+		"idle":       modes.Idle,
+		"iowait":     modes.IOWait,
+		"irq":        modes.IRQ,
+		"softirq":    modes.SoftIRQ,
+		"steal":      modes.Steal,
+		"guest":      modes.Guest,
+		"guest_nice": modes.GuestNice,
+	}
+}
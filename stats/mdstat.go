@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RAIDArrayStat holds the status of a single software RAID (md) array
+type RAIDArrayStat struct {
+	Device       string // e.g., "md0"
+	State        string // "active", "inactive", ...
+	Level        string // e.g., "raid1"
+	MemberDisks  int    // disks the array is configured with
+	ActiveDisks  int    // disks currently in sync
+	Degraded     bool
+	SyncAction   string  // "resync", "recovery", "check", "reshape" or empty when idle
+	SyncProgress float64 // percentage of SyncAction completed
+}
+
+var (
+	mdDiskCountPattern = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	mdSyncPattern      = regexp.MustCompile(`(resync|recovery|check|reshape)\s*=\s*([\d.]+)%`)
+)
+
+// getRAIDStats reads /proc/mdstat. It returns nil without an error when the md driver is not loaded.
+func (r *remoteStatsCollector) getRAIDStats(ctx context.Context) ([]RAIDArrayStat, error) {
+	content, err := r.readRemoteFile(ctx, "/proc/mdstat")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseMdstat(content), nil
+}
+
+func parseMdstat(content string) []RAIDArrayStat {
+	var arrays []RAIDArrayStat
+	var current *RAIDArrayStat
+	for _, line := range strings.Split(content, "\n") {
+		// Array header: "md0 : active raid1 sdb1[1] sda1[0]"
+		if name, rest, ok := strings.Cut(line, " : "); ok && strings.HasPrefix(name, "md") {
+			fields := strings.Fields(rest)
+			arrays = append(arrays, RAIDArrayStat{Device: strings.TrimSpace(name)})
+			current = &arrays[len(arrays)-1]
+			if len(fields) > 0 {
+				current.State = fields[0]
+			}
+			// "active (auto-read-only) raid1 ..." puts the read-only flag before the level
+			for _, f := range fields[1:] {
+				if strings.HasPrefix(f, "raid") || f == "linear" || f == "multipath" {
+					current.Level = f
+					break
+				}
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		// Status line: "1048512 blocks super 1.2 [2/1] [_U]"
+		if m := mdDiskCountPattern.FindStringSubmatch(line); m != nil {
+			current.MemberDisks, _ = strconv.Atoi(m[1])
+			current.ActiveDisks, _ = strconv.Atoi(m[2])
+			current.Degraded = current.ActiveDisks < current.MemberDisks
+		}
+		// Progress line: "[=>....]  recovery =  8.5% (89344/1048512) finish=0.7min speed=22336K/sec"
+		if m := mdSyncPattern.FindStringSubmatch(line); m != nil {
+			current.SyncAction = m[1]
+			current.SyncProgress, _ = strconv.ParseFloat(m[2], 64)
+		}
+	}
+	return arrays
+}
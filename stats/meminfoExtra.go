@@ -0,0 +1,26 @@
+package stats
+
+// SetExtraMeminfoFields sets additional /proc/meminfo keys (e.g., "Buffers", "Cached", "Shmem")
+// reported on every sample
+func (r *remoteStatsCollector) SetExtraMeminfoFields(fields []string) {
+	r.extraMeminfoFields = append([]string(nil), fields...)
+}
+
+// GetExtraMeminfoFields returns the additional /proc/meminfo keys reported
+func (r *remoteStatsCollector) GetExtraMeminfoFields() []string {
+	return append([]string(nil), r.extraMeminfoFields...)
+}
+
+// selectMeminfoFields picks the requested keys out of meminfo, skipping keys the kernel does not report
+func selectMeminfoFields(meminfo map[string]float64, fields []string) map[string]float64 {
+	if len(fields) == 0 {
+		return nil
+	}
+	selected := make(map[string]float64, len(fields))
+	for _, field := range fields {
+		if v, ok := meminfo[field]; ok {
+			selected[field] = v
+		}
+	}
+	return selected
+}
@@ -0,0 +1,211 @@
+package stats
+
+import (
+	"strconv"
+)
+
+// MetricPoint is a single numeric value of a sample, flattened for metric backends
+type MetricPoint struct {
+	Name   string            // snake_case, e.g., "cpu_usage_percent"
+	Labels map[string]string // e.g., {"core": "cpu0"}, nil for host-wide values
+	Value  float64
+}
+
+// SystemStatsToMetrics flattens stats into metric points, one per value and label set
+func SystemStatsToMetrics(stats *SystemStats) []MetricPoint {
+	var points []MetricPoint
+	add := func(name string, value float64, labels ...string) {
+		var l map[string]string
+		if len(labels) > 0 {
+			l = make(map[string]string, len(labels)/2)
+			for i := 0; i+1 < len(labels); i += 2 {
+				l[labels[i]] = labels[i+1]
+			}
+		}
+		points = append(points, MetricPoint{Name: name, Labels: l, Value: value})
+	}
+
+	add("memory_total_mb", stats.TotalMemoryMB)
+	add("memory_used_mb", stats.UsedMemoryMB)
+	add("memory_used_percent", stats.UsedMemoryPercent)
+	add("swap_total_mb", stats.SwapTotalMB)
+	add("swap_used_mb", stats.SwapUsedMB)
+	add("swap_used_percent", stats.SwapUsedPercent)
+	add("memory_dirty_mb", stats.DirtyMB)
+	add("memory_writeback_mb", stats.WritebackMB)
+	add("slab_reclaimable_mb", stats.Slab.ReclaimableMB)
+	add("slab_unreclaimable_mb", stats.Slab.UnreclaimableMB)
+	add("hugepages_total", float64(stats.HugePages.Total))
+	add("hugepages_free", float64(stats.HugePages.Free))
+
+	add("cpu_usage_percent", stats.TotalCPUPercentage, "core", "total")
+	for _, cpu := range stats.CPUStats {
+		add("cpu_usage_percent", cpu.UsagePct, "core", cpu.Core)
+	}
+	for mode, value := range cpuModesToJSON(stats.CPUModes) {
+		add("cpu_mode_percent", value, "mode", mode)
+	}
+
+	add("load1", stats.LoadAvg.Load1)
+	add("load5", stats.LoadAvg.Load5)
+	add("load15", stats.LoadAvg.Load15)
+	add("run_queue_per_cpu", stats.RunQueue.RunnablePerCPU)
+	add("processes_total", float64(stats.Processes.Processes))
+	add("threads_total", float64(stats.Processes.Threads))
+	add("processes_running", float64(stats.Processes.Running))
+	add("processes_blocked", float64(stats.Processes.Blocked))
+	add("context_switches_per_sec", stats.KernelActivity.ContextSwitchesPerSec)
+	add("interrupts_per_sec", stats.KernelActivity.InterruptsPerSec)
+	add("forks_per_sec", stats.KernelActivity.ForksPerSec)
+	add("file_descriptors_allocated", float64(stats.FileDescriptors.Allocated))
+	add("file_descriptors_used_percent", stats.FileDescriptors.UsedPercent)
+	add("page_faults_per_sec", stats.VMStat.PageFaultsPerSec)
+	add("major_faults_per_sec", stats.VMStat.MajorFaultsPerSec)
+	add("swap_in_per_sec", stats.VMStat.SwapInPerSec)
+	add("swap_out_per_sec", stats.VMStat.SwapOutPerSec)
+	add("tcp_retrans_percent", stats.TCPRetrans.RetransPercent)
+	add("tcp_retrans_segs_per_sec", stats.TCPRetrans.RetransSegsPerSec)
+
+	for _, disk := range stats.DiskUsage {
+		add("disk_used_mb", disk.UsedMB, "mountpoint", disk.MountPoint)
+		add("disk_free_mb", disk.FreeMB, "mountpoint", disk.MountPoint)
+		add("disk_used_percent", disk.UsedPercent, "mountpoint", disk.MountPoint)
+	}
+	for _, disk := range stats.DiskStats {
+		add("disk_read_iops", disk.ReadIOPS, "device", disk.Device)
+		add("disk_write_iops", disk.WriteIOPS, "device", disk.Device)
+		add("disk_read_bytes_per_sec", disk.ReadBytesPerSec, "device", disk.Device)
+		add("disk_write_bytes_per_sec", disk.WriteBytesPerSec, "device", disk.Device)
+		add("disk_util_percent", disk.UtilPercent, "device", disk.Device)
+		add("disk_await_ms", disk.AvgAwaitMs, "device", disk.Device)
+	}
+	for _, iface := range stats.NetInterfaces {
+		add("net_rx_bytes_per_sec", iface.RxBytesPerSec, "interface", iface.Interface)
+		add("net_tx_bytes_per_sec", iface.TxBytesPerSec, "interface", iface.Interface)
+		add("net_rx_packets_per_sec", iface.RxPacketsPerSec, "interface", iface.Interface)
+		add("net_tx_packets_per_sec", iface.TxPacketsPerSec, "interface", iface.Interface)
+		add("net_rx_errors", float64(iface.RxErrors), "interface", iface.Interface)
+		add("net_tx_errors", float64(iface.TxErrors), "interface", iface.Interface)
+		add("net_rx_dropped", float64(iface.RxDropped), "interface", iface.Interface)
+		add("net_tx_dropped", float64(iface.TxDropped), "interface", iface.Interface)
+	}
+
+	if stats.Uptime != nil {
+		add("uptime_seconds", stats.Uptime.UptimeSeconds)
+	}
+	if stats.Pressure != nil {
+		add("pressure_some_avg10", stats.Pressure.CPU.Some.Avg10, "resource", "cpu")
+		add("pressure_some_avg10", stats.Pressure.Memory.Some.Avg10, "resource", "memory")
+		add("pressure_some_avg10", stats.Pressure.IO.Some.Avg10, "resource", "io")
+		add("pressure_full_avg10", stats.Pressure.Memory.Full.Avg10, "resource", "memory")
+		add("pressure_full_avg10", stats.Pressure.IO.Full.Avg10, "resource", "io")
+	}
+	if stats.Conntrack != nil {
+		add("conntrack_count", float64(stats.Conntrack.Count))
+		add("conntrack_used_percent", stats.Conntrack.UsedPercent)
+	}
+	if stats.Sockets != nil {
+		add("tcp_inuse", float64(stats.Sockets.TCPInUse))
+		add("tcp_time_wait", float64(stats.Sockets.TCPTimeWait))
+		add("udp_inuse", float64(stats.Sockets.UDPInUse))
+	}
+	if stats.EntropyAvail != nil {
+		add("entropy_avail_bits", float64(*stats.EntropyAvail))
+	}
+	for _, temp := range stats.Temperatures {
+		add("temperature_celsius", temp.Celsius, "sensor", temp.Sensor)
+	}
+	for _, gpu := range stats.GPUs {
+		index := strconv.Itoa(gpu.Index)
+		add("gpu_utilization_percent", gpu.UtilizationPercent, "gpu", index)
+		add("gpu_memory_used_mb", gpu.MemoryUsedMB, "gpu", index)
+		add("gpu_temperature_celsius", gpu.TemperatureC, "gpu", index)
+	}
+	for _, sensor := range stats.IPMISensors {
+		add(ipmiMetricName(sensor.Type), sensor.Value, "sensor", sensor.Name)
+	}
+	for _, cg := range stats.Cgroups {
+		add("cgroup_cpu_percent", cg.CPUPercent, "cgroup", cg.Path)
+		add("cgroup_memory_bytes", float64(cg.MemoryCurrent), "cgroup", cg.Path)
+	}
+	for _, proc := range stats.WatchedProcesses {
+		pid := strconv.Itoa(proc.PID)
+		add("process_cpu_percent", proc.CPUPercent, "pid", pid, "name", proc.Name)
+		add("process_rss_mb", proc.RSSMB, "pid", pid, "name", proc.Name)
+	}
+	for _, metric := range stats.CommandMetrics {
+		if metric.Error == "" {
+			add(sanitizeLabelName(metric.Name), metric.Value)
+		}
+	}
+	// Only the numeric values of the registered collectors are metrics, named "<collector>_<key>"
+	for collector, values := range stats.Custom {
+		fields, _ := values.(map[string]any)
+		for key, value := range fields {
+			if v, ok := customMetricValue(value); ok {
+				add(sanitizeLabelName(collector+"_"+key), v)
+			}
+		}
+	}
+	return points
+}
+
+// customMetricValue converts the numeric and boolean values of a custom collector
+func customMetricValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// SampleToMetrics is SystemStatsToMetrics with the static labels of the sample added to every point.
+// Label names are sanitized to [a-zA-Z0-9_]; a point's own labels (e.g., "core") win over a static
+// label of the same name, and "host" is left to the sink.
+func SampleToMetrics(sample *Sample) []MetricPoint {
+	points := SystemStatsToMetrics(sample.Stats)
+	if len(sample.Labels) == 0 {
+		return points
+	}
+	static := make(map[string]string, len(sample.Labels))
+	for k, v := range sample.Labels {
+		if name := sanitizeLabelName(k); name != "" && name != "host" {
+			static[name] = v
+		}
+	}
+	for i := range points {
+		labels := make(map[string]string, len(static)+len(points[i].Labels))
+		for k, v := range static {
+			labels[k] = v
+		}
+		for k, v := range points[i].Labels {
+			labels[k] = v
+		}
+		points[i].Labels = labels
+	}
+	return points
+}
+
+// sanitizeLabelName makes name a valid Prometheus style label name
+func sanitizeLabelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
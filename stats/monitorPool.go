@@ -0,0 +1,491 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// MonitorPool collects from many hosts on a shared interval. Each host has its own RemoteStatsMonitor
+// (so per-host settings such as optional metric groups still apply); every cycle all hosts are
+// collected concurrently (see SetMaxConcurrency) and their samples and events, tagged with the
+// host, go to the pool's shared sinks.
+type MonitorPool struct {
+	interval         time.Duration
+	mu               sync.Mutex // Protects monitors and the fleet fields
+	monitors         map[string]*RemoteStatsMonitor
+	fleetEnabled     bool
+	fleetHandlers    []func(*FleetStats)
+	fleet            *FleetStats                   // aggregate of the last cycle
+	outlierThreshold float64                       // standard deviations, 0 disables the host comparison
+	comparison       *HostComparison               // comparison of the last cycle
+	maxWorkers       int                           // hosts collected at once, 0 for all
+	hostTimeout      time.Duration                 // deadline of a single host's collection, 0 for none
+	collecting       map[string]chan struct{}      // hosts whose collection is still running, closed when it ends
+	keepalives       map[string]context.CancelFunc // stops the keepalive of every host while running
+	runCtx           context.Context               // context of the running StartSync, nil when stopped
+	staggerSpread    time.Duration                 // hosts are spread over this much of each cycle
+	staggerJitter    time.Duration                 // random extra delay of every host
+	overrunPolicy    OverrunPolicy                 // ticks missed by a slow cycle, see SetOverrunPolicy
+	overruns         uint64                        // cycles that ran past the interval
+	lastStart        map[string]time.Time          // start of every host's last collection
+	results          map[string]*Sample            // latest sample of every host, nil if its last collection failed
+	sinks            *FanOutSink
+	errorHandler     func(host string, err error)
+	ctx              context.Context
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+	ctxMu            sync.Mutex // Protects context recreation
+}
+
+// NewMonitorPool creates an empty pool collecting every interval
+func NewMonitorPool(interval time.Duration) *MonitorPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	sinks := NewFanOutSink(0)
+	sinks.SetErrorHandler(func(sink Sink, err error) {
+		fmt.Printf("Error writing to sink %T: %v\n", sink, err)
+	})
+	return &MonitorPool{
+		interval:   interval,
+		monitors:   make(map[string]*RemoteStatsMonitor),
+		collecting: make(map[string]chan struct{}),
+		keepalives: make(map[string]context.CancelFunc),
+		lastStart:  make(map[string]time.Time),
+		results:    make(map[string]*Sample),
+		sinks:      sinks,
+		errorHandler: func(host string, err error) {
+			fmt.Printf("Error collecting stats from %s: %v\n", host, err)
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// AddMonitor adds an existing monitor under host, which becomes the host of its samples.
+// The pool drives the monitor's collection, so it must not be started separately. A monitor whose
+// interval is longer than the pool's is collected at its own interval (see SetHostInterval).
+// Hosts added while the pool is running are collected from the next cycle on.
+func (p *MonitorPool) AddMonitor(host string, monitor *RemoteStatsMonitor) error {
+	p.mu.Lock()
+	if _, ok := p.monitors[host]; ok {
+		p.mu.Unlock()
+		return fmt.Errorf("host %s is already in the pool", host)
+	}
+	monitor.SetHost(host)
+	monitor.AddSampleHandler(p.sinks.WriteSample)
+	monitor.poolEvents = hostEventSink{host: host, sinks: p.sinks}
+	p.monitors[host] = monitor
+	if p.runCtx != nil {
+		p.startKeepalive(host, monitor)
+	}
+	p.mu.Unlock()
+
+	p.sinks.WriteEvent("host_added", map[string]any{"host": host})
+	return nil
+}
+
+// startKeepalive runs the keepalive of a monitor until the pool stops or the host is removed.
+// Called with p.mu held while running.
+func (p *MonitorPool) startKeepalive(host string, monitor *RemoteStatsMonitor) {
+	if monitor.keepaliveInterval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(p.runCtx)
+	p.keepalives[host] = cancel
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		monitor.runKeepalive(ctx)
+	}()
+}
+
+// AddHost connects to address and adds it to the pool, with address as the host name.
+// The connection is made before the pool is touched, so it can be called while the pool is running.
+func (p *MonitorPool) AddHost(address string, config *ssh.ClientConfig, sampleDelta time.Duration, opts ...SSHOption) error {
+	monitor, err := NewRemoteStatsMonitorFromSSHConfig(address, config, p.interval, sampleDelta, nil, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to add host %s: %w", address, err)
+	}
+	if err := p.AddMonitor(address, monitor); err != nil {
+		monitor.Close()
+		return err
+	}
+	return nil
+}
+
+// RemoveHost removes host from the pool and closes its monitor. When the pool is running, a
+// collection of host in progress is waited for before its connection is closed.
+func (p *MonitorPool) RemoveHost(host string) error {
+	p.mu.Lock()
+	monitor, ok := p.monitors[host]
+	delete(p.monitors, host)
+	delete(p.lastStart, host)
+	delete(p.results, host)
+	if cancel, running := p.keepalives[host]; running {
+		cancel()
+		delete(p.keepalives, host)
+	}
+	done := p.collecting[host]
+	p.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("host %s is not in the pool", host)
+	}
+
+	if done != nil {
+		<-done
+	}
+	p.sinks.WriteEvent("host_removed", map[string]any{"host": host})
+	return monitor.Close()
+}
+
+// hostEventSink writes the events of a pool monitor (reboots, reconnects, timeouts, ...) to the
+// pool's sinks, tagged with the host
+type hostEventSink struct {
+	host  string
+	sinks *FanOutSink
+}
+
+func (s hostEventSink) WriteEvent(event string, fields map[string]any) error {
+	// fields is shared with the other outputs of the monitor
+	tagged := make(map[string]any, len(fields)+1)
+	maps.Copy(tagged, fields)
+	tagged["host"] = s.host
+	return s.sinks.WriteEvent(event, tagged)
+}
+
+// GetMonitor returns the monitor of host, e.g., to enable optional metric groups for it
+func (p *MonitorPool) GetMonitor(host string) (*RemoteStatsMonitor, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	monitor, ok := p.monitors[host]
+	return monitor, ok
+}
+
+// Hosts returns the hosts in the pool, sorted by name
+func (p *MonitorPool) Hosts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	hosts := make([]string, 0, len(p.monitors))
+	for host := range p.monitors {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// SetHostInterval collects host every interval instead of the pool interval, with a CPU sampling
+// window of sampleDelta (0 keeps the current one). The pool checks the hosts at its own interval,
+// so interval cannot be shorter than it and is rounded to a multiple of it; create the pool with
+// the shortest interval needed (e.g., 500ms for the system under test and 10s for the databases).
+func (p *MonitorPool) SetHostInterval(host string, interval, sampleDelta time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	monitor, ok := p.monitors[host]
+	if !ok {
+		return fmt.Errorf("host %s is not in the pool", host)
+	}
+	if interval < p.interval {
+		return fmt.Errorf("interval %v of host %s is shorter than the pool interval %v", interval, host, p.interval)
+	}
+	monitor.SetInterval(interval)
+	if sampleDelta > 0 {
+		monitor.SetSampleDelta(sampleDelta)
+	}
+	return nil
+}
+
+// isDue returns whether the host of monitor, last collected at lastStart, is due in the cycle starting at now.
+// Half a pool interval of slack keeps ticker drift from skipping a cycle.
+func (p *MonitorPool) isDue(monitor *RemoteStatsMonitor, lastStart, now time.Time) bool {
+	interval := monitor.GetInterval()
+	return interval <= p.interval || now.Sub(lastStart) >= interval-p.interval/2
+}
+
+// Healthz returns the Healthz error of every unhealthy host, empty if all hosts are healthy
+func (p *MonitorPool) Healthz() map[string]error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	unhealthy := make(map[string]error)
+	for host, monitor := range p.monitors {
+		if err := monitor.Healthz(); err != nil {
+			unhealthy[host] = err
+		}
+	}
+	return unhealthy
+}
+
+// AddSink adds a sink receiving the samples of every host. Close flushes and closes it.
+func (p *MonitorPool) AddSink(sink Sink) {
+	p.sinks.Add(sink)
+}
+
+// AddLogger writes the samples of every host to the output of logger as JSON lines including a "host" field
+func (p *MonitorPool) AddLogger(logger *log.Logger) {
+	p.AddSink(NewWriterSink(logger.Writer(), nil))
+}
+
+// SetErrorHandler replaces the function called when collecting from a host fails
+// (by default the error is printed)
+func (p *MonitorPool) SetErrorHandler(handler func(host string, err error)) {
+	p.errorHandler = handler
+}
+
+// SetSinkErrorHandler replaces the function called when a shared sink fails to write
+func (p *MonitorPool) SetSinkErrorHandler(handler func(sink Sink, err error)) {
+	p.sinks.SetErrorHandler(handler)
+}
+
+// SetMaxConcurrency limits how many hosts are collected at once (0, the default, collects all hosts
+// at the same time), bounding the connections and file descriptors used by large fleets
+func (p *MonitorPool) SetMaxConcurrency(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxWorkers = n
+}
+
+// GetMaxConcurrency returns the maximum number of hosts collected at once, 0 for no limit
+func (p *MonitorPool) GetMaxConcurrency() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.maxWorkers
+}
+
+// SetHostTimeout sets the deadline of collecting from a single host (0, the default, waits forever).
+// A host missing it is reported as failed and its connection is dropped so that the stuck reads end;
+// until they do, the host is skipped in the following cycles.
+func (p *MonitorPool) SetHostTimeout(timeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hostTimeout = timeout
+}
+
+// GetHostTimeout returns the deadline of collecting from a single host, 0 for none
+func (p *MonitorPool) GetHostTimeout() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hostTimeout
+}
+
+// collectHost collects from host within the host timeout. The caller has marked host as collecting;
+// the mark is removed once the collection returns, even if it was abandoned at the timeout.
+func (p *MonitorPool) collectHost(ctx context.Context, host string, monitor *RemoteStatsMonitor, timeout time.Duration) error {
+	_, err := monitor.collectWithTimeout(ctx, timeout, func() { p.collectionEnded(host) })
+	return err
+}
+
+// collectionEnded removes the collecting mark of host
+func (p *MonitorPool) collectionEnded(host string) {
+	p.mu.Lock()
+	close(p.collecting[host])
+	delete(p.collecting, host)
+	p.mu.Unlock()
+}
+
+// collectAll collects from every host concurrently, at most maxWorkers at a time, and waits for all of them
+func (p *MonitorPool) collectAll(ctx context.Context) {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.monitors))
+	for host := range p.monitors {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	monitors := make(map[string]*RemoteStatsMonitor, len(p.monitors))
+	delays := make(map[string]time.Duration, len(p.monitors))
+	var busy []string
+	now := time.Now()
+	for i, host := range names {
+		if !p.isDue(p.monitors[host], p.lastStart[host], now) {
+			continue
+		}
+		if p.collecting[host] != nil {
+			busy = append(busy, host)
+			p.results[host] = nil
+			continue
+		}
+		if !p.monitors[host].collectionDue(now) {
+			// Down, waiting for its next probe
+			p.results[host] = nil
+			continue
+		}
+		p.collecting[host] = make(chan struct{})
+		p.lastStart[host] = now
+		monitors[host] = p.monitors[host]
+		delays[host] = p.staggerSpread*time.Duration(i)/time.Duration(len(names)) + jitterDelay(p.staggerJitter)
+	}
+	maxWorkers, timeout := p.maxWorkers, p.hostTimeout
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, host := range busy {
+		p.errorHandler(host, fmt.Errorf("previous collection is still running"))
+	}
+	var workers chan struct{}
+	if maxWorkers > 0 {
+		workers = make(chan struct{}, maxWorkers)
+	}
+	for host, monitor := range monitors {
+		wg.Add(1)
+		go func(host string, monitor *RemoteStatsMonitor) {
+			defer wg.Done()
+			if !sleepContext(ctx, delays[host]) {
+				// Stopped before the host's turn
+				p.collectionEnded(host)
+				return
+			}
+			if workers != nil {
+				workers <- struct{}{}
+				defer func() { <-workers }()
+			}
+			err := p.collectHost(ctx, host, monitor, timeout)
+			if ctx.Err() == nil {
+				err = monitor.updateBreaker(err)
+			}
+			// A sink or handler error does not undo the collection, so check for a sample of this cycle
+			sample := monitor.GetLatestSample()
+			if sample != nil && sample.Timestamp.Before(now) {
+				sample = nil
+			}
+			p.mu.Lock()
+			if _, ok := p.monitors[host]; ok {
+				p.results[host] = sample
+			}
+			p.mu.Unlock()
+			if err != nil {
+				p.errorHandler(host, err)
+			}
+		}(host, monitor)
+	}
+	wg.Wait()
+	if ctx.Err() == nil {
+		p.recordFleet()
+	}
+}
+
+// ensureFreshContext creates a new context if the current one is cancelled
+func (p *MonitorPool) ensureFreshContext() {
+	p.ctxMu.Lock()
+	defer p.ctxMu.Unlock()
+
+	select {
+	case <-p.ctx.Done():
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+	default:
+	}
+}
+
+// StartSync collects from all hosts every interval until Stop is called (blocking call)
+func (p *MonitorPool) StartSync() error {
+	ctx := p.freshContext()
+	p.wg.Add(1)
+	defer p.wg.Done()
+	return p.run(ctx)
+}
+
+// freshContext returns the context of the pool, recreating it if it was cancelled
+func (p *MonitorPool) freshContext() context.Context {
+	p.ensureFreshContext()
+	p.ctxMu.Lock()
+	defer p.ctxMu.Unlock()
+	return p.ctx
+}
+
+// run collects every interval until ctx is done
+func (p *MonitorPool) run(ctx context.Context) error {
+	// Monitors driven by the pool are never started themselves, so their keepalives run here
+	p.mu.Lock()
+	p.runCtx = ctx
+	for host, monitor := range p.monitors {
+		p.startKeepalive(host, monitor)
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.runCtx = nil
+		for host, cancel := range p.keepalives {
+			cancel()
+			delete(p.keepalives, host)
+		}
+		p.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.collectCycle(ctx, ticker)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectCycle collects from every host, counting the cycle if it ran past the interval
+func (p *MonitorPool) collectCycle(ctx context.Context, ticker *time.Ticker) {
+	start := time.Now()
+	p.collectAll(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ctx.Err() == nil && cycleOverran(ticker, start, p.interval, p.overrunPolicy) {
+		p.overruns++
+	}
+}
+
+// StartAsync starts collecting in the background (non-blocking call)
+func (p *MonitorPool) StartAsync() error {
+	ctx := p.freshContext()
+	// Count the goroutine before it starts, so a Stop right after waits for it
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx)
+	}()
+	return nil
+}
+
+// Stop stops collecting and waits for the current cycle to finish
+func (p *MonitorPool) Stop() {
+	p.ctxMu.Lock()
+	cancel := p.cancel
+	p.ctxMu.Unlock()
+
+	cancel()
+	p.wg.Wait()
+}
+
+// Close stops collecting, closes every monitor and flushes and closes the shared sinks
+func (p *MonitorPool) Close() error {
+	p.Stop()
+
+	p.mu.Lock()
+	monitors := p.monitors
+	p.monitors = make(map[string]*RemoteStatsMonitor)
+	var pending []chan struct{}
+	for _, done := range p.collecting {
+		pending = append(pending, done)
+	}
+	p.mu.Unlock()
+
+	// Collections past their host timeout may still be running on dropped connections
+	for _, done := range pending {
+		<-done
+	}
+
+	var firstErr error
+	for host, monitor := range monitors {
+		if err := monitor.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close monitor of %s: %w", host, err)
+		}
+	}
+	if err := p.sinks.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingCollector returns once release is closed, ignoring the context like a stuck SFTP read
+type blockingCollector struct {
+	stubCollector
+	release chan struct{}
+}
+
+func (c blockingCollector) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	<-c.release
+	return &SystemStats{}, nil
+}
+
+// eventRecorder keeps the events written to it
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []map[string]any
+}
+
+func (r *eventRecorder) WriteSample(sample *Sample) error { return nil }
+
+func (r *eventRecorder) WriteEvent(event string, fields map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, map[string]any{"event": event, "host": fields["host"]})
+	return nil
+}
+
+func (r *eventRecorder) Close() error { return nil }
+
+func TestPoolReportsCollectionTimeoutToSinks(t *testing.T) {
+	collector := blockingCollector{release: make(chan struct{})}
+	pool := NewMonitorPool(time.Second)
+	pool.SetHostTimeout(20 * time.Millisecond)
+	pool.SetErrorHandler(func(host string, err error) {})
+	recorder := &eventRecorder{}
+	pool.AddSink(recorder)
+	if err := pool.AddMonitor("db1", NewStatsMonitor(collector, time.Second, time.Millisecond, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	pool.collectAll(context.Background())
+	close(collector.release)
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, event := range recorder.events {
+		if event["event"] == "collection_timeout" {
+			if event["host"] != "db1" {
+				t.Errorf("collection_timeout host = %v, want db1", event["host"])
+			}
+			return
+		}
+	}
+	t.Errorf("no collection_timeout event in %v", recorder.events)
+}
+
+func TestPoolForwardsMonitorEvents(t *testing.T) {
+	pool := NewMonitorPool(time.Second)
+	recorder := &eventRecorder{}
+	pool.AddSink(recorder)
+	monitor := NewStatsMonitor(stubCollector{}, time.Second, time.Millisecond, nil)
+	if err := pool.AddMonitor("web1", monitor); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor.logEvent("reboot", map[string]any{"uptime_seconds": 12.0})
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, event := range recorder.events {
+		if event["event"] == "reboot" {
+			if event["host"] != "web1" {
+				t.Errorf("reboot host = %v, want web1", event["host"])
+			}
+			return
+		}
+	}
+	t.Errorf("no reboot event in %v", recorder.events)
+}
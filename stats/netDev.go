@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NetInterfaceStat holds the activity of a network interface between two snapshots
+type NetInterfaceStat struct {
+	Interface       string // e.g., "eth0"
+	RxBytesPerSec   float64
+	TxBytesPerSec   float64
+	RxPacketsPerSec float64
+	TxPacketsPerSec float64
+	RxMbps          float64 // megabits per second
+	TxMbps          float64
+	RxErrors        uint64
+	TxErrors        uint64
+	RxDropped       uint64
+	TxDropped       uint64
+}
+
+// Indexes into the /proc/net/dev counters following the interface name
+const (
+	netRxBytes   = 0
+	netRxPackets = 1
+	netRxErrors  = 2
+	netRxDropped = 3
+	netTxBytes   = 8
+	netTxPackets = 9
+	netTxErrors  = 10
+	netTxDropped = 11
+	netMinFields = 16
+)
+
+func (r *remoteStatsCollector) readNetDevSnapshot(ctx context.Context) (map[string][]uint64, error) {
+	file, err := r.transport.Open(ctx, "/proc/net/dev")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stats := make(map[string][]uint64)
+	// The counters of every interface share one backing array
+	counters := make([]uint64, 0, 256)
+	err = scanProcLines(file, func(line []byte) error {
+		// Header lines have no "iface:" prefix
+		name, rest, ok := bytes.Cut(line, []byte(":"))
+		if !ok {
+			return nil
+		}
+		name = trimProcSpace(name)
+		start := len(counters)
+		for field, rest := nextField(rest); field != nil; field, rest = nextField(rest) {
+			v, ok := parseUintBytes(field)
+			if !ok {
+				return fmt.Errorf("failed to parse counters of %s: invalid value %q", name, field)
+			}
+			counters = append(counters, v)
+		}
+		if len(counters)-start < netMinFields {
+			counters = counters[:start]
+			return nil
+		}
+		stats[internName(name)] = counters[start:len(counters):len(counters)]
+		return nil
+	})
+	return stats, err
+}
+
+func computeNetInterfaceStats(stat1, stat2 *procSnapshot) []NetInterfaceStat {
+	elapsed := stat2.taken.Sub(stat1.taken)
+	var interfaces []NetInterfaceStat
+	for name, values1 := range stat1.netDev {
+		values2, ok := stat2.netDev[name]
+		if !ok {
+			continue
+		}
+		rate := func(i int) float64 {
+			return ratePerSecond(counterDelta(values1[i], values2[i]), elapsed)
+		}
+		rxBytes, txBytes := rate(netRxBytes), rate(netTxBytes)
+		interfaces = append(interfaces, NetInterfaceStat{
+			Interface:       name,
+			RxBytesPerSec:   rxBytes,
+			TxBytesPerSec:   txBytes,
+			RxPacketsPerSec: rate(netRxPackets),
+			TxPacketsPerSec: rate(netTxPackets),
+			RxMbps:          rxBytes * 8 / 1e6,
+			TxMbps:          txBytes * 8 / 1e6,
+			RxErrors:        counterDelta(values1[netRxErrors], values2[netRxErrors]),
+			TxErrors:        counterDelta(values1[netTxErrors], values2[netTxErrors]),
+			RxDropped:       counterDelta(values1[netRxDropped], values2[netRxDropped]),
+			TxDropped:       counterDelta(values1[netTxDropped], values2[netTxDropped]),
+		})
+	}
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].Interface < interfaces[j].Interface
+	})
+	return interfaces
+}
+
+// netErrorsError describes the interfaces whose error or drop counters increased, or returns nil
+func netErrorsError(interfaces []NetInterfaceStat) error {
+	var increased []string
+	for _, iface := range interfaces {
+		if iface.RxErrors+iface.TxErrors+iface.RxDropped+iface.TxDropped == 0 {
+			continue
+		}
+		increased = append(increased, fmt.Sprintf("%s (rx_errors=%d tx_errors=%d rx_dropped=%d tx_dropped=%d)",
+			iface.Interface, iface.RxErrors, iface.TxErrors, iface.RxDropped, iface.TxDropped))
+	}
+	if len(increased) == 0 {
+		return nil
+	}
+	return fmt.Errorf("network errors or drops increased on %s", strings.Join(increased, ", "))
+}
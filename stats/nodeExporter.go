@@ -0,0 +1,666 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultNodeExporterAddress is where node_exporter listens by default, as seen from its host
+const DefaultNodeExporterAddress = "127.0.0.1:9100"
+
+// NodeExporterCollector collects the stats of a host by scraping the node_exporter already running
+// on it, for fleets without a central Prometheus. The scrapes of consecutive collections, at least
+// sampleDelta apart, give the rates (CPU usage, disk and network I/O, context switches); the series node_exporter exposes for memory,
+// swap, load, uptime, filesystems, processes, file descriptors, conntrack, entropy and hwmon
+// temperatures are mapped to the same SystemStats fields as the /proc based collectors.
+// The scrapes can be tunneled through an SSH connection, so the exporter port need not be open.
+type NodeExporterCollector struct {
+	url         string // metrics endpoint, e.g., "http://10.0.0.5:9100/metrics"
+	headers     map[string]string
+	client      *http.Client
+	sampleDelta time.Duration
+	conn        *remoteStatsCollector // SSH connection the scrapes are tunneled through, nil for direct scrapes
+	prev        *promScrape           // scrape of the previous collection, the start of the next window
+	mu          sync.Mutex
+}
+
+var _ StatsCollector = (*NodeExporterCollector)(nil)
+
+// NewNodeExporterCollector creates a collector scraping the metrics endpoint at url directly
+func NewNodeExporterCollector(url string, sampleDelta time.Duration) *NodeExporterCollector {
+	return &NodeExporterCollector{
+		url:         url,
+		headers:     make(map[string]string),
+		client:      &http.Client{Timeout: 10 * time.Second},
+		sampleDelta: sampleDelta,
+	}
+}
+
+// NewNodeExporterCollectorFromSSH creates a collector scraping the node_exporter listening on
+// exporterAddress of the host (e.g., DefaultNodeExporterAddress) through sshClient
+func NewNodeExporterCollectorFromSSH(sshClient *ssh.Client, exporterAddress string, sampleDelta time.Duration) *NodeExporterCollector {
+	return newTunneledNodeExporterCollector(NewRemoteStatsCollectorFromSSHExec(sshClient, sampleDelta), exporterAddress, sampleDelta)
+}
+
+// NewNodeExporterCollectorFromSSHConfig connects to a host and scrapes the node_exporter listening on
+// exporterAddress through the connection, reconnecting when the connection is lost
+func NewNodeExporterCollectorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, exporterAddress string, sampleDelta time.Duration, opts ...SSHOption) (*NodeExporterCollector, error) {
+	conn, err := NewRemoteStatsCollectorFromSSHConfig(serverAddress, config, sampleDelta, append(opts, WithExecMode())...)
+	if err != nil {
+		return nil, err
+	}
+	return newTunneledNodeExporterCollector(conn, exporterAddress, sampleDelta), nil
+}
+
+// NewNodeExporterMonitorFromSSHConfig creates a monitor scraping the node_exporter of a host through
+// an SSH connection, named after serverAddress
+func NewNodeExporterMonitorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, exporterAddress string, interval time.Duration, sampleDelta time.Duration, logger *log.Logger, opts ...SSHOption) (*RemoteStatsMonitor, error) {
+	collector, err := NewNodeExporterCollectorFromSSHConfig(serverAddress, config, exporterAddress, sampleDelta, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m := NewStatsMonitor(collector, interval, sampleDelta, logger)
+	m.host = serverAddress
+	return m, nil
+}
+
+func newTunneledNodeExporterCollector(conn *remoteStatsCollector, exporterAddress string, sampleDelta time.Duration) *NodeExporterCollector {
+	c := NewNodeExporterCollector("http://"+exporterAddress+"/metrics", sampleDelta)
+	c.conn = conn
+	c.client.Transport = &http.Transport{
+		// Every connection is opened from the host, whatever the URL says
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			conn.connMu.Lock()
+			sshClient := conn.sshClient
+			conn.connMu.Unlock()
+			if sshClient == nil {
+				return nil, fmt.Errorf("not connected")
+			}
+			return sshClient.Dial(network, exporterAddress)
+		},
+		// Idle connections would go stale when the SSH connection is replaced
+		DisableKeepAlives: true,
+	}
+	return c
+}
+
+// SetBasicAuth authenticates the scrapes, for exporters started with a web config
+func (c *NodeExporterCollector) SetBasicAuth(username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth(username, password)
+	c.headers["Authorization"] = req.Header.Get("Authorization")
+}
+
+// SetHTTPClient replaces the HTTP client of direct scrapes, e.g., to configure TLS
+func (c *NodeExporterCollector) SetHTTPClient(client *http.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+}
+
+func (c *NodeExporterCollector) SetSampleDelta(sampleDelta time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sampleDelta = sampleDelta
+}
+
+func (c *NodeExporterCollector) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func (c *NodeExporterCollector) CanReconnect() bool { return c.conn != nil && c.conn.CanReconnect() }
+func (c *NodeExporterCollector) IsConnected() bool  { return c.conn == nil || c.conn.IsConnected() }
+
+func (c *NodeExporterCollector) Reconnect() error {
+	if c.conn == nil {
+		return fmt.Errorf("collector does not own its SSH connection and cannot reconnect")
+	}
+	return c.conn.Reconnect()
+}
+
+func (c *NodeExporterCollector) Ping(timeout time.Duration) (time.Duration, error) {
+	if c.conn == nil {
+		return 0, nil
+	}
+	return c.conn.Ping(timeout)
+}
+
+func (c *NodeExporterCollector) dropConnection() {
+	if c.conn != nil {
+		c.conn.dropConnection()
+	}
+}
+
+// promSample is one sample of the Prometheus text exposition format
+type promSample struct {
+	labels map[string]string
+	value  float64
+}
+
+// promScrape holds the samples of a scrape by metric name
+type promScrape struct {
+	taken   time.Time
+	samples map[string][]promSample
+}
+
+// value returns the first sample of name
+func (s *promScrape) value(name string) (float64, bool) {
+	samples := s.samples[name]
+	if len(samples) == 0 {
+		return 0, false
+	}
+	return samples[0].value, true
+}
+
+// scrape fetches and parses the metrics endpoint
+func (c *NodeExporterCollector) scrape(ctx context.Context) (*promScrape, error) {
+	c.mu.Lock()
+	client := c.client
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err == nil {
+		for k, value := range c.headers {
+			req.Header.Set(k, value)
+		}
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	taken := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("node_exporter scrape failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("node_exporter returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	samples, err := parsePrometheusText(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse node_exporter metrics: %w", err)
+	}
+	return &promScrape{taken: taken, samples: samples}, nil
+}
+
+func (c *NodeExporterCollector) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	c.mu.Lock()
+	sampleDelta := c.sampleDelta
+	c.mu.Unlock()
+
+	// The first collection, or one after a reboot, scrapes twice
+	scrape1 := c.prev
+	if scrape1 == nil {
+		var err error
+		if scrape1, err = c.scrape(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if !sleepContext(ctx, sampleDelta-time.Since(scrape1.taken)) {
+		return nil, ctx.Err()
+	}
+	scrape2, err := c.scrape(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.prev = scrape2
+	boot1, _ := scrape1.value("node_boot_time_seconds")
+	if boot2, _ := scrape2.value("node_boot_time_seconds"); boot1 != boot2 {
+		scrape1 = scrape2
+		if !sleepContext(ctx, sampleDelta) {
+			return nil, ctx.Err()
+		}
+		if scrape2, err = c.scrape(ctx); err != nil {
+			return nil, err
+		}
+		c.prev = scrape2
+	}
+	return nodeExporterStats(scrape1, scrape2)
+}
+
+// parsePrometheusText parses the Prometheus text exposition format, ignoring comments and timestamps
+func parsePrometheusText(r io.Reader) (map[string][]promSample, error) {
+	samples := make(map[string][]promSample)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, labels, rest, err := parsePromSeries(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("line %d: missing value", n)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		samples[name] = append(samples[name], promSample{labels: labels, value: value})
+	}
+	return samples, scanner.Err()
+}
+
+// parsePromSeries splits `name{label="value",...} rest` into its name, labels and the rest of the line
+func parsePromSeries(line string) (name string, labels map[string]string, rest string, err error) {
+	end := strings.IndexAny(line, "{ \t")
+	if end < 0 {
+		return "", nil, "", fmt.Errorf("missing value")
+	}
+	name, rest = line[:end], line[end:]
+	if name == "" {
+		return "", nil, "", fmt.Errorf("missing metric name")
+	}
+	if rest[0] != '{' {
+		return name, nil, rest, nil
+	}
+
+	labels = make(map[string]string)
+	rest = rest[1:]
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if strings.HasPrefix(rest, "}") {
+			return name, labels, rest[1:], nil
+		}
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 || len(rest) < eq+2 || rest[eq+1] != '"' {
+			return "", nil, "", fmt.Errorf("malformed labels of %s", name)
+		}
+		key := strings.TrimSpace(rest[:eq])
+		if key == "" {
+			return "", nil, "", fmt.Errorf("malformed labels of %s", name)
+		}
+		var value strings.Builder
+		i := eq + 2
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+				switch rest[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default: // \\ and \"
+					value.WriteByte(rest[i])
+				}
+				continue
+			}
+			value.WriteByte(rest[i])
+		}
+		if i >= len(rest) {
+			return "", nil, "", fmt.Errorf("unterminated label value in %s", name)
+		}
+		labels[key] = value.String()
+		rest = rest[i+1:]
+	}
+}
+
+// nodeExporterFSTypes are the filesystems node_exporter reports that df would not show
+var nodeExporterFSTypes = map[string]bool{"tmpfs": true, "devtmpfs": true, "ramfs": true, "squashfs": true, "overlay": true}
+
+// nodeExporterStats maps two scrapes of node_exporter to a sample
+func nodeExporterStats(scrape1, scrape2 *promScrape) (*SystemStats, error) {
+	totalBytes, ok := scrape2.value("node_memory_MemTotal_bytes")
+	if !ok || totalBytes <= 0 {
+		return nil, fmt.Errorf("node_exporter metrics have no node_memory_MemTotal_bytes")
+	}
+	// The clock of the host gives the window of the counters, without the latency of the scrapes
+	elapsed := scrape2.taken.Sub(scrape1.taken)
+	time1, ok1 := scrape1.value("node_time_seconds")
+	time2, ok2 := scrape2.value("node_time_seconds")
+	if ok1 && ok2 && time2 > time1 {
+		elapsed = time.Duration((time2 - time1) * float64(time.Second))
+	}
+
+	stats := &SystemStats{CollectionMode: CollectionNodeExporter}
+	const mb = 1024 * 1024
+	availableBytes, _ := scrape2.value("node_memory_MemAvailable_bytes")
+	stats.TotalMemoryMB = totalBytes / mb
+	stats.UsedMemoryMB = (totalBytes - availableBytes) / mb
+	stats.UsedMemoryPercent = stats.UsedMemoryMB / stats.TotalMemoryMB * 100
+	swapTotal, _ := scrape2.value("node_memory_SwapTotal_bytes")
+	swapFree, _ := scrape2.value("node_memory_SwapFree_bytes")
+	stats.SwapTotalMB = swapTotal / mb
+	stats.SwapUsedMB = (swapTotal - swapFree) / mb
+	if swapTotal > 0 {
+		stats.SwapUsedPercent = (swapTotal - swapFree) / swapTotal * 100
+	}
+	dirty, _ := scrape2.value("node_memory_Dirty_bytes")
+	writeback, _ := scrape2.value("node_memory_Writeback_bytes")
+	stats.DirtyMB, stats.WritebackMB = dirty/mb, writeback/mb
+
+	stats.LoadAvg.Load1, _ = scrape2.value("node_load1")
+	stats.LoadAvg.Load5, _ = scrape2.value("node_load5")
+	stats.LoadAvg.Load15, _ = scrape2.value("node_load15")
+	if now, ok := scrape2.value("node_time_seconds"); ok {
+		if boot, ok := scrape2.value("node_boot_time_seconds"); ok && boot > 0 {
+			stats.Uptime = &UptimeStats{UptimeSeconds: now - boot}
+		}
+	}
+
+	running, _ := scrape2.value("node_procs_running")
+	blocked, _ := scrape2.value("node_procs_blocked")
+	stats.Processes.Running, stats.Processes.Blocked = int(running), int(blocked)
+	stats.LoadAvg.RunnableProcs = int(running)
+
+	computeNodeExporterCPU(stats, scrape1, scrape2)
+	stats.RunQueue = RunQueueStats{Runnable: int(running), CPUCount: len(stats.CPUStats)}
+	if len(stats.CPUStats) > 0 {
+		stats.RunQueue.RunnablePerCPU = running / float64(len(stats.CPUStats))
+	}
+
+	rate := func(name string) float64 {
+		v1, ok1 := scrape1.value(name)
+		v2, ok2 := scrape2.value(name)
+		if !ok1 || !ok2 {
+			return 0
+		}
+		return ratePerSecond(counterDelta(uint64(v1), uint64(v2)), elapsed)
+	}
+	stats.KernelActivity = KernelActivityStats{
+		ContextSwitchesPerSec: rate("node_context_switches_total"),
+		InterruptsPerSec:      rate("node_intr_total"),
+		ForksPerSec:           rate("node_forks_total"),
+	}
+
+	if allocated, ok := scrape2.value("node_filefd_allocated"); ok {
+		maximum, _ := scrape2.value("node_filefd_maximum")
+		stats.FileDescriptors = FileDescriptorStats{Allocated: uint64(allocated), Max: uint64(maximum)}
+		if maximum > 0 {
+			stats.FileDescriptors.UsedPercent = allocated / maximum * 100
+		}
+	}
+	if entries, ok := scrape2.value("node_nf_conntrack_entries"); ok {
+		limit, _ := scrape2.value("node_nf_conntrack_entries_limit")
+		stats.Conntrack = &ConntrackStats{Count: uint64(entries), Max: uint64(limit)}
+		if limit > 0 {
+			stats.Conntrack.UsedPercent = entries / limit * 100
+		}
+	}
+	if entropy, ok := scrape2.value("node_entropy_available_bits"); ok {
+		bits := int(entropy)
+		stats.EntropyAvail = &bits
+	}
+
+	stats.DiskUsage = nodeExporterDiskUsage(scrape2)
+	stats.DiskStats = nodeExporterDiskIO(scrape1, scrape2, elapsed)
+	stats.NetInterfaces = nodeExporterNetInterfaces(scrape1, scrape2, elapsed)
+	stats.Temperatures = nodeExporterTemperatures(scrape2)
+	return stats, nil
+}
+
+// computeNodeExporterCPU sets the total and per core usage from node_cpu_seconds_total{cpu,mode}
+func computeNodeExporterCPU(stats *SystemStats, scrape1, scrape2 *promScrape) {
+	byCore := func(s *promScrape) map[string]map[string]float64 {
+		cores := make(map[string]map[string]float64)
+		for _, sample := range s.samples["node_cpu_seconds_total"] {
+			core := sample.labels["cpu"]
+			if cores[core] == nil {
+				cores[core] = make(map[string]float64)
+			}
+			cores[core][sample.labels["mode"]] = sample.value
+		}
+		return cores
+	}
+	cores1, cores2 := byCore(scrape1), byCore(scrape2)
+
+	var totalDeltas map[string]float64
+	var names []string
+	perCore := make(map[string]float64)
+	for core, modes2 := range cores2 {
+		modes1, ok := cores1[core]
+		if !ok {
+			continue
+		}
+		deltas := make(map[string]float64)
+		var total float64
+		for mode, v2 := range modes2 {
+			if delta := v2 - modes1[mode]; delta > 0 {
+				deltas[mode] = delta
+				total += delta
+			}
+		}
+		if total <= 0 {
+			continue
+		}
+		// iowait is counted as busy time, like the /proc based collectors
+		perCore[core] = 100 - deltas["idle"]/total*100
+		names = append(names, core)
+		if totalDeltas == nil {
+			totalDeltas = make(map[string]float64)
+		}
+		for mode, delta := range deltas {
+			totalDeltas[mode] += delta
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, _ := strconv.Atoi(names[i])
+		b, _ := strconv.Atoi(names[j])
+		return a < b
+	})
+	for _, core := range names {
+		stats.CPUStats = append(stats.CPUStats, CPUStat{Core: "cpu" + core, UsagePct: perCore[core]})
+	}
+
+	var total float64
+	for _, delta := range totalDeltas {
+		total += delta
+	}
+	if total <= 0 {
+		return
+	}
+	percent := func(mode string) float64 {
+		return totalDeltas[mode] / total * 100
+	}
+	stats.CPUModes = CPUModeBreakdown{
+		User:    percent("user"),
+		Nice:    percent("nice"),
+		System:  percent("system"),
+		Idle:    percent("idle"),
+		IOWait:  percent("iowait"),
+		IRQ:     percent("irq"),
+		SoftIRQ: percent("softirq"),
+		Steal:   percent("steal"),
+	}
+	stats.TotalCPUPercentage = 100 - stats.CPUModes.Idle
+}
+
+// nodeExporterDiskUsage maps node_filesystem_* to the usage of the mounted block devices, like df
+func nodeExporterDiskUsage(s *promScrape) []DiskUsage {
+	key := func(labels map[string]string) string {
+		return labels["mountpoint"]
+	}
+	free := make(map[string]float64)
+	for _, sample := range s.samples["node_filesystem_free_bytes"] {
+		free[key(sample.labels)] = sample.value
+	}
+	avail := make(map[string]float64)
+	for _, sample := range s.samples["node_filesystem_avail_bytes"] {
+		avail[key(sample.labels)] = sample.value
+	}
+
+	const mb = 1024 * 1024
+	var usage []DiskUsage
+	seen := make(map[string]bool)
+	for _, sample := range s.samples["node_filesystem_size_bytes"] {
+		labels := sample.labels
+		mountPoint := key(labels)
+		if sample.value <= 0 || seen[mountPoint] || nodeExporterFSTypes[labels["fstype"]] || !strings.HasPrefix(labels["device"], "/") {
+			continue
+		}
+		seen[mountPoint] = true
+		total := sample.value / mb
+		used := (sample.value - free[mountPoint]) / mb
+		available := avail[mountPoint] / mb
+		// Same formula as df: used / (used + available to non-root)
+		var percent float64
+		if used+available > 0 {
+			percent = used / (used + available) * 100.0
+		}
+		usage = append(usage, DiskUsage{
+			MountPoint:  mountPoint,
+			Device:      labels["device"],
+			FSType:      labels["fstype"],
+			TotalMB:     total,
+			UsedMB:      used,
+			FreeMB:      available,
+			UsedPercent: percent,
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].MountPoint < usage[j].MountPoint
+	})
+	return usage
+}
+
+// labeledDeltas returns the counter increase of each series of name between the scrapes, by label
+func labeledDeltas(scrape1, scrape2 *promScrape, name, label string) map[string]uint64 {
+	before := make(map[string]float64)
+	for _, sample := range scrape1.samples[name] {
+		before[sample.labels[label]] = sample.value
+	}
+	deltas := make(map[string]uint64)
+	for _, sample := range scrape2.samples[name] {
+		if v1, ok := before[sample.labels[label]]; ok {
+			deltas[sample.labels[label]] = counterDelta(uint64(v1), uint64(sample.value))
+		}
+	}
+	return deltas
+}
+
+// labeledSeconds is labeledDeltas for counters of seconds, in milliseconds
+func labeledSeconds(scrape1, scrape2 *promScrape, name, label string) map[string]float64 {
+	before := make(map[string]float64)
+	for _, sample := range scrape1.samples[name] {
+		before[sample.labels[label]] = sample.value
+	}
+	deltas := make(map[string]float64)
+	for _, sample := range scrape2.samples[name] {
+		if v1, ok := before[sample.labels[label]]; ok {
+			deltas[sample.labels[label]] = max(0, sample.value-v1) * 1000
+		}
+	}
+	return deltas
+}
+
+// nodeExporterDiskIO maps the node_disk_* counters to the I/O of each block device
+func nodeExporterDiskIO(scrape1, scrape2 *promScrape, elapsed time.Duration) []DiskIOStat {
+	reads := labeledDeltas(scrape1, scrape2, "node_disk_reads_completed_total", "device")
+	writes := labeledDeltas(scrape1, scrape2, "node_disk_writes_completed_total", "device")
+	readBytes := labeledDeltas(scrape1, scrape2, "node_disk_read_bytes_total", "device")
+	writtenBytes := labeledDeltas(scrape1, scrape2, "node_disk_written_bytes_total", "device")
+	ioTime := labeledSeconds(scrape1, scrape2, "node_disk_io_time_seconds_total", "device")
+	weightedTime := labeledSeconds(scrape1, scrape2, "node_disk_io_time_weighted_seconds_total", "device")
+	readTime := labeledSeconds(scrape1, scrape2, "node_disk_read_time_seconds_total", "device")
+	writeTime := labeledSeconds(scrape1, scrape2, "node_disk_write_time_seconds_total", "device")
+	inProgress := make(map[string]uint64)
+	for _, sample := range scrape2.samples["node_disk_io_now"] {
+		inProgress[sample.labels["device"]] = uint64(sample.value)
+	}
+
+	elapsedMs := float64(elapsed.Milliseconds())
+	var disks []DiskIOStat
+	for device, r := range reads {
+		w := writes[device]
+		disk := DiskIOStat{
+			Device:           device,
+			ReadsCompleted:   r,
+			WritesCompleted:  w,
+			SectorsRead:      readBytes[device] / diskSectorBytes,
+			SectorsWritten:   writtenBytes[device] / diskSectorBytes,
+			IOTimeMs:         uint64(ioTime[device]),
+			ReadIOPS:         ratePerSecond(r, elapsed),
+			WriteIOPS:        ratePerSecond(w, elapsed),
+			ReadBytesPerSec:  ratePerSecond(readBytes[device], elapsed),
+			WriteBytesPerSec: ratePerSecond(writtenBytes[device], elapsed),
+			IOsInProgress:    inProgress[device],
+		}
+		if elapsedMs > 0 {
+			disk.UtilPercent = min(ioTime[device]/elapsedMs*100.0, 100.0)
+			disk.AvgQueueSize = weightedTime[device] / elapsedMs
+		}
+		if ios := r + w; ios > 0 {
+			disk.AvgAwaitMs = (readTime[device] + writeTime[device]) / float64(ios)
+		}
+		disks = append(disks, disk)
+	}
+	sort.Slice(disks, func(i, j int) bool {
+		return disks[i].Device < disks[j].Device
+	})
+	return disks
+}
+
+// nodeExporterNetInterfaces maps the node_network_* counters to the traffic of each interface
+func nodeExporterNetInterfaces(scrape1, scrape2 *promScrape, elapsed time.Duration) []NetInterfaceStat {
+	counter := func(name string) map[string]uint64 {
+		return labeledDeltas(scrape1, scrape2, "node_network_"+name+"_total", "device")
+	}
+	rxBytes, txBytes := counter("receive_bytes"), counter("transmit_bytes")
+	rxPackets, txPackets := counter("receive_packets"), counter("transmit_packets")
+	rxErrors, txErrors := counter("receive_errs"), counter("transmit_errs")
+	rxDropped, txDropped := counter("receive_drop"), counter("transmit_drop")
+
+	var interfaces []NetInterfaceStat
+	for name, rx := range rxBytes {
+		rxRate, txRate := ratePerSecond(rx, elapsed), ratePerSecond(txBytes[name], elapsed)
+		interfaces = append(interfaces, NetInterfaceStat{
+			Interface:       name,
+			RxBytesPerSec:   rxRate,
+			TxBytesPerSec:   txRate,
+			RxPacketsPerSec: ratePerSecond(rxPackets[name], elapsed),
+			TxPacketsPerSec: ratePerSecond(txPackets[name], elapsed),
+			RxMbps:          rxRate * 8 / 1e6,
+			TxMbps:          txRate * 8 / 1e6,
+			RxErrors:        rxErrors[name],
+			TxErrors:        txErrors[name],
+			RxDropped:       rxDropped[name],
+			TxDropped:       txDropped[name],
+		})
+	}
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].Interface < interfaces[j].Interface
+	})
+	return interfaces
+}
+
+// nodeExporterTemperatures maps node_hwmon_temp_celsius{chip,sensor}, labeled with node_hwmon_sensor_label
+func nodeExporterTemperatures(s *promScrape) []TemperatureStat {
+	sensorLabels := make(map[string]string)
+	for _, sample := range s.samples["node_hwmon_sensor_label"] {
+		sensorLabels[sample.labels["chip"]+"/"+sample.labels["sensor"]] = sample.labels["label"]
+	}
+	var temperatures []TemperatureStat
+	for _, sample := range s.samples["node_hwmon_temp_celsius"] {
+		sensor := sample.labels["chip"] + "/" + sample.labels["sensor"]
+		label := sample.labels["chip"]
+		if name := sensorLabels[sensor]; name != "" {
+			label += "/" + name
+		}
+		temperatures = append(temperatures, TemperatureStat{Sensor: sensor, Label: label, Celsius: sample.value})
+	}
+	sort.Slice(temperatures, func(i, j int) bool {
+		return temperatures[i].Sensor < temperatures[j].Sensor
+	})
+	return temperatures
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleNodeExporterMetrics = `# HELP node_cpu_seconds_total Seconds the CPUs spent in each mode.
+# TYPE node_cpu_seconds_total counter
+node_cpu_seconds_total{cpu="0",mode="idle"} 7606.42
+node_cpu_seconds_total{cpu="0",mode="user"} 563.49
+# HELP node_filesystem_avail_bytes Filesystem space available to non-root users in bytes.
+# TYPE node_filesystem_avail_bytes gauge
+node_filesystem_avail_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/srv/a \"b\", c\\d\nnext"} 1.2e+10
+
+# TYPE node_load1 gauge
+  node_load1 0.52
+node_time_seconds 1.712345678e+09 1712345678123
+# HELP http_request_duration_seconds A histogram of latencies.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.1"} 10
+http_request_duration_seconds_bucket{le="+Inf"} 12
+http_request_duration_seconds_sum 1.75
+http_request_duration_seconds_count 12
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.99",} NaN
+rpc_duration_seconds_sum +Inf
+rpc_duration_seconds_count -Inf
+node_empty_labels{} 3
+`
+
+func TestParsePrometheusText(t *testing.T) {
+	got, err := parsePrometheusText(strings.NewReader(sampleNodeExporterMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]promSample{
+		"node_cpu_seconds_total": {
+			{labels: map[string]string{"cpu": "0", "mode": "idle"}, value: 7606.42},
+			{labels: map[string]string{"cpu": "0", "mode": "user"}, value: 563.49},
+		},
+		"node_filesystem_avail_bytes": {
+			{labels: map[string]string{"device": "/dev/sda1", "fstype": "ext4", "mountpoint": "/srv/a \"b\", c\\d\nnext"}, value: 1.2e10},
+		},
+		"node_load1":        {{value: 0.52}},
+		"node_time_seconds": {{value: 1712345678}},
+		"node_empty_labels": {{labels: map[string]string{}, value: 3}},
+		"http_request_duration_seconds_bucket": {
+			{labels: map[string]string{"le": "0.1"}, value: 10},
+			{labels: map[string]string{"le": "+Inf"}, value: 12},
+		},
+		"http_request_duration_seconds_sum":   {{value: 1.75}},
+		"http_request_duration_seconds_count": {{value: 12}},
+		"rpc_duration_seconds_sum":            {{value: math.Inf(1)}},
+		"rpc_duration_seconds_count":          {{value: math.Inf(-1)}},
+	}
+
+	// NaN is not equal to itself, compare it apart
+	nan := got["rpc_duration_seconds"]
+	if len(nan) != 1 || !math.IsNaN(nan[0].value) || !reflect.DeepEqual(nan[0].labels, map[string]string{"quantile": "0.99"}) {
+		t.Errorf("rpc_duration_seconds = %+v, want NaN with quantile 0.99", nan)
+	}
+	delete(got, "rpc_duration_seconds")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePrometheusText() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParsePrometheusTextErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // part of the error
+	}{
+		{"node_load1\n", "line 1: missing value"},
+		{"# TYPE node_load1 gauge\nnode_load1 \n", "line 2: missing value"},
+		{`node_load1{cpu="0"}` + "\n", "line 1: missing value"},
+		{"node_load1 high\n", "line 1: "},
+		{`node_cpu{cpu=0} 1` + "\n", "line 1: malformed labels"},
+		{`node_cpu{cpu} 1` + "\n", "line 1: malformed labels"},
+		{`node_cpu{="0"} 1` + "\n", "line 1: malformed labels"},
+		{`node_cpu{cpu="0} 1` + "\n", "line 1: unterminated label value"},
+		{`node_cpu{cpu="0"` + "\n", "line 1: malformed labels"},
+		{`{cpu="0"} 1` + "\n", "line 1: missing metric name"},
+	}
+	for _, tt := range tests {
+		_, err := parsePrometheusText(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parsePrometheusText(%q) error = %v, want one containing %q", tt.input, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultOOMCheckInterval is how often OOM kills are checked for when no interval is set
+const defaultOOMCheckInterval = 30 * time.Second
+
+// OOMKillEvent describes a process killed by the kernel OOM killer
+type OOMKillEvent struct {
+	PID     int    // zero when the victim could not be identified from the kernel log
+	Process string // victim command name
+}
+
+// oomKillPattern matches kernel log lines like "Out of memory: Killed process 1234 (java) total-vm:..."
+var oomKillPattern = regexp.MustCompile(`Killed process (\d+) \(([^)]*)\)`)
+
+// SetOOMDetectionEnabled enables or disables periodic detection of OOM kill events
+func (r *remoteStatsCollector) SetOOMDetectionEnabled(enabled bool) {
+	r.oomEnabled = enabled
+	r.oomBaselined = false
+}
+
+// IsOOMDetectionEnabled returns whether OOM kill events are detected
+func (r *remoteStatsCollector) IsOOMDetectionEnabled() bool {
+	return r.oomEnabled
+}
+
+// SetOOMCheckInterval sets how often the host is checked for new OOM kills
+func (r *remoteStatsCollector) SetOOMCheckInterval(interval time.Duration) {
+	r.oomInterval = interval
+}
+
+// GetOOMCheckInterval returns how often the host is checked for new OOM kills
+func (r *remoteStatsCollector) GetOOMCheckInterval() time.Duration {
+	return r.oomInterval
+}
+
+// readKernelLogKills returns the OOM victims found in the kernel ring buffer, or in
+// /var/log/kern.log when dmesg is restricted to root
+func (r *remoteStatsCollector) readKernelLogKills(ctx context.Context) []OOMKillEvent {
+	output, err := r.runRemoteCommand(ctx, "dmesg 2>/dev/null || tail -n 2000 /var/log/kern.log")
+	if err != nil {
+		return nil
+	}
+	var kills []OOMKillEvent
+	for _, line := range strings.Split(output, "\n") {
+		if m := oomKillPattern.FindStringSubmatch(line); m != nil {
+			pid, _ := strconv.Atoi(m[1])
+			kills = append(kills, OOMKillEvent{PID: pid, Process: m[2]})
+		}
+	}
+	return kills
+}
+
+// checkOOMKills returns the OOM kills that happened since the previous check. The first check only
+// records a baseline so kills from before monitoring started are not reported.
+func (r *remoteStatsCollector) checkOOMKills(ctx context.Context) ([]OOMKillEvent, error) {
+	interval := r.oomInterval
+	if interval <= 0 {
+		interval = defaultOOMCheckInterval
+	}
+	if r.oomBaselined && time.Since(r.oomCheckedAt) < interval {
+		return nil, nil
+	}
+	r.oomCheckedAt = time.Now()
+
+	vmstat, err := r.readVmstat(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Kernels before 4.13 have no oom_kill counter, so the kernel log is the only source
+	count, hasCounter := vmstat["oom_kill"]
+	var logKills []OOMKillEvent
+	if !hasCounter {
+		logKills = r.readKernelLogKills(ctx)
+		count = uint64(len(logKills))
+	}
+
+	if !r.oomBaselined {
+		r.oomBaselined = true
+		r.oomKillCount = count
+		return nil, nil
+	}
+	newKills := counterDelta(r.oomKillCount, count)
+	r.oomKillCount = count
+	if newKills == 0 {
+		return nil, nil
+	}
+
+	if hasCounter {
+		logKills = r.readKernelLogKills(ctx)
+	}
+	// The newest entries of the log are the kills counted since the previous check
+	events := make([]OOMKillEvent, 0, newKills)
+	if start := len(logKills) - int(newKills); start >= 0 {
+		events = append(events, logKills[start:]...)
+	} else {
+		events = append(events, logKills...)
+		for len(events) < int(newKills) {
+			events = append(events, OOMKillEvent{Process: "unknown"})
+		}
+	}
+	return events, nil
+}
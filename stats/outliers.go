@@ -0,0 +1,155 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// comparedMetrics are the values compared across the hosts of a fleet
+var comparedMetrics = []struct {
+	name  string
+	value func(*SystemStats) float64
+}{
+	{"cpu_percentage", func(s *SystemStats) float64 { return s.TotalCPUPercentage }},
+	{"memory_used_percent", func(s *SystemStats) float64 { return s.UsedMemoryPercent }},
+	{"swap_used_percent", func(s *SystemStats) float64 { return s.SwapUsedPercent }},
+	{"load1", func(s *SystemStats) float64 { return s.LoadAvg.Load1 }},
+	{"disk_used_percent_max", func(s *SystemStats) float64 {
+		highest := 0.0
+		for _, disk := range s.DiskUsage {
+			highest = math.Max(highest, disk.UsedPercent)
+		}
+		return highest
+	}},
+}
+
+// MetricSummary describes one compared metric across the fleet
+type MetricSummary struct {
+	Mean   float64
+	StdDev float64 // population standard deviation
+	Min    float64
+	Max    float64
+}
+
+// HostOutlier is a host whose metric is more than the threshold of standard deviations from the fleet mean
+type HostOutlier struct {
+	Host   string
+	Metric string
+	Value  float64
+	ZScore float64 // standard deviations from the mean, negative below it
+}
+
+// HostComparison compares the latest samples of several hosts
+type HostComparison struct {
+	Timestamp time.Time
+	Hosts     int
+	Threshold float64
+	Metrics   map[string]MetricSummary // by metric name, e.g., "cpu_percentage"
+	Outliers  []HostOutlier            // largest deviation first
+}
+
+// CompareHosts summarizes CPU, memory, swap, load and disk usage across samples and reports the
+// hosts more than threshold standard deviations from the mean (e.g., 3). The host itself is part of
+// the mean, so with n hosts no deviation exceeds (n-1)/√n: small fleets need a lower threshold.
+// Fewer than 3 samples give no outliers.
+func CompareHosts(samples []*Sample, threshold float64) *HostComparison {
+	comparison := &HostComparison{
+		Timestamp: time.Now(),
+		Hosts:     len(samples),
+		Threshold: threshold,
+		Metrics:   make(map[string]MetricSummary, len(comparedMetrics)),
+	}
+	if len(samples) == 0 {
+		return comparison
+	}
+
+	values := make([]float64, len(samples))
+	for _, metric := range comparedMetrics {
+		summary := MetricSummary{Min: math.Inf(1), Max: math.Inf(-1)}
+		var sum float64
+		for i, sample := range samples {
+			values[i] = metric.value(sample.Stats)
+			sum += values[i]
+			summary.Min = math.Min(summary.Min, values[i])
+			summary.Max = math.Max(summary.Max, values[i])
+		}
+		summary.Mean = sum / float64(len(values))
+		var squares float64
+		for _, v := range values {
+			squares += (v - summary.Mean) * (v - summary.Mean)
+		}
+		summary.StdDev = math.Sqrt(squares / float64(len(values)))
+		comparison.Metrics[metric.name] = summary
+
+		if len(samples) < 3 || summary.StdDev == 0 || threshold <= 0 {
+			continue
+		}
+		for i, sample := range samples {
+			z := (values[i] - summary.Mean) / summary.StdDev
+			if math.Abs(z) > threshold {
+				comparison.Outliers = append(comparison.Outliers, HostOutlier{
+					Host:   sample.Host,
+					Metric: metric.name,
+					Value:  values[i],
+					ZScore: z,
+				})
+			}
+		}
+	}
+	sort.SliceStable(comparison.Outliers, func(i, j int) bool {
+		return math.Abs(comparison.Outliers[i].ZScore) > math.Abs(comparison.Outliers[j].ZScore)
+	})
+	return comparison
+}
+
+// HostComparisonToJSON renders the comparison with snake_case keys
+func HostComparisonToJSON(comparison *HostComparison) map[string]any {
+	metrics := make(map[string]any, len(comparison.Metrics))
+	for name, summary := range comparison.Metrics {
+		metrics[name] = map[string]any{
+			"mean":   summary.Mean,
+			"stddev": summary.StdDev,
+			"min":    summary.Min,
+			"max":    summary.Max,
+		}
+	}
+	outliers := make([]map[string]any, 0, len(comparison.Outliers))
+	for _, outlier := range comparison.Outliers {
+		outliers = append(outliers, map[string]any{
+			"host":    outlier.Host,
+			"metric":  outlier.Metric,
+			"value":   outlier.Value,
+			"z_score": outlier.ZScore,
+		})
+	}
+	return map[string]any{
+		"hosts":     comparison.Hosts,
+		"threshold": comparison.Threshold,
+		"metrics":   metrics,
+		"outliers":  outliers,
+	}
+}
+
+// SetOutlierThreshold writes a "host_comparison" event comparing the latest samples of all hosts
+// to the shared sinks after every cycle, listing the hosts more than threshold standard deviations
+// from the fleet mean (see CompareHosts). 0, the default, disables the comparison.
+func (p *MonitorPool) SetOutlierThreshold(threshold float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.outlierThreshold = threshold
+}
+
+// GetOutlierThreshold returns the outlier threshold in standard deviations, 0 if disabled
+func (p *MonitorPool) GetOutlierThreshold() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.outlierThreshold
+}
+
+// GetHostComparison returns the comparison of the last cycle, nil if disabled or before the first cycle
+func (p *MonitorPool) GetHostComparison() *HostComparison {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.comparison
+}
@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GroupError is the failure of one metric group of a sample, e.g., "memory" or "disk usage"
+type GroupError struct {
+	Group string
+	Err   error
+}
+
+func (e GroupError) Error() string {
+	return fmt.Sprintf("failed to get %s: %v", e.Group, e.Err)
+}
+
+func (e GroupError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is returned by GetSystemStats along with a sample when some of its metric groups
+// failed. The sample holds the groups that succeeded and lists the failures in SystemStats.Errors;
+// the fields of the failed groups are left zero.
+type PartialError struct {
+	Errors []GroupError
+}
+
+func (e *PartialError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, groupErr := range e.Errors {
+		messages[i] = groupErr.Error()
+	}
+	return "partial sample: " + strings.Join(messages, "; ")
+}
+
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, groupErr := range e.Errors {
+		errs[i] = groupErr
+	}
+	return errs
+}
+
+// isPartial reports whether err came with a usable sample (see PartialError)
+func isPartial(stats *SystemStats, err error) bool {
+	var partial *PartialError
+	return stats != nil && errors.As(err, &partial)
+}
+
+// groupErrorsToJSON renders the failed metric groups of a partial sample
+func groupErrorsToJSON(errs []GroupError) []map[string]any {
+	list := make([]map[string]any, len(errs))
+	for i, groupErr := range errs {
+		list[i] = map[string]any{"group": groupErr.Group, "error": groupErr.Err.Error()}
+	}
+	return list
+}
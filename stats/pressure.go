@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PressureLine holds one line ("some" or "full") of a /proc/pressure file
+type PressureLine struct {
+	Avg10   float64 // percentage of time stalled over the last 10s
+	Avg60   float64
+	Avg300  float64
+	TotalUs uint64 // total stall time in microseconds
+}
+
+// PressureResource holds the stall information for a single resource
+type PressureResource struct {
+	Some PressureLine // at least one task stalled
+	Full PressureLine // all non-idle tasks stalled (not reported for cpu on older kernels)
+}
+
+// PressureStats holds the Pressure Stall Information (PSI) of the host
+type PressureStats struct {
+	CPU    PressureResource
+	Memory PressureResource
+	IO     PressureResource
+}
+
+// getPressureStats reads /proc/pressure/{cpu,memory,io}.
+// It returns nil without an error when the kernel does not support PSI.
+func (r *remoteStatsCollector) getPressureStats(ctx context.Context) (*PressureStats, error) {
+	var pressure PressureStats
+	for _, res := range []struct {
+		name   string
+		target *PressureResource
+	}{
+		{"cpu", &pressure.CPU},
+		{"memory", &pressure.Memory},
+		{"io", &pressure.IO},
+	} {
+		content, err := r.readRemoteFile(ctx, "/proc/pressure/"+res.name)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if *res.target, err = parsePressure(content); err != nil {
+			return nil, fmt.Errorf("failed to parse %s pressure: %w", res.name, err)
+		}
+	}
+	return &pressure, nil
+}
+
+// parsePressure parses lines like "some avg10=0.00 avg60=0.00 avg300=0.00 total=0"
+func parsePressure(content string) (PressureResource, error) {
+	var res PressureResource
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var target *PressureLine
+		switch fields[0] {
+		case "some":
+			target = &res.Some
+		case "full":
+			target = &res.Full
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			var err error
+			switch key {
+			case "avg10":
+				target.Avg10, err = strconv.ParseFloat(value, 64)
+			case "avg60":
+				target.Avg60, err = strconv.ParseFloat(value, 64)
+			case "avg300":
+				target.Avg300, err = strconv.ParseFloat(value, 64)
+			case "total":
+				target.TotalUs, err = strconv.ParseUint(value, 10, 64)
+			}
+			if err != nil {
+				return PressureResource{}, fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
+	}
+	return res, nil
+}
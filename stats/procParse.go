@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// The /proc parsers run several times per collection on every monitored host, so they work on the
+// bytes of the scanned lines: fields are sliced instead of split into strings, numbers are parsed
+// in place and the scan buffers are pooled.
+
+// scanBuffers holds the line buffers of the /proc scanners. They are large enough for the "intr"
+// line of /proc/stat, which has one counter per interrupt.
+var scanBuffers = sync.Pool{New: func() any {
+	buf := make([]byte, 64*1024)
+	return &buf
+}}
+
+// maxProcLine is the longest line the /proc scanners accept
+const maxProcLine = 1024 * 1024
+
+// scanProcLines calls fn with every line of file. The line is only valid until fn returns.
+func scanProcLines(file io.Reader, fn func(line []byte) error) error {
+	buf := scanBuffers.Get().(*[]byte)
+	defer scanBuffers.Put(buf)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(*buf, maxProcLine)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func isProcSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// nextField returns the first whitespace-separated field of b and what follows it, with a nil
+// field when b holds only whitespace
+func nextField(b []byte) (field, rest []byte) {
+	start := 0
+	for start < len(b) && isProcSpace(b[start]) {
+		start++
+	}
+	if start == len(b) {
+		return nil, nil
+	}
+	end := start
+	for end < len(b) && !isProcSpace(b[end]) {
+		end++
+	}
+	return b[start:end], b[end:]
+}
+
+// trimProcSpace removes the leading and trailing whitespace of b
+func trimProcSpace(b []byte) []byte {
+	for len(b) > 0 && isProcSpace(b[0]) {
+		b = b[1:]
+	}
+	for len(b) > 0 && isProcSpace(b[len(b)-1]) {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// parseUintBytes parses a decimal counter, failing on anything else (including overflow)
+func parseUintBytes(b []byte) (uint64, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	var v uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		d := uint64(c - '0')
+		if v > (1<<64-1-d)/10 {
+			return 0, false
+		}
+		v = v*10 + d
+	}
+	return v, true
+}
+
+// parseIntBytes parses a decimal value that may be negative (e.g., "-1" for an unlimited MaxConn)
+func parseIntBytes(b []byte) (int64, bool) {
+	negative := len(b) > 0 && b[0] == '-'
+	if negative {
+		b = b[1:]
+	}
+	v, ok := parseUintBytes(b)
+	if !ok || v > 1<<63 || (v == 1<<63 && !negative) {
+		return 0, false
+	}
+	if negative {
+		return -int64(v), true
+	}
+	return int64(v), true
+}
+
+// procNames interns the names read from /proc (counters, devices, interfaces), which repeat on
+// every collection, so that keying the maps of a snapshot does not allocate a string per line.
+// Names past maxProcNames (e.g., short-lived virtual interfaces) are not kept.
+var procNames = struct {
+	sync.RWMutex
+	names map[string]string
+}{names: make(map[string]string)}
+
+const maxProcNames = 16 * 1024
+
+func internName(b []byte) string {
+	procNames.RLock()
+	name, ok := procNames.names[string(b)]
+	procNames.RUnlock()
+	if ok {
+		return name
+	}
+	name = string(b)
+	procNames.Lock()
+	if len(procNames.names) < maxProcNames {
+		procNames.names[name] = name
+	}
+	procNames.Unlock()
+	return name
+}
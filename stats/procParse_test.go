@@ -0,0 +1,523 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// fileTransport serves fixed file contents, keyed by path
+type fileTransport map[string]string
+
+func (t fileTransport) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	content, ok := t[path]
+	if !ok {
+		return nil, fmt.Errorf("open %s: %w", path, fs.ErrNotExist)
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (t fileTransport) ReadDir(ctx context.Context, path string) ([]os.FileInfo, error) {
+	return nil, fs.ErrNotExist
+}
+
+func (t fileTransport) Glob(ctx context.Context, pattern string) ([]string, error) {
+	return nil, nil
+}
+
+func (t fileTransport) StatVFS(ctx context.Context, path string) (*sftp.StatVFS, error) {
+	return nil, fs.ErrNotExist
+}
+
+func (t fileTransport) Run(ctx context.Context, cmd string) (string, error) {
+	return "", fmt.Errorf("cannot run %q", cmd)
+}
+
+const sampleProcStat = `cpu  2255413 1437 601357 30431720 31588 0 21164 0 0 0
+cpu0 563491 364 150627 7606427 8041 0 10418 0 0 0
+cpu1 564110 359 150192 7608693 7814 0 4371 0 0 0
+cpu2 563845 352 150381 7608412 7903 0 3236 0 0 0
+cpu3 563967 362 150157 7608188 7830 0 3139 0 0 0
+intr 183726352 9 0 0 0 0 0 0 0 0 0 0 0 156 0 0 0 0 0 0 0 0 0 0 0 0 1 0 1842367 0 412 97
+ctxt 392847561
+btime 1791995402
+processes 1728813
+procs_running 3
+procs_blocked 0
+softirq 98173625 4 31824077 28 4512310 1562013 0 173845 33651283 1812 26448253
+`
+
+const sampleMeminfo = `MemTotal:       16303428 kB
+MemFree:         1523744 kB
+MemAvailable:    9482216 kB
+Buffers:          512344 kB
+Cached:          7310280 kB
+SwapCached:         3120 kB
+Active:          8201732 kB
+Inactive:        5025884 kB
+SwapTotal:       2097148 kB
+SwapFree:        2076924 kB
+Dirty:               580 kB
+Writeback:             0 kB
+Shmem:            402816 kB
+Slab:             689428 kB
+HugePages_Total:       0
+HugePages_Free:        0
+Hugepagesize:       2048 kB
+DirectMap4k:      419596 kB
+DirectMap2M:    12111872 kB
+`
+
+const sampleDiskstats = `   7       0 loop0 81 0 2140 25 0 0 0 0 0 48 25 0 0 0 0 0 0
+   7       1 loop1 52 0 712 11 0 0 0 0 0 28 11 0 0 0 0 0 0
+ 259       0 nvme0n1 482133 121077 38120554 118290 1924718 1289541 96734192 2143891 0 1053012 2281045 0 0 0 0 90811 18863
+ 259       1 nvme0n1p1 335 1011 18964 101 2 0 2 0 0 128 101 0 0 0 0 0 0
+ 259       2 nvme0n1p2 481701 120066 38097830 118156 1924716 1289541 96734190 2143891 0 1052912 2262047 0 0 0 0 0 0
+   8       0 sda 12733 3014 1214370 30214 4012 1908 502712 18430 0 21870 48644
+   1       0 ram0 0 0 0 0 0 0 0 0 0 0 0
+ 253       0 dm-0 601212 0 38094114 156930 3214289 0 96734192 5290012 0 1063400 5446942 0 0 0 0 0 0
+`
+
+const sampleNetDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 85274617  412851    0    0    0     0          0         0 85274617  412851    0    0    0     0       0          0
+  eth0: 9137751394 8213651    0 1204    0     0          0     11201 1084326931 4012377    0    0    0     0       0          0
+ wlan0:       0       0    0    0    0     0          0         0        0       0    0    0    0     0       0          0
+docker0: 2313011   21312    0    0    0     0          0         0 59123840   34121    0    3    0     0       0          0
+`
+
+const sampleSnmp = `Ip: Forwarding DefaultTTL InReceives InHdrErrors InAddrErrors ForwDatagrams InUnknownProtos InDiscards InDelivers OutRequests OutDiscards OutNoRoutes
+Ip: 1 64 12437221 0 4 0 0 0 12431502 10214377 12 48
+Icmp: InMsgs InErrors InCsumErrors InDestUnreachs OutMsgs OutErrors
+Icmp: 2312 21 0 2240 2385 0
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 214311 3812 11427 5123 27 11872612 12430142 18322 17 31264 0
+Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+Udp: 512344 2213 0 521398 0 0 0 1021 0
+`
+
+const sampleVmstat = `nr_free_pages 380936
+nr_zone_inactive_anon 121412
+nr_zone_active_anon 1323872
+nr_dirty 145
+nr_writeback 0
+pgpgin 19060277
+pgpgout 48367096
+pswpin 112
+pswpout 5141
+pgfault 1392130584
+pgmajfault 41290
+pgsteal_kswapd 2213342
+oom_kill 0
+`
+
+// The reference parsers below are the string-based parsers the byte-based ones replaced
+
+func legacyProcStat(content string) (map[string][]float64, map[string]uint64) {
+	stats := make(map[string][]float64)
+	counters := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if !strings.HasPrefix(line, "cpu") {
+			if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				counters[fields[0]] = v
+			}
+			continue
+		}
+		values := make([]float64, 0, len(fields)-1)
+		for _, f := range fields[1:] {
+			v, _ := strconv.ParseFloat(f, 64)
+			values = append(values, v)
+		}
+		stats[fields[0]] = values
+	}
+	return stats, counters
+}
+
+func legacyMeminfo(content string) map[string]float64 {
+	meminfo := make(map[string]float64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		val, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		meminfo[strings.TrimSuffix(fields[0], ":")] = val
+	}
+	return meminfo
+}
+
+func legacyDiskSnapshot(content string) map[string][]uint64 {
+	stats := make(map[string][]uint64)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3+diskMinFields {
+			continue
+		}
+		device := fields[2]
+		if strings.HasPrefix(device, "loop") || strings.HasPrefix(device, "ram") {
+			continue
+		}
+		values := make([]uint64, 0, len(fields)-3)
+		for _, f := range fields[3:] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				break
+			}
+			values = append(values, v)
+		}
+		if len(values) < diskMinFields {
+			continue
+		}
+		stats[device] = values
+	}
+	return stats
+}
+
+func legacyNetDevSnapshot(content string) map[string][]uint64 {
+	stats := make(map[string][]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < netMinFields {
+			continue
+		}
+		values := make([]uint64, 0, len(fields))
+		for _, f := range fields {
+			v, _ := strconv.ParseUint(f, 10, 64)
+			values = append(values, v)
+		}
+		stats[strings.TrimSpace(name)] = values
+	}
+	return stats
+}
+
+func legacySnmp(content string) map[string]map[string]int64 {
+	result := make(map[string]map[string]int64)
+	headers := make(map[string][]string)
+	for _, line := range strings.Split(content, "\n") {
+		prefix, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		names, seen := headers[prefix]
+		if !seen {
+			headers[prefix] = fields
+			continue
+		}
+		values := make(map[string]int64)
+		for i := 0; i < len(names) && i < len(fields); i++ {
+			if v, err := strconv.ParseInt(fields[i], 10, 64); err == nil {
+				values[names[i]] = v
+			}
+		}
+		result[prefix] = values
+		delete(headers, prefix)
+	}
+	return result
+}
+
+func legacyVmstat(content string) map[string]uint64 {
+	vmstat := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			vmstat[fields[0]] = v
+		}
+	}
+	return vmstat
+}
+
+func procFiles(procStat, meminfo, diskstats, netDev, snmp, vmstat string) fileTransport {
+	return fileTransport{
+		"/proc/stat":      procStat,
+		"/proc/meminfo":   meminfo,
+		"/proc/diskstats": diskstats,
+		"/proc/net/dev":   netDev,
+		"/proc/net/snmp":  snmp,
+		"/proc/vmstat":    vmstat,
+	}
+}
+
+func TestProcParsersMatchStringParsers(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fileTransport
+	}{
+		{"sample", procFiles(sampleProcStat, sampleMeminfo, sampleDiskstats, sampleNetDev, sampleSnmp, sampleVmstat)},
+		{"large host", procFiles(largeProcStat(32), sampleMeminfo, largeDiskstats(12), largeNetDev(8), sampleSnmp, sampleVmstat)},
+		{"CRLF and no trailing newline", procFiles(
+			strings.ReplaceAll(strings.TrimSuffix(sampleProcStat, "\n"), "\n", "\r\n"),
+			strings.TrimSuffix(sampleMeminfo, "\n"),
+			strings.TrimSuffix(sampleDiskstats, "\n"),
+			strings.TrimSuffix(sampleNetDev, "\n"),
+			strings.TrimSuffix(sampleSnmp, "\n"),
+			strings.TrimSuffix(sampleVmstat, "\n"),
+		)},
+		{"empty files", procFiles("", "", "", "", "", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &remoteStatsCollector{transport: tt.files}
+
+			cpu, counters, err := r.readProcStat(ctx)
+			if err != nil {
+				t.Fatalf("readProcStat: %v", err)
+			}
+			wantCPU, wantCounters := legacyProcStat(strings.ReplaceAll(tt.files["/proc/stat"], "\r", ""))
+			if !reflect.DeepEqual(cpu, wantCPU) {
+				t.Errorf("readProcStat cpu = %v, want %v", cpu, wantCPU)
+			}
+			if !reflect.DeepEqual(counters, wantCounters) {
+				t.Errorf("readProcStat counters = %v, want %v", counters, wantCounters)
+			}
+
+			meminfo, err := r.readMeminfo(ctx)
+			if err != nil {
+				t.Fatalf("readMeminfo: %v", err)
+			}
+			if want := legacyMeminfo(tt.files["/proc/meminfo"]); !reflect.DeepEqual(meminfo, want) {
+				t.Errorf("readMeminfo = %v, want %v", meminfo, want)
+			}
+
+			disks, err := r.readDiskSnapshot(ctx)
+			if err != nil {
+				t.Fatalf("readDiskSnapshot: %v", err)
+			}
+			if want := legacyDiskSnapshot(tt.files["/proc/diskstats"]); !reflect.DeepEqual(disks, want) {
+				t.Errorf("readDiskSnapshot = %v, want %v", disks, want)
+			}
+
+			netDev, err := r.readNetDevSnapshot(ctx)
+			if err != nil {
+				t.Fatalf("readNetDevSnapshot: %v", err)
+			}
+			if want := legacyNetDevSnapshot(tt.files["/proc/net/dev"]); !reflect.DeepEqual(netDev, want) {
+				t.Errorf("readNetDevSnapshot = %v, want %v", netDev, want)
+			}
+
+			snmp, err := r.readSnmp(ctx, "/proc/net/snmp")
+			if err != nil {
+				t.Fatalf("readSnmp: %v", err)
+			}
+			if want := legacySnmp(tt.files["/proc/net/snmp"]); !reflect.DeepEqual(snmp, want) {
+				t.Errorf("readSnmp = %v, want %v", snmp, want)
+			}
+
+			vmstat, err := r.readVmstat(ctx)
+			if err != nil {
+				t.Fatalf("readVmstat: %v", err)
+			}
+			if want := legacyVmstat(tt.files["/proc/vmstat"]); !reflect.DeepEqual(vmstat, want) {
+				t.Errorf("readVmstat = %v, want %v", vmstat, want)
+			}
+		})
+	}
+}
+
+func TestProcParsersRejectInvalidCounters(t *testing.T) {
+	ctx := context.Background()
+	r := &remoteStatsCollector{transport: procFiles(
+		"cpu  12 x 3 4\n", "", "",
+		"  eth0: 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 -16\n", "", "",
+	)}
+	if _, _, err := r.readProcStat(ctx); err == nil {
+		t.Error("readProcStat accepted a non-numeric CPU value")
+	}
+	if _, err := r.readNetDevSnapshot(ctx); err == nil {
+		t.Error("readNetDevSnapshot accepted a negative counter")
+	}
+}
+
+func TestParseUintBytes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"42", 42, true},
+		{"18446744073709551615", 1<<64 - 1, true},
+		{"18446744073709551616", 0, false},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"1.5", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseUintBytes([]byte(tt.in))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseUintBytes(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseIntBytes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"-1", -1, true},
+		{"120000", 120000, true},
+		{"9223372036854775807", 1<<63 - 1, true},
+		{"-9223372036854775808", -1 << 63, true},
+		{"9223372036854775808", 0, false},
+		{"-", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseIntBytes([]byte(tt.in))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseIntBytes(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// largeProcStat returns a /proc/stat of a host with the given number of cores
+func largeProcStat(cores int) string {
+	var b strings.Builder
+	b.WriteString("cpu  72173216 45984 19243424 973815040 1010816 0 677248 0 0 0\n")
+	for i := 0; i < cores; i++ {
+		fmt.Fprintf(&b, "cpu%d %d 1437 601357 30431720 31588 0 21164 0 0 0\n", i, 2255413+i*977)
+	}
+	b.WriteString("intr 183726352")
+	for i := 0; i < 1024; i++ {
+		fmt.Fprintf(&b, " %d", i%7*31)
+	}
+	b.WriteString("\nctxt 392847561\nbtime 1791995402\nprocesses 1728813\nprocs_running 3\nprocs_blocked 0\n")
+	b.WriteString("softirq 98173625 4 31824077 28 4512310 1562013 0 173845 33651283 1812 26448253\n")
+	return b.String()
+}
+
+// largeDiskstats returns a /proc/diskstats with the given number of disks and a loop device
+func largeDiskstats(disks int) string {
+	var b strings.Builder
+	b.WriteString("   7       0 loop0 81 0 2140 25 0 0 0 0 0 48 25 0 0 0 0 0 0\n")
+	for i := 0; i < disks; i++ {
+		fmt.Fprintf(&b, "   8      %2d sd%c %d 121077 38120554 118290 1924718 1289541 96734192 2143891 0 1053012 2281045 0 0 0 0 90811 18863\n",
+			i*16, 'a'+i, 482133+i)
+	}
+	return b.String()
+}
+
+// largeNetDev returns a /proc/net/dev with the given number of interfaces
+func largeNetDev(interfaces int) string {
+	var b strings.Builder
+	b.WriteString("Inter-|   Receive                                                |  Transmit\n")
+	b.WriteString(" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n")
+	for i := 0; i < interfaces; i++ {
+		fmt.Fprintf(&b, "  eth%d: %d 8213651    0 1204    0     0          0     11201 1084326931 4012377    0    0    0     0       0          0\n",
+			i, 9137751394+i)
+	}
+	return b.String()
+}
+
+func benchmarkCollector() *remoteStatsCollector {
+	return &remoteStatsCollector{transport: procFiles(largeProcStat(32), sampleMeminfo, largeDiskstats(12), largeNetDev(8), sampleSnmp, sampleVmstat)}
+}
+
+func BenchmarkReadProcStat(b *testing.B) {
+	r := benchmarkCollector()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := r.readProcStat(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadMeminfo(b *testing.B) {
+	r := benchmarkCollector()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.readMeminfo(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadDiskSnapshot(b *testing.B) {
+	r := benchmarkCollector()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.readDiskSnapshot(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadNetDevSnapshot(b *testing.B) {
+	r := benchmarkCollector()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.readNetDevSnapshot(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadVmstat(b *testing.B) {
+	r := benchmarkCollector()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.readVmstat(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTakeSnapshot(b *testing.B) {
+	r := benchmarkCollector()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.takeSnapshot(ctx, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The string-based parsers, as a baseline for the benchmarks above
+
+func BenchmarkLegacyProcStat(b *testing.B) {
+	content := largeProcStat(32)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyProcStat(content)
+	}
+}
+
+func BenchmarkLegacyMeminfo(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyMeminfo(sampleMeminfo)
+	}
+}
@@ -0,0 +1,103 @@
+package stats
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// ProcessStats holds system-wide process and thread counts
+type ProcessStats struct {
+	Processes       int // entries under /proc
+	Threads         int // scheduling entities, from /proc/loadavg
+	Running         int // procs_running from /proc/stat
+	Blocked         int // procs_blocked (waiting on I/O) from /proc/stat
+	Zombie          int // only when process states are counted
+	Uninterruptible int // D-state, only when process states are counted
+}
+
+// countProcesses counts the numeric (PID) directories under /proc
+func (r *remoteStatsCollector) countProcesses(ctx context.Context) (int, error) {
+	entries, err := r.transport.ReadDir(ctx, "/proc")
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := strconv.Atoi(entry.Name()); err == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// getProcessStats counts the processes, and their states if enabled. The running, blocked and thread
+// counts come from the snapshot and the load average of the sample.
+func (r *remoteStatsCollector) getProcessStats(ctx context.Context) (ProcessStats, error) {
+	processes, err := r.countProcesses(ctx)
+	if err != nil {
+		return ProcessStats{}, err
+	}
+	stats := ProcessStats{Processes: processes}
+	if r.processStatesEnabled {
+		if stats.Zombie, stats.Uninterruptible, err = r.countProcessStates(ctx); err != nil {
+			return ProcessStats{}, err
+		}
+	}
+	return stats, nil
+}
+
+// SetProcessStatesEnabled enables or disables counting zombie and uninterruptible (D-state) processes
+func (r *remoteStatsCollector) SetProcessStatesEnabled(enabled bool) {
+	r.processStatesEnabled = enabled
+}
+
+// IsProcessStatesEnabled returns whether zombie and D-state processes are counted
+func (r *remoteStatsCollector) IsProcessStatesEnabled() bool {
+	return r.processStatesEnabled
+}
+
+// countProcessStates counts zombie and uninterruptible processes with a single ps call,
+// falling back to reading every /proc/<pid>/stat when there is no SSH client
+func (r *remoteStatsCollector) countProcessStates(ctx context.Context) (zombie, uninterruptible int, err error) {
+	var states []string
+	if r.sshClient != nil {
+		output, err := r.runRemoteCommand(ctx, "ps -e -o stat=")
+		if err != nil {
+			return 0, 0, err
+		}
+		states = strings.Fields(output)
+	} else {
+		entries, err := r.transport.ReadDir(ctx, "/proc")
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, entry := range entries {
+			if _, err := strconv.Atoi(entry.Name()); err != nil {
+				continue
+			}
+			content, err := r.readRemoteFile(ctx, "/proc/"+entry.Name()+"/stat")
+			if err != nil {
+				// Process exited while scanning
+				continue
+			}
+			// The state follows the command name, which may itself contain spaces and parentheses
+			if i := strings.LastIndexByte(content, ')'); i >= 0 && i+2 < len(content) {
+				states = append(states, content[i+2:i+3])
+			}
+		}
+	}
+
+	for _, state := range states {
+		switch state[0] {
+		case 'Z':
+			zombie++
+		case 'D':
+			uninterruptible++
+		}
+	}
+	return zombie, uninterruptible, nil
+}
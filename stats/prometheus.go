@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusExporter keeps the latest sample of every host and serves it in the
+// Prometheus text exposition format
+type PrometheusExporter struct {
+	namespace string
+	mu        sync.RWMutex
+	latest    map[string]*Sample // host -> latest sample
+	server    *http.Server
+}
+
+// NewPrometheusExporter creates an exporter whose metric names are prefixed with namespace
+// (e.g., "remote_stats" gives "remote_stats_cpu_usage_percent")
+func NewPrometheusExporter(namespace string) *PrometheusExporter {
+	return &PrometheusExporter{
+		namespace: namespace,
+		latest:    make(map[string]*Sample),
+	}
+}
+
+// WriteSample records sample as the latest one of its host
+func (e *PrometheusExporter) WriteSample(sample *Sample) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.latest[sample.Host] = sample
+	return nil
+}
+
+// RemoveHost stops exporting the metrics of host
+func (e *PrometheusExporter) RemoveHost(host string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.latest, host)
+}
+
+// ServeHTTP writes the latest metrics of every host
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	e.writeMetrics(bw)
+	bw.Flush()
+}
+
+// ListenAndServe serves the metrics on /metrics at addr (e.g., ":9100") until Close is called
+func (e *PrometheusExporter) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+
+	e.mu.Lock()
+	e.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	server := e.server
+	e.mu.Unlock()
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Close stops the HTTP server started by ListenAndServe
+func (e *PrometheusExporter) Close() error {
+	e.mu.Lock()
+	server := e.server
+	e.server = nil
+	e.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+func (e *PrometheusExporter) writeMetrics(w *bufio.Writer) {
+	e.mu.RLock()
+	hosts := make([]string, 0, len(e.latest))
+	for host := range e.latest {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	// Group the points of every host by metric name, as required by the exposition format
+	families := make(map[string][]string)
+	for _, host := range hosts {
+		for _, point := range SampleToMetrics(e.latest[host]) {
+			name := e.metricName(point.Name)
+			families[name] = append(families[name], name+formatPrometheusLabels(host, point.Labels)+" "+
+				strconv.FormatFloat(point.Value, 'g', -1, 64))
+		}
+	}
+	e.mu.RUnlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		for _, line := range families[name] {
+			w.WriteString(line)
+			w.WriteByte('\n')
+		}
+	}
+}
+
+func (e *PrometheusExporter) metricName(name string) string {
+	if e.namespace == "" {
+		return name
+	}
+	return e.namespace + "_" + name
+}
+
+// formatPrometheusLabels renders {host="...",core="..."} with the label names sorted
+func formatPrometheusLabels(host string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(`{host="`)
+	b.WriteString(escapePrometheusLabel(host))
+	b.WriteByte('"')
+	for _, k := range keys {
+		b.WriteByte(',')
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(escapePrometheusLabel(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func escapePrometheusLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// DialFunc opens the network connection the SSH session runs over
+type DialFunc func(network, address string) (net.Conn, error)
+
+// WithDialer makes the SSH connection (and reconnects) over connections opened by dial
+func WithDialer(dial DialFunc) SSHOption {
+	return func(o *sshOptions) error {
+		o.dial = dial
+		return nil
+	}
+}
+
+// WithProxyURL connects through a proxy: "socks5://[user:pass@]host:port" or
+// "http://[user:pass@]host:port" (HTTP CONNECT)
+func WithProxyURL(proxyURL string) SSHOption {
+	return func(o *sshOptions) error {
+		dial, err := ProxyDialer(proxyURL)
+		if err != nil {
+			return err
+		}
+		o.dial = dial
+		return nil
+	}
+}
+
+// ProxyDialer returns a DialFunc tunneling connections through the proxy at proxyURL (see WithProxyURL)
+func ProxyDialer(proxyURL string) (DialFunc, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(u, &net.Dialer{Timeout: 30 * time.Second})
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOCKS5 proxy: %w", err)
+		}
+		return dialer.Dial, nil
+	case "http":
+		return func(network, address string) (net.Conn, error) {
+			return dialHTTPConnect(u, network, address)
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+}
+
+// bufferedConn reads through the reader used for the proxy response, which may already hold
+// the first bytes sent by the SSH server
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// dialHTTPConnect opens a tunnel to address with an HTTP CONNECT request to the proxy
+func dialHTTPConnect(proxyURL *url.URL, network, address string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout(network, proxyAddr, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy: %w", err)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", address, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+// dialSSH connects to address using the dialer of the options, if any
+func (o *sshOptions) dialSSH(address string) (*ssh.Client, error) {
+	if o.dial == nil {
+		return ssh.Dial("tcp", address, o.config)
+	}
+	conn, err := o.dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, address, o.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
@@ -0,0 +1,209 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedfishCollector reads the power draw and thermal sensors of a host from its BMC through the
+// Redfish REST API. It is a MetricCollector, so registering it on the monitor of the host (see
+// RegisterCollector) puts the readings in the same samples as the OS stats, e.g., under "redfish":
+//
+//	"power_watts": 212, "temperature_celsius_CPU1_Temp": 45, "fan_FAN1_rpm": 3200
+//
+// The power is summed over every chassis; temperatures and fans are keyed by sensor name.
+type RedfishCollector struct {
+	url     string // base URL of the BMC, e.g., "https://10.0.0.5"
+	headers map[string]string
+	client  *http.Client
+	mu      sync.Mutex
+	chassis []string // paths of the chassis, listed on the first collection
+}
+
+// NewRedfishCollector creates a collector for the BMC at url. BMCs often have self-signed
+// certificates, which need an HTTP client with their CA (see SetHTTPClient).
+func NewRedfishCollector(url string) *RedfishCollector {
+	return &RedfishCollector{
+		url:     strings.TrimRight(url, "/"),
+		headers: make(map[string]string),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetBasicAuth authenticates the requests with a BMC username and password
+func (c *RedfishCollector) SetBasicAuth(username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth(username, password)
+	c.headers["Authorization"] = req.Header.Get("Authorization")
+}
+
+// SetHTTPClient replaces the HTTP client, e.g., to configure TLS
+func (c *RedfishCollector) SetHTTPClient(client *http.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+}
+
+// errRedfishNotFound is returned for resources the BMC does not implement
+var errRedfishNotFound = errors.New("not found")
+
+// get decodes the resource at path (e.g., "/redfish/v1/Chassis") into v
+func (c *RedfishCollector) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	c.mu.Lock()
+	for k, value := range c.headers {
+		req.Header.Set(k, value)
+	}
+	client := c.client
+	c.mu.Unlock()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("redfish request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", path, errRedfishNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("redfish %s returned %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode redfish %s: %w", path, err)
+	}
+	return nil
+}
+
+// redfishCollection is a Redfish resource collection, e.g., /redfish/v1/Chassis
+type redfishCollection struct {
+	Members []struct {
+		ID string `json:"@odata.id"`
+	} `json:"Members"`
+}
+
+// redfishPower is the (deprecated but widely implemented) Power resource of a chassis
+type redfishPower struct {
+	PowerControl []struct {
+		PowerConsumedWatts *float64 `json:"PowerConsumedWatts"`
+	} `json:"PowerControl"`
+}
+
+// redfishEnvironment is the EnvironmentMetrics resource replacing Power and Thermal in newer BMCs
+type redfishEnvironment struct {
+	PowerWatts *struct {
+		Reading *float64 `json:"Reading"`
+	} `json:"PowerWatts"`
+}
+
+// redfishThermal is the Thermal resource of a chassis
+type redfishThermal struct {
+	Temperatures []struct {
+		Name           string   `json:"Name"`
+		ReadingCelsius *float64 `json:"ReadingCelsius"`
+	} `json:"Temperatures"`
+	Fans []struct {
+		Name         string   `json:"Name"`
+		FanName      string   `json:"FanName"` // instead of Name in older schemas
+		Reading      *float64 `json:"Reading"`
+		ReadingUnits string   `json:"ReadingUnits"` // "RPM" or "Percent"
+	} `json:"Fans"`
+}
+
+// listChassis returns the chassis paths, cached after the first successful listing
+func (c *RedfishCollector) listChassis(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	chassis := c.chassis
+	c.mu.Unlock()
+	if chassis != nil {
+		return chassis, nil
+	}
+	var collection redfishCollection
+	if err := c.get(ctx, "/redfish/v1/Chassis", &collection); err != nil {
+		return nil, err
+	}
+	chassis = []string{}
+	for _, member := range collection.Members {
+		chassis = append(chassis, member.ID)
+	}
+	c.mu.Lock()
+	c.chassis = chassis
+	c.mu.Unlock()
+	return chassis, nil
+}
+
+// Collect reads the power and thermal resources of every chassis
+func (c *RedfishCollector) Collect(ctx context.Context) (map[string]any, error) {
+	chassis, err := c.listChassis(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	var powerWatts float64
+	hasPower := false
+	for _, path := range chassis {
+		// Chassis without power readings (e.g., a backplane) answer 404 or leave the values null
+		var power redfishPower
+		if err := c.get(ctx, path+"/Power", &power); err == nil {
+			for _, control := range power.PowerControl {
+				if control.PowerConsumedWatts != nil {
+					powerWatts += *control.PowerConsumedWatts
+					hasPower = true
+				}
+			}
+		} else if errors.Is(err, errRedfishNotFound) {
+			var env redfishEnvironment
+			if err := c.get(ctx, path+"/EnvironmentMetrics", &env); err == nil && env.PowerWatts != nil && env.PowerWatts.Reading != nil {
+				powerWatts += *env.PowerWatts.Reading
+				hasPower = true
+			}
+		} else {
+			return nil, err
+		}
+
+		var thermal redfishThermal
+		if err := c.get(ctx, path+"/Thermal", &thermal); err != nil {
+			if errors.Is(err, errRedfishNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		for _, temp := range thermal.Temperatures {
+			if temp.ReadingCelsius != nil {
+				values["temperature_celsius_"+sanitizeLabelName(temp.Name)] = *temp.ReadingCelsius
+			}
+		}
+		for _, fan := range thermal.Fans {
+			name := fan.Name
+			if name == "" {
+				name = fan.FanName
+			}
+			if fan.Reading == nil {
+				continue
+			}
+			unit := "rpm"
+			if strings.EqualFold(fan.ReadingUnits, "Percent") {
+				unit = "percent"
+			}
+			values["fan_"+sanitizeLabelName(name)+"_"+unit] = *fan.Reading
+		}
+	}
+	if hasPower {
+		values["power_watts"] = powerWatts
+	}
+	return values, nil
+}
@@ -0,0 +1,138 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MetricCollector collects additional values merged into every sample, under the name it was
+// registered with (see SystemStats.Custom)
+type MetricCollector interface {
+	Collect(ctx context.Context) (map[string]any, error)
+}
+
+// MetricCollectorFunc adapts a function to a MetricCollector
+type MetricCollectorFunc func(ctx context.Context) (map[string]any, error)
+
+func (f MetricCollectorFunc) Collect(ctx context.Context) (map[string]any, error) {
+	return f(ctx)
+}
+
+// CollectorRegistry holds named metric collectors. A registry may be shared by several monitors
+// (see RemoteStatsMonitor.SetCollectorRegistry), e.g., for collectors that do not depend on the host.
+type CollectorRegistry struct {
+	mu         sync.RWMutex
+	collectors map[string]MetricCollector
+}
+
+// NewCollectorRegistry creates an empty registry
+func NewCollectorRegistry() *CollectorRegistry {
+	return &CollectorRegistry{collectors: make(map[string]MetricCollector)}
+}
+
+// Register adds collector under name, which must not be used by another collector of the registry
+func (g *CollectorRegistry) Register(name string, collector MetricCollector) error {
+	if name == "" {
+		return errors.New("collector name must not be empty")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.collectors[name]; exists {
+		return fmt.Errorf("collector %q is already registered", name)
+	}
+	g.collectors[name] = collector
+	return nil
+}
+
+// Unregister removes the collector registered under name, if any
+func (g *CollectorRegistry) Unregister(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.collectors, name)
+}
+
+// Names returns the names of the registered collectors, sorted
+func (g *CollectorRegistry) Names() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	names := make([]string, 0, len(g.collectors))
+	for name := range g.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Collect runs every collector concurrently and returns their values by collector name. Failed
+// collectors are left out of the result and reported together in the error.
+func (g *CollectorRegistry) Collect(ctx context.Context) (map[string]any, error) {
+	g.mu.RLock()
+	collectors := make(map[string]MetricCollector, len(g.collectors))
+	for name, collector := range g.collectors {
+		collectors[name] = collector
+	}
+	g.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]any, len(collectors))
+	var errs []error
+	for name, collector := range collectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values, err := collector.Collect(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("collector %q: %w", name, err))
+				return
+			}
+			results[name] = values
+		}()
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
+
+// RegisterCollector adds collector to the registry of the monitor under name. Its values are added
+// to every sample in SystemStats.Custom; a failing collector is reported as a "collector_error" event
+// without failing the sample. Collectors are cancelled after the monitoring interval.
+func (m *RemoteStatsMonitor) RegisterCollector(name string, collector MetricCollector) error {
+	if m.collectors == nil {
+		m.collectors = NewCollectorRegistry()
+	}
+	return m.collectors.Register(name, collector)
+}
+
+// SetCollectorRegistry replaces the registry of the monitor, nil to remove every collector
+func (m *RemoteStatsMonitor) SetCollectorRegistry(registry *CollectorRegistry) {
+	m.collectors = registry
+}
+
+// GetCollectorRegistry returns the registry of the monitor, nil if no collector was registered
+func (m *RemoteStatsMonitor) GetCollectorRegistry() *CollectorRegistry {
+	return m.collectors
+}
+
+// collectCustom merges the values of the registered collectors into stats
+func (m *RemoteStatsMonitor) collectCustom(ctx context.Context, stats *SystemStats) {
+	if m.collectors == nil {
+		return
+	}
+	if interval := m.GetInterval(); interval > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, interval)
+		defer cancel()
+	}
+	custom, err := m.collectors.Collect(ctx)
+	if len(custom) > 0 {
+		stats.Custom = custom
+	}
+	if err != nil {
+		m.logEvent("collector_error", map[string]any{"error": err.Error()})
+	}
+}
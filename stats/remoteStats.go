@@ -1,46 +1,148 @@
 package stats
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
+	"io"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/sync/errgroup"
 )
 
 type CPUStat struct {
 	Core     string // e.g., "cpu0", "cpu1"
 	UsagePct float64
+	Modes    *CPUModeBreakdown // only when per-core mode breakdown is enabled
 }
 
 type SystemStats struct {
-	TotalMemoryMB      float64
+	TotalMemoryMB      float64 // not in the JSON of a sample, see SystemInfo
 	UsedMemoryMB       float64
 	UsedMemoryPercent  float64
-	TotalCPUPercentage float64   // "cpu" aggregate line
-	CPUStats           []CPUStat // only "cpu0", "cpu1", ...
+	SwapTotalMB        float64
+	SwapUsedMB         float64
+	SwapUsedPercent    float64 // zero when the host has no swap
+	DirtyMB            float64 // memory waiting to be written back to disk
+	WritebackMB        float64 // memory actively being written back
+	HugePages          HugePagesStats
+	Slab               SlabStats
+	MeminfoExtra       map[string]float64 // fields set with SetExtraMeminfoFields, in kB like /proc/meminfo
+	TotalCPUPercentage float64            // "cpu" aggregate line
+	CPUModes           CPUModeBreakdown   // "cpu" aggregate line, per mode
+	CPUStats           []CPUStat          // only "cpu0", "cpu1", ...
+	DiskUsage          []DiskUsage
+	DiskStats          []DiskIOStat // per block device, over the sampleDelta window
+	LoadAvg            LoadAvg
+	RunQueue           RunQueueStats
+	Uptime             *UptimeStats // nil on samples where uptime is not reported
+	Reboot             *RebootEvent // set on the first sample after the host rebooted
+	Processes          ProcessStats
+	WatchedProcesses   []WatchedProcessStat // processes set with WatchProcess/WatchPID
+	Users              []UserUsageStat      // only when per-user aggregation is enabled
+	KernelActivity     KernelActivityStats
+	TCPRetrans         TCPRetransStats
+	VMStat             VMStatRates
+	NetInterfaces      []NetInterfaceStat // per interface, over the sampleDelta window
+	FileDescriptors    FileDescriptorStats
+	Temperatures       []TemperatureStat   // only when thermal collection is enabled
+	Pressure           *PressureStats      // nil when the kernel has no PSI support
+	Conntrack          *ConntrackStats     // nil when nf_conntrack is not loaded
+	RAIDArrays         []RAIDArrayStat     // md arrays from /proc/mdstat
+	Sockets            *SocketStats        // only when socket collection is enabled
+	EntropyAvail       *int                // bits, only when entropy collection is enabled
+	Cgroups            []CgroupStats       // only for the paths set with SetCgroupPaths
+	GPUs               []GPUStat           // only when GPU collection is enabled
+	IPMISensors        []IPMISensorStat    // latest BMC readings, only when IPMI collection is enabled
+	Systemd            *SystemdStats       // only when systemd collection is enabled
+	SMART              []SMARTStat         // latest smartctl results for the devices set with SetSMARTDevices
+	CommandMetrics     []CommandMetricStat // values of the commands set with AddCommandMetric
+	Buddyinfo          []BuddyZoneStat     // only when buddyinfo collection is enabled
+	OOMKills           []OOMKillEvent      // kills detected since the previous OOM check
+	Labels             map[string]string   // static labels of the monitor, see SetLabels
+	CollectionMode     CollectionMode      // how the files of the sample were read
+	Custom             map[string]any      // values of the registered collectors, by collector name
+	Errors             []GroupError        // metric groups missing from a partial sample, see PartialError
 }
 
 // remoteStatsCollector handles collecting system stats from a remote system via SFTP
 type remoteStatsCollector struct {
-	sftpClient     *sftp.Client
-	sshClient      *ssh.Client
-	sampleDelta    time.Duration
-	ownsSftpClient bool // true if we created the SFTP client and should close it
-	ownsSSHClient  bool // true if we created the SSH client and should close it
+	sftpClient           *sftp.Client
+	sshClient            *ssh.Client
+	transport            transport  // how files are read and commands run, over sftpClient and sshClient by default
+	connMu               sync.Mutex // Protects sshClient against replacement while a keepalive is sent from another goroutine
+	collectMu            sync.Mutex // Serializes collections with the reads of SystemInfo
+	sampleDeltaMu        sync.Mutex // Protects sampleDelta, changed while a collection runs
+	sampleDelta          time.Duration
+	uptimeEvery          int // report uptime on every nth sample
+	thermalEnabled       bool
+	socketStatsEnabled   bool
+	fdWatchPIDs          []int
+	entropyEnabled       bool
+	cgroups              *CgroupCollector
+	gpuEnabled           bool
+	systemdEnabled       bool
+	systemdUnits         []string
+	smartDevices         []string
+	smartInterval        time.Duration
+	smartCache           []SMARTStat
+	smartCollectedAt     time.Time
+	perCoreModesEnabled  bool
+	extraMeminfoFields   []string
+	slabTopCaches        int
+	buddyinfoEnabled     bool
+	processStatesEnabled bool
+	oomEnabled           bool
+	oomInterval          time.Duration
+	oomBaselined         bool
+	oomCheckedAt         time.Time
+	oomKillCount         uint64
+	redial               func() (*ssh.Client, error) // set when the collector owns its connection and can reconnect
+	prevSnapshot         *procSnapshot               // counters of the previous collection, see cycleSnapshots
+	lastUptime           float64
+	watchedNames         []processWatch
+	watchedPIDs          []int
+	perUserEnabled       bool
+	execMode             bool          // files are read by commands over SSH, without an SFTP client
+	streamInterval       time.Duration // period of the streaming agent frames, 0 if disabled
+	stream               *streamAgent
+	streamLast           time.Time        // remote time of the newest frame already used
+	collectionModes      []CollectionMode // fallback chain, see SetCollectionModes
+	modeIndex            int              // index of the current mode in collectionModes, -1 before the first collection
+	agentInterval        time.Duration    // streaming interval of CollectionAgent
+	commandMetrics       []commandMetric  // see AddCommandMetric
+	ipmiEnabled          bool
+	ipmiInterval         time.Duration
+	ipmiCache            []IPMISensorStat
+	ipmiCollectedAt      time.Time
+	handles              map[string]*reusedHandle // open handles of reusedFiles, see openReused
+	handlesMu            sync.Mutex               // Protects handles
+	info                 *SystemInfo              // static values of the host, see cacheSystemInfo
+	infoMu               sync.Mutex               // Protects info, read from other goroutines
+	sampleCount          uint64                   // number of GetSystemStats calls so far
+	ownsSftpClient       bool                     // true if we created the SFTP client and should close it
+	ownsSSHClient        bool                     // true if we created the SSH client and should close it
 }
 
 // NewRemoteStatsCollectorFromSFTP creates a new instance of remoteStatsCollector from an existing SFTP client
 func NewRemoteStatsCollectorFromSFTP(sftpClient *sftp.Client, sampleDelta time.Duration) *remoteStatsCollector {
-	return &remoteStatsCollector{
+	collector := &remoteStatsCollector{
 		sftpClient:     sftpClient,
 		sampleDelta:    sampleDelta,
+		uptimeEvery:    1,
+		smartInterval:  defaultSMARTInterval,
+		ipmiInterval:   defaultIPMIInterval,
+		oomInterval:    defaultOOMCheckInterval,
 		ownsSftpClient: false,
 		ownsSSHClient:  false,
 	}
+	collector.transport = sshTransport{collector}
+	return collector
 }
 
 // NewRemoteStatsCollectorFromSSH creates a new instance of remoteStatsCollector from an SSH connection
@@ -49,28 +151,171 @@ func NewRemoteStatsCollectorFromSSH(sshClient *ssh.Client, sampleDelta time.Dura
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SFTP client: %w", err)
 	}
-	return NewRemoteStatsCollectorFromSFTP(sftpClient, sampleDelta), nil
+	collector := NewRemoteStatsCollectorFromSFTP(sftpClient, sampleDelta)
+	collector.sshClient = sshClient
+	collector.ownsSftpClient = true
+	return collector, nil
 }
 
 // NewRemoteStatsCollectorFromSSHConfig creates a new instance of remoteStatsCollector from SSH configuration
-func NewRemoteStatsCollectorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, sampleDelta time.Duration) (*remoteStatsCollector, error) {
+func NewRemoteStatsCollectorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, sampleDelta time.Duration, opts ...SSHOption) (*remoteStatsCollector, error) {
+	o, err := applySSHOptions(config, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH options: %w", err)
+	}
+	dial := func() (*ssh.Client, error) {
+		return o.dialSSH(serverAddress)
+	}
+	sshClient, err := dial()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH server: %w", err)
+	}
+	return newRemoteStatsCollectorFromClient(sshClient, dial, o, sampleDelta)
+}
+
+// newRemoteStatsCollectorFromDial creates a collector owning the SSH connection made by dial,
+// which is also used to reconnect
+func newRemoteStatsCollectorFromDial(dial func() (*ssh.Client, error), sampleDelta time.Duration) (*remoteStatsCollector, error) {
 	// Connect to SSH server
-	sshClient, err := ssh.Dial("tcp", serverAddress, config)
+	sshClient, err := dial()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to SSH server: %w", err)
 	}
+	return newRemoteStatsCollectorFromClient(sshClient, dial, &sshOptions{}, sampleDelta)
+}
+
+// newRemoteStatsCollectorFromClient creates a collector owning sshClient, made by dial, that reads
+// the files as set by the options. sshClient is closed on failure.
+func newRemoteStatsCollectorFromClient(sshClient *ssh.Client, dial func() (*ssh.Client, error), o *sshOptions, sampleDelta time.Duration) (*remoteStatsCollector, error) {
+	if o.execMode || len(o.collectionModes) > 0 {
+		collector := NewRemoteStatsCollectorFromSSHExec(sshClient, sampleDelta)
+		collector.ownsSSHClient = true
+		collector.redial = dial
+		if len(o.collectionModes) > 0 {
+			if err := collector.SetCollectionModes(o.collectionModes...); err != nil {
+				sshClient.Close()
+				return nil, err
+			}
+		}
+		return collector, nil
+	}
 	collector, err := NewRemoteStatsCollectorFromSSH(sshClient, sampleDelta)
 	if err != nil {
+		sshClient.Close()
 		return nil, fmt.Errorf("failed to create remote stats collector: %w", err)
 	}
 	collector.ownsSSHClient = true
+	collector.redial = dial
 	return collector, nil
 }
 
+// CanReconnect returns whether the collector owns its SSH connection and is able to re-establish it
+func (r *remoteStatsCollector) CanReconnect() bool {
+	return r.redial != nil
+}
+
+// IsConnected checks that the SSH connection still answers requests
+func (r *remoteStatsCollector) IsConnected() bool {
+	_, err := r.Ping(defaultPingTimeout)
+	return err == nil
+}
+
+// Ping sends a keepalive request and returns its round-trip time. A connection that does not
+// answer within timeout (e.g., half-open behind a NAT) is reported as an error instead of blocking.
+func (r *remoteStatsCollector) Ping(timeout time.Duration) (time.Duration, error) {
+	r.connMu.Lock()
+	sshClient, sftpClient := r.sshClient, r.sftpClient
+	r.connMu.Unlock()
+	if sshClient == nil && sftpClient == nil {
+		// Nothing to probe for collectors that do not use a connection (e.g., LocalStatsCollector)
+		return 0, nil
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		if sshClient == nil {
+			// Without the SSH client only the SFTP session can be probed
+			_, err := sftpClient.Getwd()
+			done <- err
+			return
+		}
+		_, _, err := sshClient.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			return 0, fmt.Errorf("keepalive failed: %w", err)
+		}
+		return time.Since(start), nil
+	case <-timer.C:
+		return 0, fmt.Errorf("keepalive timed out after %v", timeout)
+	}
+}
+
+// dropConnection closes the SSH connection so that reads blocked on a dead connection fail
+// and the next collection reconnects. Connections the collector does not own are left open.
+func (r *remoteStatsCollector) dropConnection() {
+	if r.redial == nil {
+		return
+	}
+	r.connMu.Lock()
+	defer r.connMu.Unlock()
+	if r.sshClient != nil {
+		r.sshClient.Close()
+	}
+}
+
+// Reconnect closes the current SSH and SFTP clients and dials the host again
+func (r *remoteStatsCollector) Reconnect() error {
+	if r.redial == nil {
+		return fmt.Errorf("collector does not own its SSH connection and cannot reconnect")
+	}
+	r.closeHandles()
+	if r.sftpClient != nil {
+		r.sftpClient.Close()
+	}
+	if r.sshClient != nil {
+		r.sshClient.Close()
+	}
+
+	sshClient, err := r.redial()
+	if err != nil {
+		return fmt.Errorf("failed to connect to SSH server: %w", err)
+	}
+	r.prevSnapshot = nil
+	if r.execMode || len(r.collectionModes) > 0 {
+		// A fallback chain starts over from its first mode, creating the SFTP client if needed
+		r.connMu.Lock()
+		r.sshClient = sshClient
+		r.sftpClient = nil
+		r.connMu.Unlock()
+		r.modeIndex = -1
+		return nil
+	}
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return fmt.Errorf("failed to create SFTP client: %w", err)
+	}
+	r.connMu.Lock()
+	r.sshClient = sshClient
+	r.sftpClient = sftpClient
+	r.connMu.Unlock()
+	return nil
+}
+
 // Close closes the SFTP and SSH clients if we own them
 func (r *remoteStatsCollector) Close() error {
 	var err error
 
+	r.stopStreamAgent()
+	r.closeHandles()
+
 	if r.ownsSftpClient && r.sftpClient != nil {
 		if closeErr := r.sftpClient.Close(); closeErr != nil {
 			err = closeErr
@@ -86,46 +331,86 @@ func (r *remoteStatsCollector) Close() error {
 	return err
 }
 
-// SetSampleDelta updates the CPU sampling interval
+// SetSampleDelta updates the CPU sampling interval. Rates are computed between consecutive
+// collections, so sampleDelta is their minimum window, waited for only when collections are closer
+// (and on the first collection).
 func (r *remoteStatsCollector) SetSampleDelta(sampleDelta time.Duration) {
+	r.sampleDeltaMu.Lock()
+	defer r.sampleDeltaMu.Unlock()
 	r.sampleDelta = sampleDelta
 }
 
 // GetSampleDelta returns the current CPU sampling interval
 func (r *remoteStatsCollector) GetSampleDelta() time.Duration {
+	r.sampleDeltaMu.Lock()
+	defer r.sampleDeltaMu.Unlock()
 	return r.sampleDelta
 }
 
-func (r *remoteStatsCollector) getMemoryStats() (totalMB float64, usedMB float64, err error) {
-	file, err := r.sftpClient.Open("/proc/meminfo")
+// readRemoteFile reads a whole (small) remote file such as a /proc or /sys entry
+func (r *remoteStatsCollector) readRemoteFile(ctx context.Context, path string) (string, error) {
+	file, err := r.transport.Open(ctx, path)
 	if err != nil {
-		return
+		return "", err
 	}
 	defer file.Close()
 
-	var total, available float64
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Fields(line)
-		if len(fields) < 2 {
-			continue
-		}
-		key := fields[0]
-		val, err2 := strconv.ParseFloat(fields[1], 64)
-		if err2 != nil {
-			continue
-		}
-		switch key {
-		case "MemTotal:":
-			total = val
-		case "MemAvailable:":
-			available = val
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func readSFTPFile(client *sftp.Client, path string) (string, error) {
+	file, err := client.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// runRemoteCommand runs a command on the monitored system (in a new SSH session) and returns its standard output
+func (r *remoteStatsCollector) runRemoteCommand(ctx context.Context, cmd string) (string, error) {
+	return r.transport.Run(ctx, cmd)
+}
+
+// readMeminfo reads /proc/meminfo into a map of field name (e.g., "MemTotal") to its value in kB
+func (r *remoteStatsCollector) readMeminfo(ctx context.Context) (map[string]float64, error) {
+	file, err := r.transport.Open(ctx, "/proc/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	meminfo := make(map[string]float64, 64)
+	err = scanProcLines(file, func(line []byte) error {
+		key, rest := nextField(line)
+		value, _ := nextField(rest)
+		if v, ok := parseUintBytes(value); ok && len(key) > 1 {
+			meminfo[internName(bytes.TrimSuffix(key, []byte(":")))] = float64(v)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if err = scanner.Err(); err != nil {
-		return
+	return meminfo, nil
+}
+
+// getMemoryStats returns the total and used memory, taking the total from info once it is cached
+func getMemoryStats(meminfo map[string]float64, info *SystemInfo) (totalMB float64, usedMB float64, err error) {
+	total := meminfo["MemTotal"]
+	if info != nil {
+		total = info.TotalMemoryMB * 1024
 	}
+	available := meminfo["MemAvailable"]
 	if total == 0 {
 		err = fmt.Errorf("invalid meminfo (MemTotal is zero)")
 		return
@@ -135,95 +420,435 @@ func (r *remoteStatsCollector) getMemoryStats() (totalMB float64, usedMB float64
 	return
 }
 
-func (r *remoteStatsCollector) getCPUStats() (totalUsage float64, perCore []CPUStat, err error) {
-	snapshot := func() (map[string][]float64, error) {
-		file, err := r.sftpClient.Open("/proc/stat")
-		if err != nil {
-			return nil, err
-		}
-		defer file.Close()
+func getSwapStats(meminfo map[string]float64) (totalMB float64, usedMB float64) {
+	total := meminfo["SwapTotal"]
+	free := meminfo["SwapFree"]
+	totalMB = total / 1024
+	usedMB = (total - free) / 1024
+	return
+}
 
-		stats := make(map[string][]float64)
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if !strings.HasPrefix(line, "cpu") {
-				break
-			}
-			fields := strings.Fields(line)
-			core := fields[0]
-			values := make([]float64, 0, len(fields)-1)
-			for _, f := range fields[1:] {
-				v, err := strconv.ParseFloat(f, 64)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse CPU stat: %w", err)
-				}
-				values = append(values, v)
+// procSnapshot holds the cumulative kernel counters read at a single point in time
+type procSnapshot struct {
+	taken     time.Time
+	cpu       map[string][]float64        // "cpu", "cpu0", ... -> /proc/stat jiffies
+	procStat  map[string]uint64           // single-value /proc/stat lines (ctxt, procs_running, ...)
+	disks     map[string][]uint64         // device -> /proc/diskstats counters
+	snmp      map[string]map[string]int64 // "Tcp" -> "RetransSegs" -> value, from /proc/net/snmp
+	netDev    map[string][]uint64         // interface -> /proc/net/dev counters
+	vmstat    map[string]uint64           // /proc/vmstat counters
+	procTicks map[int]uint64              // watched PID -> utime+stime
+}
+
+// cycleSnapshots returns the snapshot kept from the previous collection and a new one, so that the
+// rates cover the time between collections. The new snapshot is waited for until sampleDelta after
+// the previous one; the first collection, or one after the counters were reset (e.g., by a reboot),
+// takes two snapshots sampleDelta apart.
+func (r *remoteStatsCollector) cycleSnapshots(ctx context.Context, watched map[int]string) (stat1, stat2 *procSnapshot, err error) {
+	sampleDelta := r.GetSampleDelta()
+	if prev := r.prevSnapshot; prev != nil {
+		if wait := sampleDelta - time.Since(prev.taken); wait > 0 {
+			if !sleepContext(ctx, wait) {
+				return nil, nil, ctx.Err()
 			}
-			stats[core] = values
+			r.prefetch(ctx, snapshotFiles(watched))
+		}
+		if stat2, err = r.takeSnapshot(ctx, watched); err != nil {
+			return nil, nil, fmt.Errorf("failed to take snapshot: %w", err)
+		}
+		r.prevSnapshot = stat2
+		if snapshotContinues(prev, stat2) {
+			return prev, stat2, nil
 		}
-		return stats, scanner.Err()
+		stat1 = stat2
+	} else if stat1, err = r.takeSnapshot(ctx, watched); err != nil {
+		return nil, nil, fmt.Errorf("failed to take first snapshot: %w", err)
 	}
 
-	stat1, err := snapshot()
+	if !sleepContext(ctx, sampleDelta) {
+		return nil, nil, ctx.Err()
+	}
+	r.prefetch(ctx, snapshotFiles(watched))
+	if stat2, err = r.takeSnapshot(ctx, watched); err != nil {
+		return nil, nil, fmt.Errorf("failed to take second snapshot: %w", err)
+	}
+	r.prevSnapshot = stat2
+	return stat1, stat2, nil
+}
+
+// snapshotContinues reports whether the counters of next follow those of prev, which they do not
+// after a reboot
+func snapshotContinues(prev, next *procSnapshot) bool {
+	total1, total2 := prev.cpu["cpu"], next.cpu["cpu"]
+	if len(total1) == 0 || len(total2) < len(total1) {
+		return false
+	}
+	var sum1, sum2 float64
+	for i := range total1 {
+		sum1 += total1[i]
+		sum2 += total2[i]
+	}
+	return sum2 >= sum1
+}
+
+// takeSnapshot reads every counter source that is reported as a delta
+func (r *remoteStatsCollector) takeSnapshot(ctx context.Context, watched map[int]string) (*procSnapshot, error) {
+	cpu, procStat, err := r.readProcStat(ctx)
 	if err != nil {
-		return
+		return nil, fmt.Errorf("failed to read /proc/stat: %w", err)
 	}
-	time.Sleep(r.sampleDelta)
-	stat2, err := snapshot()
+	disks, err := r.readDiskSnapshot(ctx)
 	if err != nil {
-		return
+		return nil, fmt.Errorf("failed to read /proc/diskstats: %w", err)
 	}
+	snmp, err := r.readSnmp(ctx, "/proc/net/snmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/net/snmp: %w", err)
+	}
+	netDev, err := r.readNetDevSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/net/dev: %w", err)
+	}
+	vmstat, err := r.readVmstat(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/vmstat: %w", err)
+	}
+	return &procSnapshot{
+		taken:     time.Now(),
+		cpu:       cpu,
+		procStat:  procStat,
+		disks:     disks,
+		snmp:      snmp,
+		netDev:    netDev,
+		vmstat:    vmstat,
+		procTicks: r.readProcessTicksAll(ctx, watched),
+	}, nil
+}
 
-	for core, values1 := range stat1 {
-		values2, ok := stat2[core]
-		if !ok {
-			continue
+func (r *remoteStatsCollector) readProcStat(ctx context.Context) (map[string][]float64, map[string]uint64, error) {
+	file, err := r.transport.Open(ctx, "/proc/stat")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	stats := make(map[string][]float64)
+	counters := make(map[string]uint64, 8)
+	// The values of every core share one backing array
+	jiffies := make([]float64, 0, 256)
+	err = scanProcLines(file, func(line []byte) error {
+		name, rest := nextField(line)
+		first, _ := nextField(rest)
+		if first == nil {
+			return nil
 		}
-		var total1, total2, idle1, idle2 float64
-		for i := range values1 {
-			total1 += values1[i]
-			total2 += values2[i]
+		if !bytes.HasPrefix(name, []byte("cpu")) {
+			// Only the first value is kept ("intr" and "softirq" start with their total)
+			if v, ok := parseUintBytes(first); ok {
+				counters[internName(name)] = v
+			}
+			return nil
 		}
-		if len(values1) > 3 {
-			idle1 = values1[3]
-			idle2 = values2[3]
-		} else {
+		start := len(jiffies)
+		for field, rest := nextField(rest); field != nil; field, rest = nextField(rest) {
+			v, ok := parseUintBytes(field)
+			if !ok {
+				return fmt.Errorf("failed to parse CPU stat: invalid value %q", field)
+			}
+			jiffies = append(jiffies, float64(v))
+		}
+		stats[internName(name)] = jiffies[start:len(jiffies):len(jiffies)]
+		return nil
+	})
+	return stats, counters, err
+}
+
+func computeCPUStats(stat1, stat2 *procSnapshot, perCoreModes bool) (totalUsage float64, totalModes CPUModeBreakdown, perCore []CPUStat) {
+	for core, values1 := range stat1.cpu {
+		values2, ok := stat2.cpu[core]
+		if !ok || len(values1) <= 3 || len(values2) < len(values1) {
 			continue
 		}
-		deltaIdle := idle2 - idle1
-		deltaTotal := total2 - total1
-		if deltaTotal == 0 {
+		modes, ok := computeCPUModes(values1, values2)
+		if !ok {
 			continue
 		}
-		usage := (1 - deltaIdle/deltaTotal) * 100.0
+		// iowait is counted as busy time, like the original idle-only formula
+		usage := 100.0 - modes.Idle
 
 		if core == "cpu" {
 			totalUsage = usage
+			totalModes = modes
 		} else {
-			perCore = append(perCore, CPUStat{Core: core, UsagePct: usage})
+			stat := CPUStat{Core: core, UsagePct: usage}
+			if perCoreModes {
+				stat.Modes = &modes
+			}
+			perCore = append(perCore, stat)
 		}
 	}
 
 	return
 }
 
-func (r *remoteStatsCollector) GetSystemStats() (*SystemStats, error) {
-	totalMem, usedMem, err := r.getMemoryStats()
+func (r *remoteStatsCollector) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	r.collectMu.Lock()
+	defer r.collectMu.Unlock()
+	if len(r.collectionModes) > 0 {
+		return r.collectWithFallback(ctx)
+	}
+	return r.collectSystemStats(ctx)
+}
+
+func (r *remoteStatsCollector) collectSystemStats(ctx context.Context) (*SystemStats, error) {
+	watched, err := r.resolveWatchedProcesses(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get memory stats: %w", err)
+		return nil, fmt.Errorf("failed to list watched processes: %w", err)
+	}
+	// With a streaming agent both snapshots come from its frames, which also serve the other files
+	var stat1, stat2 *procSnapshot
+	if r.streamInterval > 0 {
+		inner := r.transport
+		frames := &batchingTransport{transport: inner, files: make(map[string]batchedFile)}
+		r.transport = frames
+		defer func() { r.transport = inner }()
+		r.prevSnapshot = nil // frames carry the host clock, see cycleSnapshots for the other path
+		if stat1, stat2, err = r.streamSnapshots(ctx, watched, frames); err != nil {
+			return nil, err
+		}
+	} else {
+		r.prefetch(ctx, append(r.sampleFiles(watched), snapshotFiles(watched)...))
+		defer r.resetBatch()
+	}
+
+	// The metric groups are collected concurrently, each setting its own fields of stats, so that
+	// their round trips overlap. A failed group leaves its fields zero and is listed in the errors
+	// of the sample rather than failing it.
+	stats := &SystemStats{CollectionMode: r.collectionMode()}
+	var g errgroup.Group
+	g.SetLimit(maxConcurrentGroups)
+	var errsMu sync.Mutex
+	var groupErrs []GroupError
+	groups := 0
+	group := func(name string, collect func() error) {
+		groups++
+		g.Go(func() error {
+			if err := collect(); err != nil {
+				errsMu.Lock()
+				groupErrs = append(groupErrs, GroupError{Group: name, Err: err})
+				errsMu.Unlock()
+			}
+			return nil
+		})
 	}
 
-	totalCPU, coreStats, err := r.getCPUStats()
+	group("memory", func() error {
+		return r.collectMemory(ctx, stats)
+	})
+	group("CPU stats", func() error {
+		if stat1 == nil {
+			var err error
+			if stat1, stat2, err = r.cycleSnapshots(ctx, watched); err != nil {
+				return err
+			}
+		}
+		stats.TotalCPUPercentage, stats.CPUModes, stats.CPUStats = computeCPUStats(stat1, stat2, r.perCoreModesEnabled)
+		stats.DiskStats = computeDiskIOStats(stat1, stat2)
+		stats.KernelActivity = computeKernelActivity(stat1, stat2)
+		stats.TCPRetrans = computeTCPRetrans(stat1, stat2)
+		stats.VMStat = computeVMStatRates(stat1, stat2)
+		stats.WatchedProcesses = r.getWatchedProcessStats(ctx, watched, stat1, stat2)
+		stats.NetInterfaces = computeNetInterfaceStats(stat1, stat2)
+		var cpus int
+		if info := r.cachedSystemInfo(); info != nil {
+			cpus = info.CPUCount
+		}
+		stats.RunQueue = computeRunQueue(stat2, cpus)
+		return nil
+	})
+	group("disk usage", func() (err error) {
+		stats.DiskUsage, err = r.getDiskUsage(ctx)
+		return
+	})
+	group("load average", func() (err error) {
+		stats.LoadAvg, err = r.getLoadAvg(ctx)
+		return
+	})
+	group("process stats", func() (err error) {
+		stats.Processes, err = r.getProcessStats(ctx)
+		return
+	})
+	group("file descriptor stats", func() (err error) {
+		stats.FileDescriptors, err = r.getFileDescriptorStats(ctx)
+		return
+	})
+	group("pressure stats", func() (err error) {
+		stats.Pressure, err = r.getPressureStats(ctx)
+		return
+	})
+	group("conntrack stats", func() (err error) {
+		stats.Conntrack, err = r.getConntrackStats(ctx)
+		return
+	})
+	group("RAID stats", func() (err error) {
+		stats.RAIDArrays, err = r.getRAIDStats(ctx)
+		return
+	})
+	if r.socketStatsEnabled {
+		group("socket stats", func() (err error) {
+			stats.Sockets, err = r.getSocketStats(ctx)
+			return
+		})
+	}
+	if r.entropyEnabled {
+		group("entropy", func() error {
+			e, err := r.getEntropyAvail(ctx)
+			if err != nil {
+				return err
+			}
+			stats.EntropyAvail = &e
+			return nil
+		})
+	}
+	if r.cgroups != nil {
+		group("cgroup stats", func() (err error) {
+			stats.Cgroups, err = r.cgroups.Collect(ctx)
+			return
+		})
+	}
+	if r.gpuEnabled {
+		group("GPU stats", func() (err error) {
+			stats.GPUs, err = r.getGPUStats(ctx)
+			return
+		})
+	}
+	if r.ipmiEnabled {
+		group("IPMI sensors", func() (err error) {
+			stats.IPMISensors, err = r.getIPMIStats(ctx)
+			return
+		})
+	}
+	if r.systemdEnabled {
+		group("systemd stats", func() (err error) {
+			stats.Systemd, err = r.getSystemdStats(ctx)
+			return
+		})
+	}
+	if r.perUserEnabled {
+		group("per-user usage", func() (err error) {
+			stats.Users, err = r.getUserUsage(ctx)
+			return
+		})
+	}
+	if r.oomEnabled {
+		group("OOM kills", func() (err error) {
+			stats.OOMKills, err = r.checkOOMKills(ctx)
+			return
+		})
+	}
+	if r.buddyinfoEnabled {
+		group("buddyinfo", func() (err error) {
+			stats.Buddyinfo, err = r.getBuddyinfo(ctx)
+			return
+		})
+	}
+	if len(r.commandMetrics) > 0 {
+		g.Go(func() error {
+			stats.CommandMetrics = r.getCommandMetrics(ctx)
+			return nil
+		})
+	}
+	if len(r.smartDevices) > 0 {
+		g.Go(func() error {
+			stats.SMART = r.getSMARTStats(ctx)
+			return nil
+		})
+	}
+	if r.thermalEnabled {
+		g.Go(func() error {
+			stats.Temperatures = r.getTemperatures(ctx)
+			return nil
+		})
+	}
+	var info *SystemInfo
+	if r.cachedSystemInfo() == nil {
+		g.Go(func() error {
+			// Not worth failing the sample over, it is read again on the next collection
+			info, _ = r.readSystemInfo(ctx)
+			return nil
+		})
+	}
+	// Uptime is read on every sample for reboot detection, even when it is not reported
+	group("uptime", func() error {
+		currentUptime, err := r.getUptime(ctx)
+		if err != nil {
+			return err
+		}
+		stats.Reboot = r.checkReboot(currentUptime)
+		if r.sampleCount%uint64(r.uptimeEvery) == 0 {
+			stats.Uptime = &currentUptime
+		}
+		return nil
+	})
+	g.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(groupErrs, func(i, j int) bool { return groupErrs[i].Group < groupErrs[j].Group })
+	if len(groupErrs) == groups {
+		// Nothing to report, e.g., the connection is gone
+		return nil, errors.Join((&PartialError{Errors: groupErrs}).Unwrap()...)
+	}
+	r.sampleCount++
+
+	// Counts from the snapshot and the load average
+	stats.Processes.Threads = stats.LoadAvg.TotalProcs
+	if stat2 != nil {
+		stats.Processes.Running = int(stat2.procStat["procs_running"])
+		stats.Processes.Blocked = int(stat2.procStat["procs_blocked"])
+	}
+	r.cacheSystemInfo(info, stats)
+	if len(groupErrs) > 0 {
+		stats.Errors = groupErrs
+		return stats, &PartialError{Errors: groupErrs}
+	}
+	return stats, nil
+}
+
+// maxConcurrentGroups bounds the metric groups collected at once, below the sessions an SSH server
+// accepts on a connection (10 by default with OpenSSH) as commands each open one
+const maxConcurrentGroups = 8
+
+// collectMemory sets the fields of stats read from /proc/meminfo
+func (r *remoteStatsCollector) collectMemory(ctx context.Context, stats *SystemStats) error {
+	meminfo, err := r.readMeminfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read meminfo: %w", err)
+	}
+	totalMem, usedMem, err := getMemoryStats(meminfo, r.cachedSystemInfo())
+	if err != nil {
+		return fmt.Errorf("failed to get memory stats: %w", err)
+	}
+	slab, err := r.getSlabStats(ctx, meminfo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get CPU stats: %w", err)
+		return fmt.Errorf("failed to get slab stats: %w", err)
+	}
+	totalSwap, usedSwap := getSwapStats(meminfo)
+	var swapPercent float64
+	if totalSwap > 0 {
+		swapPercent = (usedSwap / totalSwap) * 100.0
 	}
 
-	return &SystemStats{
-		TotalMemoryMB:      totalMem,
-		UsedMemoryMB:       usedMem,
-		UsedMemoryPercent:  (usedMem / totalMem) * 100.0,
-		TotalCPUPercentage: totalCPU,
-		CPUStats:           coreStats,
-	}, nil
+	stats.TotalMemoryMB = totalMem
+	stats.UsedMemoryMB = usedMem
+	stats.UsedMemoryPercent = (usedMem / totalMem) * 100.0
+	stats.SwapTotalMB = totalSwap
+	stats.SwapUsedMB = usedSwap
+	stats.SwapUsedPercent = swapPercent
+	stats.DirtyMB = meminfo["Dirty"] / 1024
+	stats.WritebackMB = meminfo["Writeback"] / 1024
+	stats.HugePages = getHugePagesStats(meminfo)
+	stats.Slab = slab
+	stats.MeminfoExtra = selectMeminfoFields(meminfo, r.extraMeminfoFields)
+	return nil
 }
@@ -0,0 +1,159 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the timestamp inserted in the names of rotated files, e.g., "stats-2024-06-01T15-04-05.000.log"
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is an io.WriteCloser appending to a file that is rotated once it reaches a maximum size.
+// Rotated files are renamed with a timestamp and removed once there are more than maxBackups of them
+// or they are older than maxAge.
+type RotatingFile struct {
+	filename   string
+	maxSize    int64 // bytes, 0 for no size limit
+	maxAge     time.Duration
+	maxBackups int
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+}
+
+var _ io.WriteCloser = (*RotatingFile)(nil)
+
+// NewRotatingFile opens (or creates) filename for appending. maxSizeMB is the size at which the file is
+// rotated; maxAge and maxBackups limit the rotated files kept, 0 meaning no limit.
+func NewRotatingFile(filename string, maxSizeMB int, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		filename:   filename,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file over the maximum size
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it with a timestamp and starts a new one
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		r.file = nil
+	}
+	ext := filepath.Ext(r.filename)
+	backup := strings.TrimSuffix(r.filename, ext) + "-" + time.Now().Format(rotatedTimeFormat) + ext
+	if err := os.Rename(r.filename, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.removeOldBackups()
+}
+
+// removeOldBackups deletes the rotated files beyond maxBackups or older than maxAge
+func (r *RotatingFile) removeOldBackups() error {
+	if r.maxBackups <= 0 && r.maxAge <= 0 {
+		return nil
+	}
+	dir := filepath.Dir(r.filename)
+	ext := filepath.Ext(r.filename)
+	prefix := strings.TrimSuffix(filepath.Base(r.filename), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list log directory: %w", err)
+	}
+	type backup struct {
+		path  string
+		taken time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		taken, err := time.ParseInLocation(rotatedTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+		if err != nil {
+			continue // not one of ours
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), taken: taken})
+	}
+	// Newest first, so the ones to keep come first
+	sort.Slice(backups, func(i, j int) bool { return backups[i].taken.After(backups[j].taken) })
+
+	for i, b := range backups {
+		tooMany := r.maxBackups > 0 && i >= r.maxBackups
+		tooOld := r.maxAge > 0 && time.Since(b.taken) > r.maxAge
+		if tooMany || tooOld {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old log file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
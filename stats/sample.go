@@ -0,0 +1,39 @@
+package stats
+
+import "time"
+
+// Sample is a single collection cycle as handed to sample handlers and sinks
+type Sample struct {
+	Host       string // the monitored host, see SetHost
+	Timestamp  time.Time
+	Stats      *SystemStats
+	Labels     map[string]string // static labels of the monitor (e.g., role=db), see SetLabels
+	StaleAfter time.Duration     // age past which the sample is flagged as stale when written, 0 for never (see SetStaleAfter)
+}
+
+// SampleToJSON returns the JSON form of a sample: the SystemStatsToJSON fields plus "host" and an RFC 3339 "timestamp".
+// A stale sample (e.g., one a sink kept queued until it reconnected) also has "stale" and its "age_ms".
+func SampleToJSON(sample *Sample) map[string]any {
+	data := sampleJSON(sample)
+	markStale(data, sample)
+	return data
+}
+
+// sampleJSON is SampleToJSON without the staleness, for samples served as history
+func sampleJSON(sample *Sample) map[string]any {
+	data := SystemStatsToJSON(sample.Stats)
+	data["host"] = sample.Host
+	data["timestamp"] = sample.Timestamp.Format(time.RFC3339Nano)
+	return data
+}
+
+// markStale adds "stale" and "age_ms" to data when sample is older than its StaleAfter
+func markStale(data map[string]any, sample *Sample) {
+	if sample.StaleAfter <= 0 {
+		return
+	}
+	if age := time.Since(sample.Timestamp); age > sample.StaleAfter {
+		data["stale"] = true
+		data["age_ms"] = age.Milliseconds()
+	}
+}
@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// jitterDelay returns a random delay in [0, max), 0 if max is not positive
+func jitterDelay(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
+
+// sleepContext waits for d, returning false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// SetPhase delays the first collection of StartSync by offset, which shifts every following one by
+// the same amount, and each collection by a further random delay of up to jitter. Giving the monitors
+// of a fleet different offsets keeps them from reading and logging at the same instant every interval.
+// Monitors added to a MonitorPool follow the pool's schedule instead (see MonitorPool.SetStagger).
+func (m *RemoteStatsMonitor) SetPhase(offset, jitter time.Duration) {
+	m.phaseOffset = offset
+	m.phaseJitter = jitter
+}
+
+// SetOverrunPolicy chooses what happens to the ticks missed while a collection runs past the interval:
+// OverrunCoalesce, the default, collects again right after it, OverrunSkip waits for the next tick.
+// Either way the collections never overlap, and every slow one is counted in MonitorHealth.Overruns.
+func (m *RemoteStatsMonitor) SetOverrunPolicy(policy OverrunPolicy) {
+	m.overrunPolicy = policy
+}
+
+// GetOverrunPolicy returns what happens to the ticks missed by a slow collection
+func (m *RemoteStatsMonitor) GetOverrunPolicy() OverrunPolicy {
+	if m.overrunPolicy == "" {
+		return OverrunCoalesce
+	}
+	return m.overrunPolicy
+}
+
+// GetPhase returns the offset and jitter of the collection schedule
+func (m *RemoteStatsMonitor) GetPhase() (offset, jitter time.Duration) {
+	return m.phaseOffset, m.phaseJitter
+}
+
+// SetStagger spreads the hosts of a cycle evenly over spread (e.g., the interval): of n hosts sorted
+// by name, the kth one is collected spread*k/n after the start of the cycle, plus a random delay of
+// up to jitter. The cycle ends when all hosts are done, so spread + jitter should leave time for
+// a collection within the interval. Both 0, the default, collect all hosts at once.
+func (p *MonitorPool) SetStagger(spread, jitter time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.staggerSpread = spread
+	p.staggerJitter = jitter
+}
+
+// GetStagger returns the spread and jitter of the hosts within a cycle
+func (p *MonitorPool) GetStagger() (spread, jitter time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.staggerSpread, p.staggerJitter
+}
+
+// OverrunPolicy chooses what happens to the ticks missed while a collection cycle runs past the interval
+type OverrunPolicy string
+
+const (
+	OverrunCoalesce OverrunPolicy = "coalesce" // collect once right after the slow cycle for all the ticks it missed, the default
+	OverrunSkip     OverrunPolicy = "skip"     // drop the missed ticks and wait for the next tick on the schedule
+)
+
+// cycleOverran reports whether the cycle started at start ran past interval. Under OverrunSkip it
+// also drops the tick that came due meanwhile, so the next cycle starts on the schedule.
+func cycleOverran(ticker *time.Ticker, start time.Time, interval time.Duration, policy OverrunPolicy) bool {
+	if time.Since(start) <= interval {
+		return false
+	}
+	if policy == OverrunSkip {
+		select {
+		case <-ticker.C:
+		default:
+		}
+	}
+	return true
+}
+
+// SetOverrunPolicy chooses what happens to the ticks missed while a cycle (the collection of every
+// due host) runs past the interval, like RemoteStatsMonitor.SetOverrunPolicy. Slow cycles are
+// counted in CycleOverruns.
+func (p *MonitorPool) SetOverrunPolicy(policy OverrunPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.overrunPolicy = policy
+}
+
+// GetOverrunPolicy returns what happens to the ticks missed by a slow cycle
+func (p *MonitorPool) GetOverrunPolicy() OverrunPolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.overrunPolicy == "" {
+		return OverrunCoalesce
+	}
+	return p.overrunPolicy
+}
+
+// CycleOverruns returns how many cycles ran past the interval
+func (p *MonitorPool) CycleOverruns() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.overruns
+}
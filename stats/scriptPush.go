@@ -0,0 +1,149 @@
+package stats
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// scriptCollector runs a user-provided shell script on the host, uploaded once over SFTP
+type scriptCollector struct {
+	r          *remoteStatsCollector
+	script     string
+	remotePath string // relative to the login directory
+
+	mu       sync.Mutex
+	uploaded bool
+}
+
+func newScriptCollector(r *remoteStatsCollector, name, script string) *scriptCollector {
+	// The content hash keeps a changed script from running a stale upload
+	sum := sha256.Sum256([]byte(script))
+	return &scriptCollector{
+		r:          r,
+		script:     script,
+		remotePath: fmt.Sprintf(".sysstats-%s-%x.sh", sanitizeLabelName(name), sum[:4]),
+	}
+}
+
+// upload writes the script to the host, readable only by the user
+func (s *scriptCollector) upload() error {
+	file, err := s.r.sftpClient.OpenFile(s.remotePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed to upload script: %w", err)
+	}
+	if _, err := io.WriteString(file, s.script); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to upload script: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to upload script: %w", err)
+	}
+	if err := s.r.sftpClient.Chmod(s.remotePath, 0o700); err != nil {
+		return fmt.Errorf("failed to upload script: %w", err)
+	}
+	s.uploaded = true
+	return nil
+}
+
+// run runs the uploaded script, uploading it again if it disappeared (e.g., the home directory was
+// cleaned up). Without an SFTP client the script is passed inline to sh instead.
+func (s *scriptCollector) run(ctx context.Context) (string, error) {
+	if s.r.sftpClient == nil {
+		return s.r.runRemoteCommand(ctx, "sh -c "+shellQuote(s.script))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.uploaded {
+		if err := s.upload(); err != nil {
+			return "", err
+		}
+	}
+	output, err := s.r.runRemoteCommand(ctx, "sh "+shellQuote(s.remotePath))
+	if err != nil {
+		if _, statErr := s.r.sftpClient.Stat(s.remotePath); errors.Is(statErr, os.ErrNotExist) {
+			if err := s.upload(); err != nil {
+				return "", err
+			}
+			return s.r.runRemoteCommand(ctx, "sh "+shellQuote(s.remotePath))
+		}
+	}
+	return output, err
+}
+
+func (s *scriptCollector) Collect(ctx context.Context) (map[string]any, error) {
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := s.run(ctx)
+		done <- result{output, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return parseScriptOutput(res.output)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("script did not finish: %w", ctx.Err())
+	}
+}
+
+// parseScriptOutput parses a JSON object, or "key=value" lines with numbers as float64 and any other
+// value as a string. Blank lines and lines starting with "#" are ignored.
+func parseScriptOutput(output string) (map[string]any, error) {
+	output = strings.TrimSpace(output)
+	if strings.HasPrefix(output, "{") {
+		var values map[string]any
+		if err := json.Unmarshal([]byte(output), &values); err != nil {
+			return nil, fmt.Errorf("failed to parse script output: %w", err)
+		}
+		return values, nil
+	}
+
+	values := make(map[string]any)
+	for i, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("failed to parse script output: line %d is not key=value", i+1)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			values[key] = number
+		} else {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// AddScript uploads script to the login directory of the host over SFTP (as ".sysstats-<name>-<hash>.sh")
+// and runs it with sh on every sample. Its output, a JSON object or "key=value" lines, is added to
+// SystemStats.Custom under name like the values of a registered collector (see RegisterCollector).
+// Collectors without an SFTP client pass the script inline to sh instead of uploading it.
+func (m *RemoteStatsMonitor) AddScript(name, script string) error {
+	return m.RegisterCollector(name, newScriptCollector(m.collector, name, script))
+}
+
+// AddScriptFile is AddScript with the script read from the local file path
+func (m *RemoteStatsMonitor) AddScriptFile(name, path string) error {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+	return m.AddScript(name, string(script))
+}
@@ -0,0 +1,160 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Sink is a destination for collected samples. Every exporter in this package
+// (PrometheusExporter, GraphiteSink, StatsDSink, ...) implements it.
+type Sink interface {
+	WriteSample(sample *Sample) error
+	Close() error
+}
+
+// EventSink is implemented by sinks that also record monitor events such as reboots and reconnects
+type EventSink interface {
+	WriteEvent(event string, fields map[string]any) error
+}
+
+var (
+	_ Sink = (*WriterSink)(nil)
+	_ Sink = (*CSVWriter)(nil)
+	_ Sink = (*PrometheusExporter)(nil)
+	_ Sink = (*GraphiteSink)(nil)
+	_ Sink = (*StatsDSink)(nil)
+	_ Sink = (*SQLiteSink)(nil)
+	_ Sink = (*ElasticsearchSink)(nil)
+	_ Sink = (*WebhookSink)(nil)
+	_ Sink = (*SyslogSink)(nil)
+	_ Sink = (*APIServer)(nil)
+	_ Sink = (*TerminalDashboard)(nil)
+)
+
+// WriterSink writes every sample as a formatted line to an io.Writer
+type WriterSink struct {
+	mu         sync.Mutex
+	w          io.Writer
+	format     func(*SystemStats) ([]byte, error)
+	timestamps timestampFormatter // format of the event timestamps
+}
+
+// NewWriterSink creates a sink writing lines formatted by format (e.g., NewCSVLogLine(0)) to w.
+// A nil format writes the default JSON lines with an added "host" field, so the output of several
+// hosts can share a file. If w is an io.Closer, Close closes it.
+func NewWriterSink(w io.Writer, format func(*SystemStats) ([]byte, error)) *WriterSink {
+	return &WriterSink{w: w, format: format}
+}
+
+// SetEventTimestampFormat sets the timestamp format of the event records, in location
+// (time.UTC, or nil for local time). Use NewJSONLogLine for the format of the samples.
+func (s *WriterSink) SetEventTimestampFormat(format TimestampFormat, location *time.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timestamps = timestampFormatter{format: format, location: location}
+}
+
+// WriteSample formats the sample and writes it followed by a newline
+func (s *WriterSink) WriteSample(sample *Sample) error {
+	var line []byte
+	var err error
+	if s.format != nil {
+		line, err = s.format(sample.Stats)
+	} else {
+		data := SystemStatsToJSON(sample.Stats)
+		data["timestamp"] = defaultTimestampFormatter.value(sample.Timestamp)
+		data["host"] = sample.Host
+		markStale(data, sample)
+		line, err = json.Marshal(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to format log line: %w", err)
+	}
+	return s.writeLine(line)
+}
+
+// WriteEvent writes an event as a JSON line
+func (s *WriterSink) WriteEvent(event string, fields map[string]any) error {
+	s.mu.Lock()
+	timestamps := s.timestamps
+	s.mu.Unlock()
+	line, err := eventJSON(event, fields, timestamps)
+	if err != nil {
+		return err
+	}
+	return s.writeLine(line)
+}
+
+func (s *WriterSink) writeLine(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write log line: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it is an io.Closer
+func (s *WriterSink) Close() error {
+	if closer, ok := s.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// eventJSON renders an event record (e.g., a reboot or reconnect)
+func eventJSON(event string, fields map[string]any, timestamps timestampFormatter) ([]byte, error) {
+	data := map[string]any{
+		"event":     event,
+		"timestamp": timestamps.value(time.Now()),
+	}
+	for k, v := range fields {
+		data[k] = v
+	}
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s event: %w", event, err)
+	}
+	return bytes, nil
+}
+
+// loggerSink writes samples to the monitor's *log.Logger using its logLineFunc.
+// It is the output used when no Sink has been set, for compatibility with the logger constructors.
+type loggerSink struct {
+	m *RemoteStatsMonitor
+}
+
+func (s loggerSink) WriteSample(sample *Sample) error {
+	if s.m.logger == nil {
+		return nil
+	}
+	// Use the configured logLine function to format the stats
+	logData, err := s.m.logLineFunc(sample.Stats)
+	if err != nil {
+		return fmt.Errorf("failed to format log line: %w", err)
+	}
+
+	// Log the formatted data
+	s.m.logger.Printf("%s", string(logData))
+	return nil
+}
+
+func (s loggerSink) WriteEvent(event string, fields map[string]any) error {
+	if s.m.logger == nil {
+		return nil
+	}
+	line, err := eventJSON(event, fields, s.m.timestamps)
+	if err != nil {
+		s.m.logger.Printf("%v", err)
+		return err
+	}
+	s.m.logger.Printf("%s", string(line))
+	return nil
+}
+
+func (s loggerSink) Close() error {
+	return nil
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SlabCache holds the memory used by a single kernel slab cache
+type SlabCache struct {
+	Name       string
+	ActiveObjs uint64
+	NumObjs    uint64
+	ObjSize    uint64  // bytes
+	SizeMB     float64 // NumObjs * ObjSize
+}
+
+// SlabStats holds kernel slab allocator memory usage
+type SlabStats struct {
+	ReclaimableMB   float64     // SReclaimable from /proc/meminfo
+	UnreclaimableMB float64     // SUnreclaim from /proc/meminfo
+	TopCaches       []SlabCache // largest caches from /proc/slabinfo, only when enabled
+}
+
+// SetSlabTopCaches sets how many of the largest /proc/slabinfo caches are reported (0 disables).
+// Reading /proc/slabinfo usually requires root.
+func (r *remoteStatsCollector) SetSlabTopCaches(n int) {
+	r.slabTopCaches = max(n, 0)
+}
+
+// GetSlabTopCaches returns how many of the largest slab caches are reported
+func (r *remoteStatsCollector) GetSlabTopCaches() int {
+	return r.slabTopCaches
+}
+
+func (r *remoteStatsCollector) getSlabStats(ctx context.Context, meminfo map[string]float64) (SlabStats, error) {
+	stats := SlabStats{
+		ReclaimableMB:   meminfo["SReclaimable"] / 1024,
+		UnreclaimableMB: meminfo["SUnreclaim"] / 1024,
+	}
+	if r.slabTopCaches == 0 {
+		return stats, nil
+	}
+
+	caches, err := r.readSlabinfo(ctx)
+	if err != nil {
+		return SlabStats{}, err
+	}
+	sort.Slice(caches, func(i, j int) bool {
+		return caches[i].SizeMB > caches[j].SizeMB
+	})
+	if len(caches) > r.slabTopCaches {
+		caches = caches[:r.slabTopCaches]
+	}
+	stats.TopCaches = caches
+	return stats, nil
+}
+
+// readSlabinfo parses lines like
+// "kmalloc-64  12345 12800 64 64 1 : tunables 0 0 0 : slabdata 200 200 0"
+func (r *remoteStatsCollector) readSlabinfo(ctx context.Context) ([]SlabCache, error) {
+	file, err := r.transport.Open(ctx, "/proc/slabinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var caches []SlabCache
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "slabinfo") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		active, err1 := strconv.ParseUint(fields[1], 10, 64)
+		num, err2 := strconv.ParseUint(fields[2], 10, 64)
+		size, err3 := strconv.ParseUint(fields[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		caches = append(caches, SlabCache{
+			Name:       fields[0],
+			ActiveObjs: active,
+			NumObjs:    num,
+			ObjSize:    size,
+			SizeMB:     float64(num*size) / (1024 * 1024),
+		})
+	}
+	return caches, scanner.Err()
+}
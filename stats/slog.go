@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"sort"
+)
+
+// SampleToSlogAttrs returns a sample as structured slog attributes: "host", "sample_time" and the
+// SystemStatsToJSON fields, with nested maps (e.g., per_core_cpu_percentages) as attribute groups,
+// and "stale" and "age_ms" for a stale sample like SampleToJSON
+func SampleToSlogAttrs(sample *Sample) []slog.Attr {
+	data := SystemStatsToJSON(sample.Stats)
+	markStale(data, sample)
+	attrs := make([]slog.Attr, 0, len(data)+2)
+	attrs = append(attrs, slog.String("host", sample.Host), slog.Time("sample_time", sample.Timestamp))
+	return append(attrs, mapToSlogAttrs(data)...)
+}
+
+// mapToSlogAttrs converts a JSON-style map to attributes in key order, so output is deterministic
+func mapToSlogAttrs(data map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, valueToSlogAttr(k, data[k]))
+	}
+	return attrs
+}
+
+// valueToSlogAttr turns string keyed maps into groups and leaves everything else to slog.Any
+func valueToSlogAttr(key string, value any) slog.Attr {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return slog.Any(key, value)
+	}
+	nested := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		nested[iter.Key().String()] = iter.Value().Interface()
+	}
+	return slog.Attr{Key: key, Value: slog.GroupValue(mapToSlogAttrs(nested)...)}
+}
+
+// logSample writes a sample to the slog logger, if one is set
+func (m *RemoteStatsMonitor) logSample(sample *Sample) {
+	if m.slogger == nil {
+		return
+	}
+	m.slogger.LogAttrs(context.Background(), slog.LevelInfo, "system stats", SampleToSlogAttrs(sample)...)
+}
@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSMARTInterval is how often smartctl is run when no interval is set
+const defaultSMARTInterval = 10 * time.Minute
+
+// SMARTStat holds the health attributes of a single disk from smartctl -A
+type SMARTStat struct {
+	Device             string // e.g., "/dev/sda"
+	ReallocatedSectors int64  // attribute 5, or NVMe media errors
+	TemperatureC       float64
+	WearPercentUsed    float64   // estimated endurance used (0 when not reported)
+	CollectedAt        time.Time // when smartctl last ran for this device
+	Error              string    // set when smartctl could not be run or parsed
+}
+
+// SetSMARTDevices sets the devices checked with smartctl (nil disables SMART collection)
+func (r *remoteStatsCollector) SetSMARTDevices(devices []string) {
+	r.smartDevices = append([]string(nil), devices...)
+	r.smartCache = nil
+}
+
+// GetSMARTDevices returns the devices checked with smartctl
+func (r *remoteStatsCollector) GetSMARTDevices() []string {
+	return append([]string(nil), r.smartDevices...)
+}
+
+// SetSMARTInterval sets how often smartctl is run; samples in between reuse the last result
+func (r *remoteStatsCollector) SetSMARTInterval(interval time.Duration) {
+	r.smartInterval = interval
+}
+
+// GetSMARTInterval returns how often smartctl is run
+func (r *remoteStatsCollector) GetSMARTInterval() time.Duration {
+	return r.smartInterval
+}
+
+// getSMARTStats returns the cached SMART attributes, refreshing them once the interval has elapsed
+func (r *remoteStatsCollector) getSMARTStats(ctx context.Context) []SMARTStat {
+	interval := r.smartInterval
+	if interval <= 0 {
+		interval = defaultSMARTInterval
+	}
+	if r.smartCache != nil && time.Since(r.smartCollectedAt) < interval {
+		return r.smartCache
+	}
+
+	now := time.Now()
+	results := make([]SMARTStat, 0, len(r.smartDevices))
+	for _, device := range r.smartDevices {
+		stat := SMARTStat{Device: device, CollectedAt: now}
+		// smartctl uses its exit status as a bit mask of disk conditions, so it is ignored
+		output, err := r.runRemoteCommand(ctx, "smartctl -A "+shellQuote(device)+"; true")
+		if err == nil {
+			err = parseSmartctl(output, &stat)
+		}
+		if err != nil {
+			stat.Error = err.Error()
+		}
+		results = append(results, stat)
+	}
+	r.smartCache = results
+	r.smartCollectedAt = now
+	return results
+}
+
+// parseSmartctl extracts the attributes of interest from ATA attribute tables and NVMe health logs
+func parseSmartctl(output string, stat *SMARTStat) error {
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		// NVMe: "Temperature:                        35 Celsius"
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields := strings.Fields(value)
+			if len(fields) > 0 {
+				number := strings.TrimSuffix(strings.ReplaceAll(fields[0], ",", ""), "%")
+				v, err := strconv.ParseFloat(number, 64)
+				if err == nil {
+					switch strings.TrimSpace(key) {
+					case "Temperature":
+						stat.TemperatureC, found = v, true
+					case "Percentage Used":
+						stat.WearPercentUsed, found = v, true
+					case "Media and Data Integrity Errors":
+						stat.ReallocatedSectors, found = int64(v), true
+					}
+				}
+			}
+			continue
+		}
+
+		// ATA: "ID# ATTRIBUTE_NAME FLAG VALUE WORST THRESH TYPE UPDATED WHEN_FAILED RAW_VALUE"
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		raw, rawErr := strconv.ParseFloat(fields[9], 64)
+		normalized, normErr := strconv.ParseFloat(fields[3], 64)
+		switch id {
+		case 5: // Reallocated_Sector_Ct
+			if rawErr == nil {
+				stat.ReallocatedSectors, found = int64(raw), true
+			}
+		case 190, 194: // Airflow_Temperature_Cel, Temperature_Celsius
+			if rawErr == nil && (id == 194 || stat.TemperatureC == 0) {
+				stat.TemperatureC, found = raw, true
+			}
+		case 177, 231, 233: // Wear_Leveling_Count, SSD_Life_Left, Media_Wearout_Indicator
+			if normErr == nil && normalized <= 100 {
+				stat.WearPercentUsed, found = 100-normalized, true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("no SMART attributes found in smartctl output")
+	}
+	return nil
+}
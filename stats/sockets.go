@@ -0,0 +1,163 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SocketStats holds socket usage from /proc/net/sockstat, /proc/net/snmp and /proc/net/tcp{,6}
+type SocketStats struct {
+	TCPInUse     int
+	TCPOrphan    int
+	TCPTimeWait  int
+	TCPAlloc     int
+	TCPCurrEstab int            // from /proc/net/snmp
+	TCPByState   map[string]int // e.g., "ESTABLISHED", "TIME_WAIT", over IPv4 and IPv6
+	UDPInUse     int
+}
+
+// tcpStates maps the hex state column of /proc/net/tcp to its name
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+	"0C": "NEW_SYN_RECV",
+}
+
+// SetSocketStatsEnabled enables or disables collection of TCP/UDP socket counts
+func (r *remoteStatsCollector) SetSocketStatsEnabled(enabled bool) {
+	r.socketStatsEnabled = enabled
+}
+
+// IsSocketStatsEnabled returns whether socket counts are collected
+func (r *remoteStatsCollector) IsSocketStatsEnabled() bool {
+	return r.socketStatsEnabled
+}
+
+// parseKeyValueLines parses files where each line is "Prefix: key1 value1 key2 value2 ...",
+// such as /proc/net/sockstat
+func parseKeyValueLines(content string) map[string]map[string]int64 {
+	result := make(map[string]map[string]int64)
+	for _, line := range strings.Split(content, "\n") {
+		prefix, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		values := make(map[string]int64)
+		for i := 0; i+1 < len(fields); i += 2 {
+			if v, err := strconv.ParseInt(fields[i+1], 10, 64); err == nil {
+				values[fields[i]] = v
+			}
+		}
+		result[prefix] = values
+	}
+	return result
+}
+
+// readSnmp reads files made of header/value line pairs sharing a prefix, such as
+// /proc/net/snmp ("Tcp: RtoAlgorithm RtoMin ..." followed by "Tcp: 1 200 ...")
+func (r *remoteStatsCollector) readSnmp(ctx context.Context, path string) (map[string]map[string]int64, error) {
+	file, err := r.transport.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := make(map[string]map[string]int64)
+	headers := make(map[string][]string)
+	err = scanProcLines(file, func(line []byte) error {
+		prefix, rest, ok := bytes.Cut(line, []byte(":"))
+		if !ok {
+			return nil
+		}
+		names, seen := headers[string(prefix)]
+		if !seen {
+			for field, rest := nextField(rest); field != nil; field, rest = nextField(rest) {
+				names = append(names, internName(field))
+			}
+			headers[internName(prefix)] = names
+			return nil
+		}
+		values := make(map[string]int64, len(names))
+		field, rest := nextField(rest)
+		for i := 0; i < len(names) && field != nil; i++ {
+			if v, ok := parseIntBytes(field); ok {
+				values[names[i]] = v
+			}
+			field, rest = nextField(rest)
+		}
+		result[internName(prefix)] = values
+		delete(headers, string(prefix))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// countTCPStates counts the sockets per state in a /proc/net/tcp style table
+func (r *remoteStatsCollector) countTCPStates(ctx context.Context, path string, states map[string]int) error {
+	file, err := r.transport.Open(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // skip the header line
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		name, ok := tcpStates[fields[3]]
+		if !ok {
+			name = "UNKNOWN"
+		}
+		states[name]++
+	}
+	return scanner.Err()
+}
+
+func (r *remoteStatsCollector) getSocketStats(ctx context.Context) (*SocketStats, error) {
+	sockstatContent, err := r.readRemoteFile(ctx, "/proc/net/sockstat")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sockstat: %w", err)
+	}
+	snmp, err := r.readSnmp(ctx, "/proc/net/snmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snmp: %w", err)
+	}
+
+	states := make(map[string]int)
+	if err := r.countTCPStates(ctx, "/proc/net/tcp", states); err != nil {
+		return nil, fmt.Errorf("failed to read tcp table: %w", err)
+	}
+	// IPv6 may be disabled on the host
+	_ = r.countTCPStates(ctx, "/proc/net/tcp6", states)
+
+	sockstat := parseKeyValueLines(sockstatContent)
+	return &SocketStats{
+		TCPInUse:     int(sockstat["TCP"]["inuse"]),
+		TCPOrphan:    int(sockstat["TCP"]["orphan"]),
+		TCPTimeWait:  int(sockstat["TCP"]["tw"]),
+		TCPAlloc:     int(sockstat["TCP"]["alloc"]),
+		TCPCurrEstab: int(snmp["Tcp"]["CurrEstab"]),
+		TCPByState:   states,
+		UDPInUse:     int(sockstat["UDP"]["inuse"]),
+	}, nil
+}
@@ -0,0 +1,214 @@
+package stats
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS hosts (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS samples (
+	id                   INTEGER PRIMARY KEY,
+	host_id              INTEGER NOT NULL REFERENCES hosts(id),
+	timestamp_ms         INTEGER NOT NULL,
+	total_memory_mb      REAL,
+	used_memory_mb       REAL,
+	used_memory_percent  REAL,
+	swap_used_mb         REAL,
+	total_cpu_percentage REAL,
+	load1                REAL,
+	load5                REAL,
+	load15               REAL
+);
+CREATE INDEX IF NOT EXISTS samples_host_time ON samples(host_id, timestamp_ms);
+CREATE TABLE IF NOT EXISTS cpu_cores (
+	sample_id     INTEGER NOT NULL REFERENCES samples(id),
+	core          TEXT NOT NULL,
+	usage_percent REAL,
+	PRIMARY KEY (sample_id, core)
+);
+`
+
+// SQLiteSink stores samples in a SQLite database with one table for hosts, one for samples and
+// one for per-core rows. The caller opens the database with the driver of their choice
+// (e.g., mattn/go-sqlite3 or modernc.org/sqlite), so this package does not depend on one.
+type SQLiteSink struct {
+	db      *sql.DB
+	mu      sync.Mutex
+	hostIDs map[string]int64
+}
+
+// StoredSample is a sample as read back from the database
+type StoredSample struct {
+	Host               string
+	Timestamp          time.Time
+	TotalMemoryMB      float64
+	UsedMemoryMB       float64
+	UsedMemoryPercent  float64
+	SwapUsedMB         float64
+	TotalCPUPercentage float64
+	LoadAvg            LoadAvg // only Load1, Load5 and Load15 are stored
+	CPUPercentages     map[string]float64
+}
+
+// NewSQLiteSink creates the schema in db if needed and returns a sink writing to it
+func NewSQLiteSink(db *sql.DB) (*SQLiteSink, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+	return &SQLiteSink{db: db, hostIDs: make(map[string]int64)}, nil
+}
+
+func (s *SQLiteSink) hostID(tx *sql.Tx, host string) (int64, error) {
+	if id, ok := s.hostIDs[host]; ok {
+		return id, nil
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO hosts(name) VALUES (?)`, host); err != nil {
+		return 0, err
+	}
+	var id int64
+	if err := tx.QueryRow(`SELECT id FROM hosts WHERE name = ?`, host).Scan(&id); err != nil {
+		return 0, err
+	}
+	s.hostIDs[host] = id
+	return id, nil
+}
+
+// WriteSample inserts a sample and its per-core rows in a single transaction
+func (s *SQLiteSink) WriteSample(sample *Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hostID, err := s.hostID(tx, sample.Host)
+	if err != nil {
+		return fmt.Errorf("failed to store host: %w", err)
+	}
+	st := sample.Stats
+	res, err := tx.Exec(`INSERT INTO samples (host_id, timestamp_ms, total_memory_mb, used_memory_mb, used_memory_percent,
+		swap_used_mb, total_cpu_percentage, load1, load5, load15) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		hostID, sample.Timestamp.UnixMilli(), st.TotalMemoryMB, st.UsedMemoryMB, st.UsedMemoryPercent,
+		st.SwapUsedMB, st.TotalCPUPercentage, st.LoadAvg.Load1, st.LoadAvg.Load5, st.LoadAvg.Load15)
+	if err != nil {
+		return fmt.Errorf("failed to insert sample: %w", err)
+	}
+	sampleID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get sample id: %w", err)
+	}
+	for _, cpu := range st.CPUStats {
+		if _, err := tx.Exec(`INSERT INTO cpu_cores (sample_id, core, usage_percent) VALUES (?, ?, ?)`,
+			sampleID, cpu.Core, cpu.UsagePct); err != nil {
+			return fmt.Errorf("failed to insert core %s: %w", cpu.Core, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		// The host may not have been stored after all
+		delete(s.hostIDs, sample.Host)
+		return fmt.Errorf("failed to commit sample: %w", err)
+	}
+	return nil
+}
+
+// Hosts returns the names of all hosts that have samples stored
+func (s *SQLiteSink) Hosts() ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM hosts ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hosts: %w", err)
+	}
+	defer rows.Close()
+
+	var hosts []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, name)
+	}
+	return hosts, rows.Err()
+}
+
+// Samples returns the samples of host taken in [since, until), oldest first, including per-core usage
+func (s *SQLiteSink) Samples(host string, since, until time.Time) ([]StoredSample, error) {
+	rows, err := s.db.Query(`SELECT s.id, s.timestamp_ms, s.total_memory_mb, s.used_memory_mb, s.used_memory_percent,
+		s.swap_used_mb, s.total_cpu_percentage, s.load1, s.load5, s.load15
+		FROM samples s JOIN hosts h ON h.id = s.host_id
+		WHERE h.name = ? AND s.timestamp_ms >= ? AND s.timestamp_ms < ?
+		ORDER BY s.timestamp_ms`, host, since.UnixMilli(), until.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query samples: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []StoredSample
+	byID := make(map[int64]int)
+	for rows.Next() {
+		var id, ts int64
+		stored := StoredSample{Host: host, CPUPercentages: make(map[string]float64)}
+		if err := rows.Scan(&id, &ts, &stored.TotalMemoryMB, &stored.UsedMemoryMB, &stored.UsedMemoryPercent,
+			&stored.SwapUsedMB, &stored.TotalCPUPercentage, &stored.LoadAvg.Load1, &stored.LoadAvg.Load5, &stored.LoadAvg.Load15); err != nil {
+			return nil, err
+		}
+		stored.Timestamp = time.UnixMilli(ts)
+		byID[id] = len(samples)
+		samples = append(samples, stored)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	coreRows, err := s.db.Query(`SELECT c.sample_id, c.core, c.usage_percent
+		FROM cpu_cores c JOIN samples s ON s.id = c.sample_id JOIN hosts h ON h.id = s.host_id
+		WHERE h.name = ? AND s.timestamp_ms >= ? AND s.timestamp_ms < ?`, host, since.UnixMilli(), until.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cores: %w", err)
+	}
+	defer coreRows.Close()
+
+	for coreRows.Next() {
+		var id int64
+		var core string
+		var usage float64
+		if err := coreRows.Scan(&id, &core, &usage); err != nil {
+			return nil, err
+		}
+		if idx, ok := byID[id]; ok {
+			samples[idx].CPUPercentages[core] = usage
+		}
+	}
+	return samples, coreRows.Err()
+}
+
+// AverageCPU returns the mean total CPU usage of host over [since, until)
+func (s *SQLiteSink) AverageCPU(host string, since, until time.Time) (float64, error) {
+	var avg sql.NullFloat64
+	err := s.db.QueryRow(`SELECT AVG(s.total_cpu_percentage)
+		FROM samples s JOIN hosts h ON h.id = s.host_id
+		WHERE h.name = ? AND s.timestamp_ms >= ? AND s.timestamp_ms < ?`,
+		host, since.UnixMilli(), until.UnixMilli()).Scan(&avg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query average cpu: %w", err)
+	}
+	return avg.Float64, nil
+}
+
+// DB returns the underlying database for ad-hoc queries
+func (s *SQLiteSink) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the database
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
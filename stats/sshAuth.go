@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AuthFromAgent returns an auth method using the keys of the SSH agent listening on SSH_AUTH_SOCK.
+// The agent connection stays open so that reconnects can authenticate again.
+func AuthFromAgent() (ssh.AuthMethod, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, errors.New("SSH_AUTH_SOCK is not set, no SSH agent available")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH agent: %w", err)
+	}
+	return ssh.PublicKeysCallback(agent.NewClient(conn).Signers), nil
+}
+
+// SignerFromPrivateKey parses a PEM or OpenSSH private key (RSA, ECDSA, ed25519), decrypting it
+// with passphrase if it is encrypted
+func SignerFromPrivateKey(key []byte, passphrase string) (ssh.Signer, error) {
+	if passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+		}
+		return signer, nil
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, errors.New("private key is encrypted, a passphrase is required")
+		}
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return signer, nil
+}
+
+// SignerFromPrivateKeyFile reads and parses a private key file (see SignerFromPrivateKey);
+// a leading "~/" in path is expanded to the home directory
+func SignerFromPrivateKeyFile(path, passphrase string) (ssh.Signer, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find home directory: %w", err)
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	signer, err := SignerFromPrivateKey(key, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return signer, nil
+}
+
+// AuthFromPrivateKeyFile returns an auth method using the private key at path (e.g., "~/.ssh/id_ed25519"),
+// decrypted with passphrase if it is encrypted (pass "" for unencrypted keys)
+func AuthFromPrivateKeyFile(path, passphrase string) (ssh.AuthMethod, error) {
+	signer, err := SignerFromPrivateKeyFile(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.PublicKeys(signer), nil
+}
+
+// SSHOption customizes the SSH connection made by the SSHConfig constructors
+type SSHOption func(*sshOptions) error
+
+// sshOptions is the result of applying SSHOptions
+type sshOptions struct {
+	config          *ssh.ClientConfig // a copy of the caller's config, safe to modify
+	dial            DialFunc          // nil to dial directly
+	execMode        bool              // read files with commands instead of SFTP
+	collectionModes []CollectionMode  // fallback chain, see SetCollectionModes
+}
+
+// WithAgentAuth authenticates with the keys of the SSH agent (see AuthFromAgent),
+// tried before the auth methods of the config
+func WithAgentAuth() SSHOption {
+	return func(o *sshOptions) error {
+		auth, err := AuthFromAgent()
+		if err != nil {
+			return err
+		}
+		o.config.Auth = append([]ssh.AuthMethod{auth}, o.config.Auth...)
+		return nil
+	}
+}
+
+// applySSHOptions returns the options applied to a copy of config
+func applySSHOptions(config *ssh.ClientConfig, opts []SSHOption) (*sshOptions, error) {
+	configCopy := *config
+	configCopy.Auth = append([]ssh.AuthMethod{}, config.Auth...)
+	o := &sshOptions{config: &configCopy}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// WithPrivateKeyFile authenticates with the private key at path (see AuthFromPrivateKeyFile),
+// tried before the auth methods of the config
+func WithPrivateKeyFile(path, passphrase string) SSHOption {
+	return func(o *sshOptions) error {
+		auth, err := AuthFromPrivateKeyFile(path, passphrase)
+		if err != nil {
+			return err
+		}
+		o.config.Auth = append([]ssh.AuthMethod{auth}, o.config.Auth...)
+		return nil
+	}
+}
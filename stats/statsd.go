@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// statsdMaxPacket keeps datagrams below a typical MTU
+const statsdMaxPacket = 1400
+
+// StatsDSink sends samples as StatsD gauges over UDP, with DogStatsD-style tags
+// ("name:value|g|#tag:value,...") understood by Datadog and Telegraf agents
+type StatsDSink struct {
+	namespace string
+	tags      map[string]string
+	mu        sync.Mutex
+	conn      net.Conn
+}
+
+// NewStatsDSink creates a sink sending to address (e.g., "127.0.0.1:8125"). Metric names are
+// prefixed with namespace and every gauge carries tags plus host and the metric's own labels.
+func NewStatsDSink(address, namespace string, tags map[string]string) (*StatsDSink, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open statsd socket: %w", err)
+	}
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return &StatsDSink{
+		namespace: strings.Trim(namespace, "."),
+		tags:      copied,
+		conn:      conn,
+	}, nil
+}
+
+// sanitizeStatsD removes the characters that delimit the StatsD line format
+func sanitizeStatsD(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', ',', '#', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func (s *StatsDSink) formatGauge(host string, point MetricPoint) string {
+	var b strings.Builder
+	if s.namespace != "" {
+		b.WriteString(s.namespace)
+		b.WriteByte('.')
+	}
+	b.WriteString(point.Name)
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(point.Value, 'f', -1, 64))
+	b.WriteString("|g")
+
+	tags := make([]string, 0, len(s.tags)+len(point.Labels)+1)
+	if host != "" {
+		tags = append(tags, "host:"+sanitizeStatsD(host))
+	}
+	for k, v := range s.tags {
+		tags = append(tags, sanitizeStatsD(k)+":"+sanitizeStatsD(v))
+	}
+	for k, v := range point.Labels {
+		tags = append(tags, sanitizeStatsD(k)+":"+sanitizeStatsD(v))
+	}
+	if len(tags) > 0 {
+		sort.Strings(tags)
+		b.WriteString("|#")
+		b.WriteString(strings.Join(tags, ","))
+	}
+	return b.String()
+}
+
+// WriteSample sends every metric of sample as a gauge, batching lines into datagrams
+func (s *StatsDSink) WriteSample(sample *Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := s.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, point := range SampleToMetrics(sample) {
+		line := s.formatGauge(sample.Host, point)
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdMaxPacket {
+			if err := flush(); err != nil {
+				return fmt.Errorf("failed to send statsd packet: %w", err)
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("failed to send statsd packet: %w", err)
+	}
+	return nil
+}
+
+// Close closes the UDP socket
+func (s *StatsDSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package stats
+
+import (
+	"fmt"
+
+	"github.com/pkg/sftp"
+)
+
+// localStatVFS is only implemented on Linux, the only system with the /proc files read locally
+func localStatVFS(path string) (*sftp.StatVFS, error) {
+	return nil, fmt.Errorf("local file system statistics are not supported on this platform")
+}
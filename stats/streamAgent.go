@@ -0,0 +1,272 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxStreamFrames bounds the frames kept by a streaming agent, whatever the sample delta
+const maxStreamFrames = 4096
+
+// streamFrame is one pass of the streaming agent over its files
+type streamFrame struct {
+	taken time.Time // remote clock when the pass started, or when it was received if unavailable
+	files map[string]batchedFile
+}
+
+// streamAgent runs a shell loop on the host that prints a frame of files every period over a single
+// SSH session, and keeps the latest frames
+type streamAgent struct {
+	session *ssh.Session
+	paths   []string // sorted files of every frame
+	marker  string
+
+	mu      sync.Mutex
+	frames  []*streamFrame // oldest first
+	retain  time.Duration  // how far back from the newest frame to keep frames
+	updated chan struct{}  // closed and replaced whenever a frame arrives or the stream ends
+	err     error          // why the stream ended, nil while running
+}
+
+func startStreamAgent(client *ssh.Client, paths []string, period time.Duration) (*streamAgent, error) {
+	if client == nil {
+		return nil, errors.New("the streaming agent requires an SSH client")
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to get the agent output: %w", err)
+	}
+
+	agent := &streamAgent{
+		session: session,
+		paths:   paths,
+		marker:  newOutputMarker(),
+		updated: make(chan struct{}),
+	}
+	// Frames are "\n<marker>F <unix time>\n", the files as in a batch, then "\n<marker>E\n"
+	script := fmt.Sprintf(`export LC_ALL=C; while :; do printf '\n%sF %%s\n' "$(date +%%s.%%N)"; %s; printf '\n%sE\n'; sleep %s || exit; done`,
+		agent.marker, catFilesScript(agent.marker, paths), agent.marker, strconv.FormatFloat(period.Seconds(), 'f', 3, 64))
+	if err := session.Start(script); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to start the streaming agent: %w", err)
+	}
+	go agent.read(stdout)
+	return agent, nil
+}
+
+// read parses frames from the agent output until the session ends
+func (a *streamAgent) read(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	var frame *streamFrame
+	var path string
+	var content []byte
+	var err error
+	for {
+		var line string
+		if line, err = reader.ReadString('\n'); err != nil {
+			break
+		}
+		header, isHeader := strings.CutPrefix(line, a.marker)
+		if !isHeader {
+			if path != "" {
+				content = append(content, line...)
+			}
+			continue
+		}
+
+		// The newline before a header belongs to the header, not to the previous file
+		if frame != nil && path != "" {
+			frame.files[path] = batchedFile{data: content[:max(len(content)-1, 0)]}
+		}
+		path, content = "", nil
+		header = strings.TrimSuffix(header, "\n")
+		switch {
+		case strings.HasPrefix(header, "F "):
+			frame = &streamFrame{taken: parseFrameTime(header[2:]), files: make(map[string]batchedFile, len(a.paths))}
+		case header == "E":
+			if frame != nil {
+				a.publish(frame)
+			}
+			frame = nil
+		case strings.HasPrefix(header, "! "):
+			if frame != nil {
+				frame.files[header[2:]] = batchedFile{err: fmt.Errorf("%s: %w", header[2:], os.ErrNotExist)}
+			}
+		case strings.HasPrefix(header, " "):
+			path = header[1:]
+		}
+	}
+	if err == io.EOF {
+		err = errors.New("the streaming agent exited")
+	}
+	a.finish(err)
+}
+
+// parseFrameTime parses the "seconds.nanoseconds" printed by date, or returns the current time if
+// the remote date does not support it
+func parseFrameTime(s string) time.Time {
+	seconds, nanos, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	nsec, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil || len(nanos) != 9 {
+		return time.Now()
+	}
+	return time.Unix(sec, nsec)
+}
+
+func (a *streamAgent) publish(frame *streamFrame) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.frames = append(a.frames, frame)
+	drop := 0
+	for drop < len(a.frames)-2 && frame.taken.Sub(a.frames[drop].taken) > a.retain {
+		drop++
+	}
+	drop = max(drop, len(a.frames)-maxStreamFrames)
+	a.frames = slices.Delete(a.frames, 0, drop)
+	close(a.updated)
+	a.updated = make(chan struct{})
+}
+
+func (a *streamAgent) finish(err error) {
+	a.session.Close()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.err = err
+	close(a.updated)
+	a.updated = make(chan struct{})
+}
+
+// running returns whether the agent is still printing frames
+func (a *streamAgent) running() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err == nil
+}
+
+// framePair waits until the newest frame is later than after and has an older frame at least delta
+// before it, and returns both. It gives up when ctx is done.
+func (a *streamAgent) framePair(ctx context.Context, delta time.Duration, after time.Time, timeout time.Duration) (older, newer *streamFrame, err error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		a.mu.Lock()
+		a.retain = 2 * delta
+		if n := len(a.frames); n > 0 && a.frames[n-1].taken.After(after) {
+			newer = a.frames[n-1]
+			for i := n - 2; i >= 0; i-- {
+				if newer.taken.Sub(a.frames[i].taken) >= delta {
+					older = a.frames[i]
+					break
+				}
+			}
+		}
+		updated, streamErr := a.updated, a.err
+		a.mu.Unlock()
+
+		switch {
+		case older != nil:
+			return older, newer, nil
+		case streamErr != nil:
+			return nil, nil, streamErr
+		}
+		select {
+		case <-updated:
+		case <-timer.C:
+			return nil, nil, errors.New("timed out waiting for the streaming agent")
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (a *streamAgent) close() {
+	a.session.Close()
+}
+
+// SetStreamingInterval starts a shell loop on the host, over a single SSH session, that prints the
+// files of a sample every interval (e.g., 50ms). Samples are then computed from the latest two frames
+// sampleDelta apart without waiting or any round trip for them, which allows sub-100ms sample deltas
+// over high latency links. The loop is restarted when the files change (e.g., a new watched process)
+// or after a reconnection. Directory listings and disk usage are still separate requests.
+// 0, the default, stops the agent.
+func (r *remoteStatsCollector) SetStreamingInterval(interval time.Duration) {
+	if interval != r.streamInterval {
+		r.stopStreamAgent()
+	}
+	r.streamInterval = interval
+}
+
+// GetStreamingInterval returns the period of the streaming agent frames, 0 if disabled
+func (r *remoteStatsCollector) GetStreamingInterval() time.Duration {
+	return r.streamInterval
+}
+
+func (r *remoteStatsCollector) stopStreamAgent() {
+	if r.stream != nil {
+		r.stream.close()
+		r.stream = nil
+	}
+}
+
+// streamSnapshots takes both snapshots from the frames of the streaming agent, starting it if needed,
+// and leaves the newer frame in frames for the rest of the sample
+func (r *remoteStatsCollector) streamSnapshots(ctx context.Context, watched map[int]string, frames *batchingTransport) (stat1, stat2 *procSnapshot, err error) {
+	paths := append(r.sampleFiles(watched), snapshotFiles(watched)...)
+	slices.Sort(paths)
+	if r.stream != nil && (!r.stream.running() || !slices.Equal(paths, r.stream.paths)) {
+		r.stopStreamAgent()
+	}
+	if r.stream == nil {
+		r.connMu.Lock()
+		client := r.sshClient
+		r.connMu.Unlock()
+		if r.stream, err = startStreamAgent(client, paths, r.streamInterval); err != nil {
+			return nil, nil, err
+		}
+		r.streamLast = time.Time{}
+	}
+
+	sampleDelta := r.GetSampleDelta()
+	older, newer, err := r.stream.framePair(ctx, sampleDelta, r.streamLast, sampleDelta+2*r.streamInterval+10*time.Second)
+	if err != nil {
+		if ctx.Err() != nil {
+			// The agent is still streaming, the next collection picks up its frames
+			return nil, nil, err
+		}
+		r.stopStreamAgent()
+		return nil, nil, fmt.Errorf("failed to read the streaming agent: %w", err)
+	}
+	r.streamLast = newer.taken
+
+	frames.files = older.files
+	if stat1, err = r.takeSnapshot(ctx, watched); err != nil {
+		return nil, nil, fmt.Errorf("failed to take first snapshot: %w", err)
+	}
+	stat1.taken = older.taken
+	frames.files = newer.files
+	if stat2, err = r.takeSnapshot(ctx, watched); err != nil {
+		return nil, nil, fmt.Errorf("failed to take second snapshot: %w", err)
+	}
+	stat2.taken = newer.taken
+	return stat1, stat2, nil
+}
@@ -0,0 +1,209 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogFacility is the RFC 5424 facility code
+type SyslogFacility int
+
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	FacilityLocal0 SyslogFacility = iota + 4
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// SyslogSeverity is the RFC 5424 severity code
+type SyslogSeverity int
+
+const (
+	SeverityEmergency SyslogSeverity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// localSyslogSockets are the usual locations of the local syslog daemon socket
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSink sends samples to syslog as RFC 5424 messages whose HOSTNAME is the monitored host
+// and whose MSG is the sample JSON. It also implements io.Writer, so it can back a *log.Logger.
+type SyslogSink struct {
+	network  string // "" for the local daemon, otherwise "udp", "tcp" or "unix"
+	address  string
+	facility SyslogFacility
+	severity SyslogSeverity
+	appName  string
+	hostname string // used for Write, where there is no sample host
+	mu       sync.Mutex
+	conn     net.Conn
+	framed   bool // stream connections use octet-counting framing (RFC 6587)
+}
+
+// NewSyslogSink connects to syslog. An empty network connects to the local daemon socket,
+// otherwise address is e.g. "syslog.example.com:514" for "udp" and "tcp", or a socket path for "unix".
+func NewSyslogSink(network, address string, facility SyslogFacility, severity SyslogSeverity) (*SyslogSink, error) {
+	if facility < FacilityKern || facility > FacilityLocal7 {
+		return nil, fmt.Errorf("invalid syslog facility %d", facility)
+	}
+	if severity < SeverityEmergency || severity > SeverityDebug {
+		return nil, fmt.Errorf("invalid syslog severity %d", severity)
+	}
+	hostname, _ := os.Hostname()
+	s := &SyslogSink{
+		network:  network,
+		address:  address,
+		facility: facility,
+		severity: severity,
+		appName:  filepath.Base(os.Args[0]),
+		hostname: hostname,
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetAppName sets the APP-NAME of the messages, the program name by default
+func (s *SyslogSink) SetAppName(appName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appName = appName
+}
+
+func (s *SyslogSink) connect() error {
+	if s.network != "" {
+		conn, err := net.Dial(s.network, s.address)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s.conn = conn
+		s.framed = s.network == "tcp" || s.network == "tcp4" || s.network == "tcp6"
+		return nil
+	}
+	for _, path := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				s.conn = conn
+				s.framed = false
+				return nil
+			}
+		}
+	}
+	return errors.New("failed to connect to local syslog: no syslog socket found")
+}
+
+// syslogField returns value as an RFC 5424 header field: printable ASCII without spaces, "-" if empty
+func syslogField(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	return value
+}
+
+// formatSyslog renders an RFC 5424 message without structured data
+func (s *SyslogSink) formatSyslog(timestamp time.Time, hostname, msgID string, msg []byte) []byte {
+	pri := int(s.facility)*8 + int(s.severity)
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ", pri,
+		timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(hostname, 255), syslogField(s.appName, 48), os.Getpid(), syslogField(msgID, 32))
+	line := append([]byte(header), msg...)
+	if s.framed {
+		line = append([]byte(strconv.Itoa(len(line))+" "), line...)
+	}
+	return line
+}
+
+// send writes a message, reconnecting once if the connection was lost
+func (s *SyslogSink) send(line []byte) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	if _, err := s.conn.Write(line); err == nil {
+		return nil
+	}
+	s.conn.Close()
+	s.conn = nil
+	if err := s.connect(); err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(line); err != nil {
+		return fmt.Errorf("failed to write to syslog: %w", err)
+	}
+	return nil
+}
+
+// WriteSample sends a sample as a single syslog message with MSGID "sample"
+func (s *SyslogSink) WriteSample(sample *Sample) error {
+	msg, err := json.Marshal(SampleToJSON(sample))
+	if err != nil {
+		return fmt.Errorf("failed to marshal sample: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.send(s.formatSyslog(sample.Timestamp, sample.Host, "sample", msg))
+}
+
+// Write sends p as a single syslog message from the local host, e.g., as the output of a *log.Logger
+func (s *SyslogSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg := []byte(strings.TrimRight(string(p), "\n"))
+	if err := s.send(s.formatSyslog(time.Now(), s.hostname, "-", msg)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to syslog
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,150 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SystemInfo holds the values of a host that do not change until it reboots. They are read on the
+// first collection and after a reboot, and reported once through a "system_info" event rather
+// than in every sample.
+type SystemInfo struct {
+	CPUModel      string // e.g., "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz", empty if not reported
+	CPUCount      int    // logical CPUs
+	TotalMemoryMB float64
+	KernelRelease string // e.g., "6.1.0-18-amd64"
+}
+
+// cpuModelKeys are the /proc/cpuinfo fields naming the CPU, by architecture, in order of preference
+var cpuModelKeys = []string{"model name", "Model", "Hardware", "cpu model", "cpu"}
+
+// parseCPUModel returns the CPU model from the content of /proc/cpuinfo
+func parseCPUModel(cpuinfo string) string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, seen := values[key]; !seen {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	for _, key := range cpuModelKeys {
+		if value := values[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// readSystemInfo reads the static values of the host, without the rest of a sample
+func (r *remoteStatsCollector) readSystemInfo(ctx context.Context) (*SystemInfo, error) {
+	info := &SystemInfo{}
+	cpu, _, err := r.readProcStat(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/stat: %w", err)
+	}
+	for core := range cpu {
+		if core != "cpu" {
+			info.CPUCount++
+		}
+	}
+	meminfo, err := r.readMeminfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read meminfo: %w", err)
+	}
+	info.TotalMemoryMB = meminfo["MemTotal"] / 1024
+	cpuinfo, err := r.readRemoteFile(ctx, "/proc/cpuinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/cpuinfo: %w", err)
+	}
+	info.CPUModel = parseCPUModel(cpuinfo)
+	release, err := r.readRemoteFile(ctx, "/proc/sys/kernel/osrelease")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kernel release: %w", err)
+	}
+	info.KernelRelease = strings.TrimSpace(release)
+	return info, nil
+}
+
+// cacheSystemInfo caches info read during a collection. A nil info keeps the cache, unless the
+// sample reports a reboot.
+func (r *remoteStatsCollector) cacheSystemInfo(info *SystemInfo, stats *SystemStats) {
+	r.infoMu.Lock()
+	defer r.infoMu.Unlock()
+	switch {
+	case info != nil:
+		r.info = info
+	case stats.Reboot != nil:
+		// Read again on the next collection, the hardware or kernel may have changed
+		r.info = nil
+	}
+}
+
+// cachedSystemInfo returns the cached info, nil if it was not read yet
+func (r *remoteStatsCollector) cachedSystemInfo() *SystemInfo {
+	r.infoMu.Lock()
+	defer r.infoMu.Unlock()
+	return r.info
+}
+
+// SystemInfo returns the static values of the host, reading them if no collection did yet. It
+// waits for a collection in progress, which may read them on its own.
+func (r *remoteStatsCollector) SystemInfo(ctx context.Context) (*SystemInfo, error) {
+	if info := r.cachedSystemInfo(); info != nil {
+		return info, nil
+	}
+	r.collectMu.Lock()
+	defer r.collectMu.Unlock()
+	if info := r.cachedSystemInfo(); info != nil {
+		return info, nil
+	}
+	info, err := r.readSystemInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.infoMu.Lock()
+	defer r.infoMu.Unlock()
+	r.info = info
+	return info, nil
+}
+
+// systemInfoSource is implemented by collectors caching the static values of their host
+type systemInfoSource interface {
+	SystemInfo(ctx context.Context) (*SystemInfo, error)
+	cachedSystemInfo() *SystemInfo
+}
+
+// SystemInfo returns the static values of the monitored host (see SystemInfo), collecting a
+// sample if the monitor has not collected one yet
+func (m *RemoteStatsMonitor) SystemInfo(ctx context.Context) (*SystemInfo, error) {
+	source, ok := m.statsSource().(systemInfoSource)
+	if !ok {
+		return nil, fmt.Errorf("collector does not report system info")
+	}
+	return source.SystemInfo(ctx)
+}
+
+// logSystemInfo emits a "system_info" event when the collector cached new static values. For
+// collectors without a cache (e.g., Windows or node_exporter), the values are taken from stats.
+func (m *RemoteStatsMonitor) logSystemInfo(stats *SystemStats) {
+	var info *SystemInfo
+	if source, ok := m.statsSource().(systemInfoSource); ok {
+		info = source.cachedSystemInfo()
+	} else if stats.TotalMemoryMB > 0 && len(stats.CPUStats) > 0 {
+		info = &SystemInfo{CPUCount: len(stats.CPUStats), TotalMemoryMB: stats.TotalMemoryMB}
+	}
+	if info == nil || (m.loggedInfo != nil && *info == *m.loggedInfo) {
+		return
+	}
+	m.loggedInfo = info
+	m.logEvent("system_info", map[string]any{
+		"cpu_model":       info.CPUModel,
+		"cpu_count":       info.CPUCount,
+		"total_memory_mb": info.TotalMemoryMB,
+		"kernel_release":  info.KernelRelease,
+	})
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestCachedSystemInfoReplacesPerSampleValues(t *testing.T) {
+	meminfo := map[string]float64{"MemTotal": 8 * 1024 * 1024, "MemAvailable": 6 * 1024 * 1024}
+	info := &SystemInfo{CPUCount: 4, TotalMemoryMB: 16 * 1024}
+
+	if total, used, err := getMemoryStats(meminfo, nil); err != nil || total != 8*1024 || used != 2*1024 {
+		t.Errorf("getMemoryStats(nil) = %v, %v, %v, want 8192, 2048", total, used, err)
+	}
+	if total, used, err := getMemoryStats(meminfo, info); err != nil || total != 16*1024 || used != 10*1024 {
+		t.Errorf("getMemoryStats(info) = %v, %v, %v, want 16384, 10240", total, used, err)
+	}
+
+	snapshot := &procSnapshot{
+		cpu:      map[string][]float64{"cpu": nil, "cpu0": nil, "cpu1": nil},
+		procStat: map[string]uint64{"procs_running": 6},
+	}
+	if got := computeRunQueue(snapshot, 0); got.CPUCount != 2 || got.RunnablePerCPU != 3 {
+		t.Errorf("computeRunQueue(0) = %+v, want 2 CPUs and 3 runnable per CPU", got)
+	}
+	if got := computeRunQueue(snapshot, info.CPUCount); got.CPUCount != 4 || got.RunnablePerCPU != 1.5 {
+		t.Errorf("computeRunQueue(4) = %+v, want 4 CPUs and 1.5 runnable per CPU", got)
+	}
+}
+
+func TestSystemStatsToJSONLeavesOutStaticValues(t *testing.T) {
+	data := SystemStatsToJSON(&SystemStats{TotalMemoryMB: 16384, RunQueue: RunQueueStats{CPUCount: 4}})
+	if _, ok := data["total_memory_mb"]; ok {
+		t.Error("total_memory_mb is written in every sample")
+	}
+	if _, ok := data["run_queue"].(map[string]any)["cpu_count"]; ok {
+		t.Error("run_queue.cpu_count is written in every sample")
+	}
+}
+
+// Run with -race: SystemInfo reads the host while the monitor collects from it
+func TestSystemInfoWhileMonitoring(t *testing.T) {
+	files := procFiles(sampleProcStat, sampleMeminfo, sampleDiskstats, sampleNetDev, sampleSnmp, sampleVmstat)
+	files["/proc/cpuinfo"] = "processor\t: 0\nmodel name\t: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz\n"
+	files["/proc/sys/kernel/osrelease"] = "6.1.0-18-amd64\n"
+	collector := NewRemoteStatsCollectorFromSFTP(nil, time.Millisecond)
+	collector.transport = files
+	m := NewStatsMonitor(collector, 5*time.Millisecond, time.Millisecond, log.New(io.Discard, "", 0))
+	if err := m.StartAsync(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	want := SystemInfo{
+		CPUModel:      "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz",
+		CPUCount:      4,
+		TotalMemoryMB: 16303428.0 / 1024,
+		KernelRelease: "6.1.0-18-amd64",
+	}
+	for i := 0; i < 20; i++ {
+		info, err := m.SystemInfo(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *info != want {
+			t.Fatalf("SystemInfo() = %+v, want %+v", *info, want)
+		}
+		// Drop the cache so that the next call reads the host again
+		collector.infoMu.Lock()
+		collector.info = nil
+		collector.infoMu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"context"
+	"strings"
+)
+
+// SystemdStats holds the state of the watched systemd units
+type SystemdStats struct {
+	Units       map[string]string // unit -> "active", "inactive", "failed", "activating", ...
+	FailedUnits []string          // every unit in the failed state, from systemctl --failed
+}
+
+// SetSystemdEnabled enables or disables systemd unit health collection
+func (r *remoteStatsCollector) SetSystemdEnabled(enabled bool) {
+	r.systemdEnabled = enabled
+}
+
+// IsSystemdEnabled returns whether systemd unit health is collected
+func (r *remoteStatsCollector) IsSystemdEnabled() bool {
+	return r.systemdEnabled
+}
+
+// SetSystemdUnits sets the units whose state is checked with systemctl is-active on every sample
+func (r *remoteStatsCollector) SetSystemdUnits(units []string) {
+	r.systemdUnits = append([]string(nil), units...)
+}
+
+// GetSystemdUnits returns the units whose state is checked
+func (r *remoteStatsCollector) GetSystemdUnits() []string {
+	return append([]string(nil), r.systemdUnits...)
+}
+
+// shellQuote quotes s for safe use as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (r *remoteStatsCollector) getSystemdStats(ctx context.Context) (*SystemdStats, error) {
+	stats := &SystemdStats{}
+
+	failed, err := r.runRemoteCommand(ctx, "systemctl --failed --no-legend --plain")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(failed, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			stats.FailedUnits = append(stats.FailedUnits, fields[0])
+		}
+	}
+
+	if len(r.systemdUnits) > 0 {
+		quoted := make([]string, 0, len(r.systemdUnits))
+		for _, unit := range r.systemdUnits {
+			quoted = append(quoted, shellQuote(unit))
+		}
+		// is-active exits non-zero when any unit is not active, the states are still printed
+		output, err := r.runRemoteCommand(ctx, "systemctl is-active "+strings.Join(quoted, " ")+" || true")
+		if err != nil {
+			return nil, err
+		}
+		states := strings.Split(strings.TrimSpace(output), "\n")
+		stats.Units = make(map[string]string, len(r.systemdUnits))
+		for i, unit := range r.systemdUnits {
+			state := "unknown"
+			if i < len(states) && strings.TrimSpace(states[i]) != "" {
+				state = strings.TrimSpace(states[i])
+			}
+			stats.Units[unit] = state
+		}
+	}
+	return stats, nil
+}
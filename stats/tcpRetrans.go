@@ -0,0 +1,30 @@
+package stats
+
+// TCPRetransStats holds the TCP retransmission rate over the sampleDelta window
+type TCPRetransStats struct {
+	RetransSegsPerSec float64
+	OutSegsPerSec     float64
+	RetransPercent    float64 // retransmitted segments as a share of all sent segments
+}
+
+func computeTCPRetrans(stat1, stat2 *procSnapshot) TCPRetransStats {
+	elapsed := stat2.taken.Sub(stat1.taken)
+	delta := func(key string) uint64 {
+		a, b := stat1.snmp["Tcp"][key], stat2.snmp["Tcp"][key]
+		if a < 0 || b < a {
+			return 0
+		}
+		return uint64(b - a)
+	}
+
+	retrans := delta("RetransSegs")
+	out := delta("OutSegs")
+	stats := TCPRetransStats{
+		RetransSegsPerSec: ratePerSecond(retrans, elapsed),
+		OutSegsPerSec:     ratePerSecond(out, elapsed),
+	}
+	if out > 0 {
+		stats.RetransPercent = float64(retrans) / float64(out) * 100.0
+	}
+	return stats
+}
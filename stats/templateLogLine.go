@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is what NewTemplateLogLine templates are executed with: every SystemStats field
+// (e.g., {{.TotalCPUPercentage}}, {{range .CPUStats}}) plus the time the line was formatted
+type TemplateData struct {
+	*SystemStats
+	Timestamp time.Time
+}
+
+// templateFuncs are the helpers available to log line templates in addition to the text/template builtins
+var templateFuncs = template.FuncMap{
+	// {{pct .UsedMemoryPercent}} -> "42.10"
+	"pct": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	// {{.Timestamp | unix}} -> seconds since the epoch
+	"unix": func(t time.Time) int64 { return t.Unix() },
+	// {{.Timestamp | rfc3339}}
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339Nano) },
+	// {{json .LoadAvg}} -> the value as compact JSON
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// NewTemplateLogLine returns a logLineFunc (see SetLogLineFunc) rendering each sample through the
+// text/template tmpl, e.g.:
+//
+//	cpu={{pct .TotalCPUPercentage}} mem={{pct .UsedMemoryPercent}}{{range .CPUStats}} {{.Core}}={{pct .UsagePct}}{{end}}
+//
+// Newlines in the output are replaced with spaces so every sample stays on one line.
+func NewTemplateLogLine(tmpl string) (func(*SystemStats) ([]byte, error), error) {
+	t, err := template.New("logline").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse log line template: %w", err)
+	}
+	return func(stats *SystemStats) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, TemplateData{SystemStats: stats, Timestamp: time.Now()}); err != nil {
+			return nil, fmt.Errorf("failed to execute log line template: %w", err)
+		}
+		line := strings.TrimRight(buf.String(), "\r\n")
+		return []byte(strings.NewReplacer("\r\n", " ", "\n", " ").Replace(line)), nil
+	}, nil
+}
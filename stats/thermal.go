@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemperatureStat holds the reading of a single temperature sensor
+type TemperatureStat struct {
+	Sensor  string // e.g., "thermal_zone0", "hwmon1/temp2"
+	Label   string // zone type or hwmon label, e.g., "cpu-thermal", "coretemp/Core 0"
+	Celsius float64
+}
+
+// SetThermalEnabled enables or disables collection of thermal zone and hwmon temperatures
+func (r *remoteStatsCollector) SetThermalEnabled(enabled bool) {
+	r.thermalEnabled = enabled
+}
+
+// IsThermalEnabled returns whether temperatures are collected
+func (r *remoteStatsCollector) IsThermalEnabled() bool {
+	return r.thermalEnabled
+}
+
+// readMilliCelsius reads a sysfs temperature file, which reports millidegrees Celsius
+func (r *remoteStatsCollector) readMilliCelsius(ctx context.Context, file string) (float64, error) {
+	content, err := r.readRemoteFile(ctx, file)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(content), 64)
+	if err != nil {
+		return 0, err
+	}
+	return v / 1000.0, nil
+}
+
+// getTemperatures reads /sys/class/thermal/thermal_zone* and /sys/class/hwmon/hwmon*/temp*_input
+// Unreadable zones and sensors are skipped
+func (r *remoteStatsCollector) getTemperatures(ctx context.Context) []TemperatureStat {
+	var temps []TemperatureStat
+
+	zones, err := r.transport.ReadDir(ctx, "/sys/class/thermal")
+	if err == nil {
+		for _, zone := range zones {
+			if !strings.HasPrefix(zone.Name(), "thermal_zone") {
+				continue
+			}
+			dir := path.Join("/sys/class/thermal", zone.Name())
+			celsius, err := r.readMilliCelsius(ctx, path.Join(dir, "temp"))
+			if err != nil {
+				// Some zones are disabled and fail to read
+				continue
+			}
+			label, _ := r.readRemoteFile(ctx, path.Join(dir, "type"))
+			temps = append(temps, TemperatureStat{
+				Sensor:  zone.Name(),
+				Label:   strings.TrimSpace(label),
+				Celsius: celsius,
+			})
+		}
+	}
+
+	chips, err := r.transport.ReadDir(ctx, "/sys/class/hwmon")
+	if err == nil {
+		for _, chip := range chips {
+			dir := path.Join("/sys/class/hwmon", chip.Name())
+			inputs, err := r.transport.Glob(ctx, path.Join(dir, "temp*_input"))
+			if err != nil || len(inputs) == 0 {
+				continue
+			}
+			name, _ := r.readRemoteFile(ctx, path.Join(dir, "name"))
+			name = strings.TrimSpace(name)
+			for _, input := range inputs {
+				celsius, err := r.readMilliCelsius(ctx, input)
+				if err != nil {
+					continue
+				}
+				sensor := strings.TrimSuffix(path.Base(input), "_input")
+				label, err := r.readRemoteFile(ctx, path.Join(dir, sensor+"_label"))
+				if err != nil {
+					label = sensor
+				}
+				temps = append(temps, TemperatureStat{
+					Sensor:  chip.Name() + "/" + sensor,
+					Label:   name + "/" + strings.TrimSpace(label),
+					Celsius: celsius,
+				})
+			}
+		}
+	}
+
+	sort.Slice(temps, func(i, j int) bool {
+		return temps[i].Sensor < temps[j].Sensor
+	})
+	return temps
+}
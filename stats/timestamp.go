@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TimestampFormat selects how the "timestamp" of JSON log lines and events is written.
+// Any value other than the constants below is used as a custom time layout (e.g., "2006-01-02 15:04:05").
+type TimestampFormat string
+
+const (
+	TimestampClock     TimestampFormat = "15:04:05.000000" // wall clock only, the default
+	TimestampRFC3339   TimestampFormat = time.RFC3339Nano
+	TimestampUnix      TimestampFormat = "unix"    // seconds since the epoch, as a JSON number
+	TimestampUnixMilli TimestampFormat = "unix_ms" // milliseconds since the epoch, as a JSON number
+)
+
+// timestampFormatter renders timestamps in a format and timezone
+type timestampFormatter struct {
+	format   TimestampFormat
+	location *time.Location // nil for local time
+}
+
+var defaultTimestampFormatter = timestampFormatter{format: TimestampClock}
+
+// value returns t as a JSON value: a number for the unix formats, a string otherwise
+func (f timestampFormatter) value(t time.Time) any {
+	if f.location != nil {
+		t = t.In(f.location)
+	}
+	switch strings.ToLower(string(f.format)) {
+	case string(TimestampUnix):
+		return t.Unix()
+	case string(TimestampUnixMilli):
+		return t.UnixMilli()
+	case "":
+		return t.Format(string(TimestampClock))
+	}
+	return t.Format(string(f.format))
+}
+
+// NewJSONLogLine returns the default JSON logLineFunc with its timestamp written in format,
+// in location (time.UTC, or nil for local time)
+func NewJSONLogLine(format TimestampFormat, location *time.Location) func(*SystemStats) ([]byte, error) {
+	formatter := timestampFormatter{format: format, location: location}
+	return func(stats *SystemStats) ([]byte, error) {
+		return formatJSONLogLine(stats, formatter)
+	}
+}
+
+func formatJSONLogLine(stats *SystemStats, timestamps timestampFormatter) ([]byte, error) {
+	data := SystemStatsToJSON(stats)
+	data["timestamp"] = timestamps.value(time.Now())
+	//bytes, err := json.MarshalIndent(data, "", "  ")
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return bytes, nil
+}
@@ -0,0 +1,232 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// transport is how a collector reaches the files and commands of the system it monitors.
+// Every request gives up once its ctx is done.
+type transport interface {
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	ReadDir(ctx context.Context, path string) ([]os.FileInfo, error)
+	Glob(ctx context.Context, pattern string) ([]string, error)
+	StatVFS(ctx context.Context, path string) (*sftp.StatVFS, error)
+	Run(ctx context.Context, cmd string) (string, error) // returns the standard output of cmd run by a shell
+}
+
+// sshTransport reads files over the collector's SFTP client and runs commands in SSH sessions.
+// It goes through the collector so that a reconnect is picked up without replacing it.
+type sshTransport struct {
+	r *remoteStatsCollector
+}
+
+// interruptible runs op, returning early with the context error when the collection is cancelled.
+// The SFTP client cannot abort a request, so an abandoned op finishes in the background and its
+// result is passed to discard.
+func interruptible[T any](ctx context.Context, op func() (T, error), discard func(T)) (T, error) {
+	var zero T
+	if ctx.Done() == nil {
+		return op()
+	}
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := op()
+		done <- result{value, err}
+	}()
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		if discard != nil {
+			go func() {
+				if res := <-done; res.err == nil {
+					discard(res.value)
+				}
+			}()
+		}
+		return zero, ctx.Err()
+	}
+}
+
+func (t sshTransport) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if reusedFiles[path] {
+		return t.r.openReused(ctx, path)
+	}
+	file, err := interruptible(ctx, func() (*sftp.File, error) {
+		return t.r.sftpClient.Open(path)
+	}, func(file *sftp.File) { file.Close() })
+	if err != nil {
+		return nil, err
+	}
+	return newSFTPReader(ctx, file, func() { file.Close() }), nil
+}
+
+// sftpReader reads an SFTP file straight into the buffers of its reader, calling release when ctx
+// is done so no further request is sent. The SFTP client cannot abort a read already waiting on the
+// server, which ends when the connection is dropped (see SetCycleTimeout and SetKeepalive).
+type sftpReader struct {
+	file    *sftp.File
+	ctx     context.Context
+	release func()
+	stop    func() bool
+}
+
+func newSFTPReader(ctx context.Context, file *sftp.File, release func()) *sftpReader {
+	return &sftpReader{file: file, ctx: ctx, release: release, stop: context.AfterFunc(ctx, release)}
+}
+
+func (f *sftpReader) Read(p []byte) (int, error) {
+	if err := f.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := f.file.Read(p)
+	if err != nil && f.ctx.Err() != nil {
+		return n, f.ctx.Err()
+	}
+	return n, err
+}
+
+func (f *sftpReader) Close() error {
+	if !f.stop() {
+		// Already released by the cancellation
+		return nil
+	}
+	f.release()
+	return nil
+}
+
+func (t sshTransport) ReadDir(ctx context.Context, path string) ([]os.FileInfo, error) {
+	return interruptible(ctx, func() ([]os.FileInfo, error) {
+		return t.r.sftpClient.ReadDir(path)
+	}, nil)
+}
+
+func (t sshTransport) Glob(ctx context.Context, pattern string) ([]string, error) {
+	return interruptible(ctx, func() ([]string, error) {
+		return t.r.sftpClient.Glob(pattern)
+	}, nil)
+}
+
+func (t sshTransport) StatVFS(ctx context.Context, path string) (*sftp.StatVFS, error) {
+	return interruptible(ctx, func() (*sftp.StatVFS, error) {
+		return t.r.sftpClient.StatVFS(path)
+	}, nil)
+}
+
+func (t sshTransport) Run(ctx context.Context, cmd string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	return commandOutput(cmd, t.runSession(ctx, cmd, &stdout, &stderr), &stdout, &stderr)
+}
+
+// runSession runs cmd in a new SSH session, writing its output to stdout and stderr.
+// The session is closed when ctx is done.
+func (t sshTransport) runSession(ctx context.Context, cmd string, stdout, stderr io.Writer) error {
+	if t.r.sshClient == nil {
+		return fmt.Errorf("running %q requires an SSH client (collector was created from an SFTP client)", cmd)
+	}
+	return runSSHSession(ctx, t.r.sshClient, cmd, nil, stdout, stderr)
+}
+
+// runSSHSession runs cmd in a new session of client with stdin as its input (nil for none),
+// closing the session when ctx is done
+func runSSHSession(ctx context.Context, client *ssh.Client, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if err := session.Run(cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// commandOutput returns the standard output of a finished command, or its error with the standard error
+func commandOutput(cmd string, err error, stdout, stderr *bytes.Buffer) (string, error) {
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%q failed: %w: %s", cmd, err, msg)
+		}
+		return "", fmt.Errorf("%q failed: %w", cmd, err)
+	}
+	return stdout.String(), nil
+}
+
+// localTransport reads the files and runs the commands of the machine the program runs on
+type localTransport struct{}
+
+func (localTransport) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (localTransport) ReadDir(ctx context.Context, path string) ([]os.FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		// Entries such as /proc/<pid> may vanish while listing
+		if info, err := entry.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (localTransport) Glob(ctx context.Context, pattern string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return filepath.Glob(pattern)
+}
+
+func (localTransport) StatVFS(ctx context.Context, path string) (*sftp.StatVFS, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return localStatVFS(path)
+}
+
+func (localTransport) Run(ctx context.Context, cmd string) (string, error) {
+	command := exec.CommandContext(ctx, "sh", "-c", cmd)
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	return commandOutput(cmd, command.Run(), &stdout, &stderr)
+}
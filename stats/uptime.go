@@ -0,0 +1,71 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UptimeStats holds the contents of /proc/uptime
+type UptimeStats struct {
+	UptimeSeconds float64
+	IdleSeconds   float64 // summed over all cores, so it can exceed UptimeSeconds
+}
+
+func (r *remoteStatsCollector) getUptime(ctx context.Context) (UptimeStats, error) {
+	content, err := r.readRemoteFile(ctx, "/proc/uptime")
+	if err != nil {
+		return UptimeStats{}, err
+	}
+	return parseUptime(content)
+}
+
+// parseUptime parses a line like "350735.47 234388.90"
+func parseUptime(content string) (UptimeStats, error) {
+	fields := strings.Fields(content)
+	if len(fields) < 2 {
+		return UptimeStats{}, fmt.Errorf("invalid uptime: %q", content)
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return UptimeStats{}, fmt.Errorf("failed to parse uptime: %w", err)
+	}
+	idle, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return UptimeStats{}, fmt.Errorf("failed to parse idle time: %w", err)
+	}
+	return UptimeStats{UptimeSeconds: uptime, IdleSeconds: idle}, nil
+}
+
+// RebootEvent describes a host reboot detected from uptime going backwards between samples
+type RebootEvent struct {
+	PreviousUptimeSeconds float64
+	UptimeSeconds         float64
+}
+
+// checkReboot compares uptime with the previous sample and returns an event when it decreased
+func (r *remoteStatsCollector) checkReboot(uptime UptimeStats) *RebootEvent {
+	previous := r.lastUptime
+	r.lastUptime = uptime.UptimeSeconds
+	if previous == 0 || uptime.UptimeSeconds >= previous {
+		return nil
+	}
+	return &RebootEvent{
+		PreviousUptimeSeconds: previous,
+		UptimeSeconds:         uptime.UptimeSeconds,
+	}
+}
+
+// SetUptimeEvery makes the collector report uptime only on every nth sample (1 reports it on every sample)
+func (r *remoteStatsCollector) SetUptimeEvery(n int) {
+	if n < 1 {
+		n = 1
+	}
+	r.uptimeEvery = n
+}
+
+// GetUptimeEvery returns how often uptime is reported
+func (r *remoteStatsCollector) GetUptimeEvery() int {
+	return r.uptimeEvery
+}
@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UserUsageStat holds the aggregated resource usage of every process owned by a user
+type UserUsageStat struct {
+	User       string
+	UID        int
+	Processes  int
+	CPUPercent float64 // sum of ps %CPU (CPU time over process lifetime), 100% is one full core
+	RSSMB      float64
+}
+
+// SetPerUserStatsEnabled enables or disables aggregating CPU and RSS usage per user (requires an SSH client)
+func (r *remoteStatsCollector) SetPerUserStatsEnabled(enabled bool) {
+	r.perUserEnabled = enabled
+}
+
+// IsPerUserStatsEnabled returns whether usage is aggregated per user
+func (r *remoteStatsCollector) IsPerUserStatsEnabled() bool {
+	return r.perUserEnabled
+}
+
+func (r *remoteStatsCollector) getUserUsage(ctx context.Context) ([]UserUsageStat, error) {
+	output, err := r.runRemoteCommand(ctx, "ps -e -o uid=,user=,pcpu=,rss=")
+	if err != nil {
+		return nil, err
+	}
+	return parseUserUsage(output), nil
+}
+
+// parseUserUsage aggregates lines like " 1000 gal  12.5 204800"
+func parseUserUsage(output string) []UserUsageStat {
+	byUID := make(map[int]*UserUsageStat)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[2], 64)
+		rssKB, _ := strconv.ParseFloat(fields[3], 64)
+
+		user, ok := byUID[uid]
+		if !ok {
+			user = &UserUsageStat{User: fields[1], UID: uid}
+			byUID[uid] = user
+		}
+		user.Processes++
+		user.CPUPercent += cpu
+		user.RSSMB += rssKB / 1024
+	}
+
+	users := make([]UserUsageStat, 0, len(byUID))
+	for _, user := range byUID {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CPUPercent > users[j].CPUPercent
+	})
+	return users
+}
@@ -0,0 +1,50 @@
+package stats
+
+import "context"
+
+// readVmstat reads /proc/vmstat into a map of counter name to value
+func (r *remoteStatsCollector) readVmstat(ctx context.Context) (map[string]uint64, error) {
+	file, err := r.transport.Open(ctx, "/proc/vmstat")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vmstat := make(map[string]uint64, 256)
+	err = scanProcLines(file, func(line []byte) error {
+		name, rest := nextField(line)
+		value, rest := nextField(rest)
+		if extra, _ := nextField(rest); extra != nil {
+			return nil
+		}
+		if v, ok := parseUintBytes(value); ok {
+			vmstat[internName(name)] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vmstat, nil
+}
+
+// VMStatRates holds per-second paging activity from /proc/vmstat over the sampleDelta window
+type VMStatRates struct {
+	SwapInPerSec      float64 // pages swapped in (pswpin)
+	SwapOutPerSec     float64 // pages swapped out (pswpout)
+	PageFaultsPerSec  float64 // all page faults (pgfault)
+	MajorFaultsPerSec float64 // faults that required disk I/O (pgmajfault)
+}
+
+func computeVMStatRates(stat1, stat2 *procSnapshot) VMStatRates {
+	elapsed := stat2.taken.Sub(stat1.taken)
+	rate := func(key string) float64 {
+		return ratePerSecond(counterDelta(stat1.vmstat[key], stat2.vmstat[key]), elapsed)
+	}
+	return VMStatRates{
+		SwapInPerSec:      rate("pswpin"),
+		SwapOutPerSec:     rate("pswpout"),
+		PageFaultsPerSec:  rate("pgfault"),
+		MajorFaultsPerSec: rate("pgmajfault"),
+	}
+}
@@ -0,0 +1,216 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WatchedProcessStat holds the resource usage of a single watched process
+type WatchedProcessStat struct {
+	PID        int
+	Name       string  // command name (comm)
+	Match      string  // the WatchProcess pattern or "pid" for WatchPID
+	CPUPercent float64 // since the first snapshot, 100% is one full core
+	RSSMB      float64
+	VSZMB      float64
+	Threads    int
+	FDCount    int // -1 when /proc/<pid>/fd is not readable by the SSH user
+}
+
+// processWatch is a pattern registered with WatchProcess
+type processWatch struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// WatchProcess reports every process whose command name or command line matches pattern (a regular expression)
+func (r *remoteStatsCollector) WatchProcess(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid process pattern: %w", err)
+	}
+	r.watchedNames = append(r.watchedNames, processWatch{pattern: pattern, re: re})
+	return nil
+}
+
+// WatchPID reports the process with the given PID (while it exists)
+func (r *remoteStatsCollector) WatchPID(pid int) {
+	r.watchedPIDs = append(r.watchedPIDs, pid)
+}
+
+// ClearWatchedProcesses stops reporting every watched process and PID
+func (r *remoteStatsCollector) ClearWatchedProcesses() {
+	r.watchedNames = nil
+	r.watchedPIDs = nil
+}
+
+// listProcesses returns the PID, command name and command line of every process,
+// using a single ps call when an SSH client is available
+func (r *remoteStatsCollector) listProcesses(ctx context.Context) (map[int][2]string, error) {
+	procs := make(map[int][2]string)
+	if r.sshClient != nil {
+		output, err := r.runRemoteCommand(ctx, "ps -e -o pid=,comm=,args=")
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			pid, err := strconv.Atoi(fields[0])
+			if err != nil {
+				continue
+			}
+			procs[pid] = [2]string{fields[1], strings.Join(fields[2:], " ")}
+		}
+		return procs, nil
+	}
+
+	entries, err := r.transport.ReadDir(ctx, "/proc")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		comm, err := r.readRemoteFile(ctx, fmt.Sprintf("/proc/%d/comm", pid))
+		if err != nil {
+			continue
+		}
+		cmdline, _ := r.readRemoteFile(ctx, fmt.Sprintf("/proc/%d/cmdline", pid))
+		procs[pid] = [2]string{strings.TrimSpace(comm), strings.ReplaceAll(strings.TrimRight(cmdline, "\x00"), "\x00", " ")}
+	}
+	return procs, nil
+}
+
+// resolveWatchedProcesses returns the PIDs to report mapped to what they matched
+func (r *remoteStatsCollector) resolveWatchedProcesses(ctx context.Context) (map[int]string, error) {
+	if len(r.watchedNames) == 0 && len(r.watchedPIDs) == 0 {
+		return nil, nil
+	}
+	resolved := make(map[int]string)
+	for _, pid := range r.watchedPIDs {
+		resolved[pid] = "pid"
+	}
+	if len(r.watchedNames) > 0 {
+		procs, err := r.listProcesses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for pid, proc := range procs {
+			for _, watch := range r.watchedNames {
+				if watch.re.MatchString(proc[0]) || watch.re.MatchString(proc[1]) {
+					resolved[pid] = watch.pattern
+					break
+				}
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// readProcessTicks returns utime+stime of a process, in the same USER_HZ units as /proc/stat
+func (r *remoteStatsCollector) readProcessTicks(ctx context.Context, pid int) (uint64, error) {
+	content, err := r.readRemoteFile(ctx, fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	// Fields after the command name, starting with the state (field 3)
+	i := strings.LastIndexByte(content, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	fields := strings.Fields(content[i+1:])
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return utime + stime, nil
+}
+
+// readProcessTicksAll reads the CPU ticks of every PID, skipping processes that exited
+func (r *remoteStatsCollector) readProcessTicksAll(ctx context.Context, pids map[int]string) map[int]uint64 {
+	if len(pids) == 0 {
+		return nil
+	}
+	ticks := make(map[int]uint64, len(pids))
+	for pid := range pids {
+		if t, err := r.readProcessTicks(ctx, pid); err == nil {
+			ticks[pid] = t
+		}
+	}
+	return ticks
+}
+
+func (r *remoteStatsCollector) getWatchedProcessStats(ctx context.Context, pids map[int]string, stat1, stat2 *procSnapshot) []WatchedProcessStat {
+	if len(pids) == 0 {
+		return nil
+	}
+
+	// Process ticks and /proc/stat jiffies share the same unit, so usage is relative to one core's share
+	var cpuTicks float64
+	if total1, ok := stat1.cpu["cpu"]; ok {
+		total2 := stat2.cpu["cpu"]
+		for i := cpuUser; i <= cpuSteal && i < len(total1) && i < len(total2); i++ {
+			cpuTicks += total2[i] - total1[i]
+		}
+	}
+	cores := max(computeRunQueue(stat2, 0).CPUCount, 1)
+
+	var watched []WatchedProcessStat
+	for pid, match := range pids {
+		ticks2, ok := stat2.procTicks[pid]
+		if !ok {
+			continue
+		}
+		status, err := r.readRemoteFile(ctx, fmt.Sprintf("/proc/%d/status", pid))
+		if err != nil {
+			continue
+		}
+		proc := WatchedProcessStat{PID: pid, Match: match, FDCount: -1}
+		for _, line := range strings.Split(status, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch key {
+			case "Name":
+				proc.Name = value
+			case "Threads":
+				proc.Threads, _ = strconv.Atoi(value)
+			case "VmRSS":
+				kb, _ := strconv.ParseFloat(strings.TrimSuffix(value, " kB"), 64)
+				proc.RSSMB = kb / 1024
+			case "VmSize":
+				kb, _ := strconv.ParseFloat(strings.TrimSuffix(value, " kB"), 64)
+				proc.VSZMB = kb / 1024
+			}
+		}
+		if ticks1, ok := stat1.procTicks[pid]; ok && cpuTicks > 0 {
+			proc.CPUPercent = float64(counterDelta(ticks1, ticks2)) / (cpuTicks / float64(cores)) * 100.0
+		}
+		if fds, err := r.countProcessFDs(ctx, pid); err == nil {
+			proc.FDCount = fds
+		}
+		watched = append(watched, proc)
+	}
+	sort.Slice(watched, func(i, j int) bool {
+		return watched[i].PID < watched[j].PID
+	})
+	return watched
+}
@@ -0,0 +1,169 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookRetries = 3
+	defaultWebhookBackoff = 500 * time.Millisecond
+	maxWebhookBackoff     = 30 * time.Second
+)
+
+// WebhookSink POSTs samples as JSON to an HTTP endpoint. With a batch size of 1 (the default) each
+// sample is sent as a single JSON object, otherwise batches are sent as a JSON array of samples.
+type WebhookSink struct {
+	url       string
+	headers   map[string]string
+	batchSize int
+	retries   int
+	backoff   time.Duration
+	client    *http.Client
+	mu        sync.Mutex
+	pending   []*Sample // serialized when sent, so that their staleness is current
+}
+
+// NewWebhookSink creates a sink posting to url
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:       url,
+		headers:   make(map[string]string),
+		batchSize: 1,
+		retries:   defaultWebhookRetries,
+		backoff:   defaultWebhookBackoff,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetHeader sets a header sent with every request, e.g., "Authorization"
+func (w *WebhookSink) SetHeader(key, value string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.headers[key] = value
+}
+
+// SetBearerToken authenticates requests with "Authorization: Bearer <token>"
+func (w *WebhookSink) SetBearerToken(token string) {
+	w.SetHeader("Authorization", "Bearer "+token)
+}
+
+// SetBatchSize sets how many samples are sent per request; 1 sends every sample as its own object
+func (w *WebhookSink) SetBatchSize(size int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if size < 1 {
+		size = 1
+	}
+	w.batchSize = size
+}
+
+// SetRetries sets how many times a failed request is retried and the initial backoff between
+// attempts, which doubles after every attempt (capped at 30s)
+func (w *WebhookSink) SetRetries(retries int, backoff time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.retries = retries
+	w.backoff = backoff
+}
+
+// SetHTTPClient replaces the HTTP client, e.g., to configure TLS or timeouts
+func (w *WebhookSink) SetHTTPClient(client *http.Client) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.client = client
+}
+
+// WriteSample sends the sample, or buffers it until the batch is full
+func (w *WebhookSink) WriteSample(sample *Sample) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, sample)
+	if len(w.pending) >= w.batchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered samples immediately
+func (w *WebhookSink) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *WebhookSink) flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	samples := w.pending
+	count := len(samples)
+	w.pending = nil
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		// Marshaled for every attempt, as a retried sample may have become stale
+		body, err := w.payload(samples)
+		if err != nil {
+			return fmt.Errorf("failed to marshal samples: %w", err)
+		}
+		retryable, err := w.post(body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= w.retries {
+			return fmt.Errorf("failed to post %d samples to webhook after %d attempts: %w", count, attempt+1, err)
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxWebhookBackoff)
+	}
+}
+
+// payload returns the JSON body of samples: an array, or a single object with a batch size of 1
+func (w *WebhookSink) payload(samples []*Sample) ([]byte, error) {
+	if w.batchSize == 1 && len(samples) == 1 {
+		return json.Marshal(SampleToJSON(samples[0]))
+	}
+	docs := make([]map[string]any, len(samples))
+	for i, sample := range samples {
+		docs[i] = SampleToJSON(sample)
+	}
+	return json.Marshal(docs)
+}
+
+// post sends a single request. Network errors, 429 and 5xx responses are retryable, other errors are not.
+func (w *WebhookSink) post(body []byte) (retryable bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// Close sends the remaining buffered samples
+func (w *WebhookSink) Close() error {
+	return w.Flush()
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookFlagsStaleSamplesWhenSent(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var docs []map[string]any
+		if err := json.Unmarshal(body, &docs); err != nil {
+			t.Errorf("invalid body %s: %v", body, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, docs)
+		if len(bodies) == 1 {
+			// The first attempt fails, the retry must see the samples as stale
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	sink.SetBatchSize(2)
+	sink.SetRetries(1, 40*time.Millisecond)
+	sample := func() *Sample {
+		return &Sample{Host: "db1", Timestamp: time.Now(), Stats: &SystemStats{}, StaleAfter: 30 * time.Millisecond}
+	}
+	if err := sink.WriteSample(sample()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.WriteSample(sample()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("got %d requests, want 2", len(bodies))
+	}
+	if _, stale := bodies[0][1]["stale"]; stale {
+		t.Error("a fresh sample was flagged as stale on the first attempt")
+	}
+	for i, doc := range bodies[1] {
+		if doc["stale"] != true {
+			t.Errorf("sample %d of the retry is not flagged as stale: %v", i, doc)
+		}
+	}
+}
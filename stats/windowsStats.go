@@ -0,0 +1,216 @@
+package stats
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// windowsStatsScript prints two raw processor snapshots sampleDelta apart (%d milliseconds), then
+// memory, uptime, process and disk lines. CIM classes are used rather than Get-Counter, whose counter
+// paths are localized.
+const windowsStatsScript = `$ErrorActionPreference = 'Stop'
+function Snap($tag) {
+  foreach ($p in Get-CimInstance Win32_PerfRawData_PerfOS_Processor) {
+    "$tag $($p.Name) $($p.PercentProcessorTime) $($p.PercentUserTime) $($p.PercentPrivilegedTime) $($p.PercentInterruptTime) $($p.Timestamp_Sys100NS)"
+  }
+}
+Snap 'cpu1'
+Start-Sleep -Milliseconds %d
+Snap 'cpu2'
+$os = Get-CimInstance Win32_OperatingSystem
+"mem $($os.TotalVisibleMemorySize) $($os.FreePhysicalMemory) $($os.SizeStoredInPagingFiles) $($os.FreeSpaceInPagingFiles)"
+"uptime $([int64]((Get-Date) - $os.LastBootUpTime).TotalSeconds)"
+$sys = Get-CimInstance Win32_PerfRawData_PerfOS_System
+"procs $($sys.Processes) $($sys.Threads) $($sys.ProcessorQueueLength)"
+foreach ($d in Get-CimInstance Win32_LogicalDisk -Filter 'DriveType=3') {
+  "disk $($d.DeviceID) $($d.Size) $($d.FreeSpace) $($d.FileSystem)"
+}
+`
+
+// WindowsStatsCollector collects the stats of a Windows host by running PowerShell over SSH (as with
+// the OpenSSH server shipped with Windows). It reports CPU usage (total and per core, with user,
+// system, IRQ and idle modes), memory, page file usage as swap, fixed disk usage, uptime and process
+// counts (with the processor queue length as runnable) in the same SystemStats shape as the /proc
+// based collectors; the other fields stay zero.
+type WindowsStatsCollector struct {
+	conn *remoteStatsCollector // holds the SSH connection, commands are run with its exec transport
+}
+
+var _ StatsCollector = (*WindowsStatsCollector)(nil)
+
+// NewWindowsStatsCollectorFromSSH creates a collector running PowerShell over sshClient
+func NewWindowsStatsCollectorFromSSH(sshClient *ssh.Client, sampleDelta time.Duration) *WindowsStatsCollector {
+	return &WindowsStatsCollector{conn: NewRemoteStatsCollectorFromSSHExec(sshClient, sampleDelta)}
+}
+
+// NewWindowsStatsCollectorFromSSHConfig connects to a Windows host, reconnecting when the connection is lost
+func NewWindowsStatsCollectorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, sampleDelta time.Duration, opts ...SSHOption) (*WindowsStatsCollector, error) {
+	conn, err := NewRemoteStatsCollectorFromSSHConfig(serverAddress, config, sampleDelta, append(opts, WithExecMode())...)
+	if err != nil {
+		return nil, err
+	}
+	return &WindowsStatsCollector{conn: conn}, nil
+}
+
+// NewWindowsStatsMonitorFromSSHConfig creates a monitor of a Windows host, named after serverAddress
+func NewWindowsStatsMonitorFromSSHConfig(serverAddress string, config *ssh.ClientConfig, interval time.Duration, sampleDelta time.Duration, logger *log.Logger, opts ...SSHOption) (*RemoteStatsMonitor, error) {
+	collector, err := NewWindowsStatsCollectorFromSSHConfig(serverAddress, config, sampleDelta, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m := NewStatsMonitor(collector, interval, sampleDelta, logger)
+	m.host = serverAddress
+	return m, nil
+}
+
+func (w *WindowsStatsCollector) SetSampleDelta(sampleDelta time.Duration) {
+	w.conn.SetSampleDelta(sampleDelta)
+}
+
+func (w *WindowsStatsCollector) Close() error {
+	return w.conn.Close()
+}
+
+func (w *WindowsStatsCollector) CanReconnect() bool { return w.conn.CanReconnect() }
+func (w *WindowsStatsCollector) IsConnected() bool  { return w.conn.IsConnected() }
+func (w *WindowsStatsCollector) Reconnect() error   { return w.conn.Reconnect() }
+
+func (w *WindowsStatsCollector) Ping(timeout time.Duration) (time.Duration, error) {
+	return w.conn.Ping(timeout)
+}
+
+func (w *WindowsStatsCollector) dropConnection() {
+	w.conn.dropConnection()
+}
+
+// powerShellCommand runs script without quoting issues, whatever the default shell of the SSH server
+func powerShellCommand(script string) string {
+	units := utf16.Encode([]rune(script))
+	encoded := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		encoded = append(encoded, byte(u), byte(u>>8))
+	}
+	return "powershell -NoProfile -NonInteractive -EncodedCommand " + base64.StdEncoding.EncodeToString(encoded)
+}
+
+func (w *WindowsStatsCollector) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	script := fmt.Sprintf(windowsStatsScript, w.conn.GetSampleDelta().Milliseconds())
+	output, err := w.conn.runRemoteCommand(ctx, powerShellCommand(script))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run PowerShell: %w", err)
+	}
+	return parseWindowsStats(output)
+}
+
+// windowsCPUTimes is a raw Win32_PerfRawData_PerfOS_Processor instance, in 100ns units
+type windowsCPUTimes struct {
+	idle, user, privileged, interrupt, timestamp float64
+}
+
+// parseWindowsStats parses the output of windowsStatsScript
+func parseWindowsStats(output string) (*SystemStats, error) {
+	stats := &SystemStats{CollectionMode: CollectionPowerShell}
+	snapshots := map[string]map[string]windowsCPUTimes{"cpu1": {}, "cpu2": {}}
+	sawMemory := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(strings.TrimSuffix(line, "\r"))
+		if len(fields) == 0 {
+			continue
+		}
+		values := make([]float64, len(fields))
+		for i, field := range fields[1:] {
+			values[i+1], _ = strconv.ParseFloat(field, 64)
+		}
+		switch {
+		case (fields[0] == "cpu1" || fields[0] == "cpu2") && len(fields) == 7:
+			if fields[1] == "_Total" {
+				continue
+			}
+			snapshots[fields[0]][fields[1]] = windowsCPUTimes{values[2], values[3], values[4], values[5], values[6]}
+		case fields[0] == "mem" && len(fields) == 5:
+			sawMemory = true
+			stats.TotalMemoryMB = values[1] / 1024
+			stats.UsedMemoryMB = (values[1] - values[2]) / 1024
+			if values[1] > 0 {
+				stats.UsedMemoryPercent = (values[1] - values[2]) / values[1] * 100
+			}
+			stats.SwapTotalMB = values[3] / 1024
+			stats.SwapUsedMB = (values[3] - values[4]) / 1024
+			if values[3] > 0 {
+				stats.SwapUsedPercent = (values[3] - values[4]) / values[3] * 100
+			}
+		case fields[0] == "uptime" && len(fields) == 2:
+			stats.Uptime = &UptimeStats{UptimeSeconds: values[1]}
+		case fields[0] == "procs" && len(fields) == 4:
+			stats.Processes = ProcessStats{Processes: int(values[1]), Threads: int(values[2])}
+			stats.LoadAvg = LoadAvg{RunnableProcs: int(values[3]), TotalProcs: int(values[2])}
+		case fields[0] == "disk" && len(fields) >= 4:
+			disk := DiskUsage{MountPoint: fields[1], Device: fields[1], TotalMB: values[2] / 1024 / 1024, FreeMB: values[3] / 1024 / 1024}
+			if len(fields) > 4 {
+				disk.FSType = fields[4]
+			}
+			disk.UsedMB = disk.TotalMB - disk.FreeMB
+			if disk.TotalMB > 0 {
+				disk.UsedPercent = disk.UsedMB / disk.TotalMB * 100
+			}
+			stats.DiskUsage = append(stats.DiskUsage, disk)
+		}
+	}
+	if !sawMemory || len(snapshots["cpu2"]) == 0 {
+		return nil, fmt.Errorf("unexpected PowerShell output: %q", output)
+	}
+
+	cores := make([]string, 0, len(snapshots["cpu2"]))
+	for core := range snapshots["cpu2"] {
+		if _, ok := snapshots["cpu1"][core]; ok {
+			cores = append(cores, core)
+		}
+	}
+	sort.Slice(cores, func(i, j int) bool {
+		a, _ := strconv.Atoi(cores[i])
+		b, _ := strconv.Atoi(cores[j])
+		return a < b
+	})
+	// The total is the average of the cores, as the raw _Total instance does not share their base
+	for _, core := range cores {
+		before, after := snapshots["cpu1"][core], snapshots["cpu2"][core]
+		elapsed := after.timestamp - before.timestamp
+		if elapsed <= 0 {
+			continue
+		}
+		percent := func(delta float64) float64 {
+			return clampPercent(delta / elapsed * 100)
+		}
+		idle := percent(after.idle - before.idle) // PercentProcessorTime counts the idle time
+		usage := 100 - idle
+		stats.CPUStats = append(stats.CPUStats, CPUStat{Core: "cpu" + core, UsagePct: usage})
+		stats.TotalCPUPercentage += usage
+		stats.CPUModes.User += percent(after.user - before.user)
+		// Privileged time includes the interrupt time, which has its own mode
+		irq := percent(after.interrupt - before.interrupt)
+		stats.CPUModes.System += max(0, percent(after.privileged-before.privileged)-irq)
+		stats.CPUModes.IRQ += irq
+		stats.CPUModes.Idle += idle
+	}
+	if n := float64(len(stats.CPUStats)); n > 0 {
+		stats.TotalCPUPercentage /= n
+		stats.CPUModes.User /= n
+		stats.CPUModes.System /= n
+		stats.CPUModes.IRQ /= n
+		stats.CPUModes.Idle /= n
+	}
+	return stats, nil
+}
+
+func clampPercent(value float64) float64 {
+	return max(0, min(100, value))
+}
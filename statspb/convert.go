@@ -0,0 +1,72 @@
+// Package statspb holds the protobuf messages and gRPC service of remote system stats samples.
+package statspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative stats.proto
+
+import (
+	"github.com/galbarnahum/remoteSystemStatsMonitor/stats"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromSample converts a sample to its protobuf message
+func FromSample(sample *stats.Sample) *Sample {
+	return &Sample{
+		Host:      sample.Host,
+		Timestamp: timestamppb.New(sample.Timestamp),
+		Stats:     FromSystemStats(sample.Stats),
+		Labels:    sample.Labels,
+	}
+}
+
+// FromSystemStats converts stats to its protobuf message
+func FromSystemStats(s *stats.SystemStats) *SystemStats {
+	msg := &SystemStats{
+		TotalMemoryMb:      s.TotalMemoryMB,
+		UsedMemoryMb:       s.UsedMemoryMB,
+		UsedMemoryPercent:  s.UsedMemoryPercent,
+		SwapTotalMb:        s.SwapTotalMB,
+		SwapUsedMb:         s.SwapUsedMB,
+		SwapUsedPercent:    s.SwapUsedPercent,
+		TotalCpuPercentage: s.TotalCPUPercentage,
+		LoadAvg: &LoadAvg{
+			Load1:         s.LoadAvg.Load1,
+			Load5:         s.LoadAvg.Load5,
+			Load15:        s.LoadAvg.Load15,
+			RunnableProcs: int32(s.LoadAvg.RunnableProcs),
+			TotalProcs:    int32(s.LoadAvg.TotalProcs),
+		},
+	}
+	for _, cpu := range s.CPUStats {
+		msg.CpuStats = append(msg.CpuStats, &CPUStat{Core: cpu.Core, UsagePercent: cpu.UsagePct})
+	}
+	for _, disk := range s.DiskUsage {
+		msg.DiskUsage = append(msg.DiskUsage, &DiskUsage{
+			MountPoint:  disk.MountPoint,
+			Device:      disk.Device,
+			FsType:      disk.FSType,
+			TotalMb:     disk.TotalMB,
+			UsedMb:      disk.UsedMB,
+			FreeMb:      disk.FreeMB,
+			UsedPercent: disk.UsedPercent,
+		})
+	}
+	for _, iface := range s.NetInterfaces {
+		msg.NetInterfaces = append(msg.NetInterfaces, &NetInterfaceStat{
+			Interface:       iface.Interface,
+			RxBytesPerSec:   iface.RxBytesPerSec,
+			TxBytesPerSec:   iface.TxBytesPerSec,
+			RxPacketsPerSec: iface.RxPacketsPerSec,
+			TxPacketsPerSec: iface.TxPacketsPerSec,
+			RxMbps:          iface.RxMbps,
+			TxMbps:          iface.TxMbps,
+			RxErrors:        iface.RxErrors,
+			TxErrors:        iface.TxErrors,
+			RxDropped:       iface.RxDropped,
+			TxDropped:       iface.TxDropped,
+		})
+	}
+	for _, point := range stats.SystemStatsToMetrics(s) {
+		msg.Metrics = append(msg.Metrics, &Metric{Name: point.Name, Labels: point.Labels, Value: point.Value})
+	}
+	return msg
+}
@@ -0,0 +1,159 @@
+package statspb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/galbarnahum/remoteSystemStatsMonitor/stats"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+// LogLine is a logLineFunc (see RemoteStatsMonitor.SetLogLineFunc) encoding stats as a binary
+// SystemStats message. Protobuf is not self-delimiting and may contain newlines, so use it where
+// each line is a separate message (e.g., a message bus); for files use DelimitedWriter.
+func LogLine(s *stats.SystemStats) ([]byte, error) {
+	return proto.Marshal(FromSystemStats(s))
+}
+
+// MarshalSample encodes a sample as a binary Sample message
+func MarshalSample(sample *stats.Sample) ([]byte, error) {
+	return proto.Marshal(FromSample(sample))
+}
+
+// UnmarshalSystemStats decodes a message produced by LogLine
+func UnmarshalSystemStats(data []byte) (*stats.SystemStats, error) {
+	var msg SystemStats
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode system stats: %w", err)
+	}
+	return ToSystemStats(&msg), nil
+}
+
+// UnmarshalSample decodes a message produced by MarshalSample
+func UnmarshalSample(data []byte) (*stats.Sample, error) {
+	var msg Sample
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode sample: %w", err)
+	}
+	return ToSample(&msg), nil
+}
+
+// ToSample converts a Sample message back to a sample
+func ToSample(msg *Sample) *stats.Sample {
+	sample := &stats.Sample{
+		Host:      msg.GetHost(),
+		Timestamp: msg.GetTimestamp().AsTime(),
+		Stats:     ToSystemStats(msg.GetStats()),
+		Labels:    msg.GetLabels(),
+	}
+	sample.Stats.Labels = sample.Labels
+	return sample
+}
+
+// ToSystemStats converts a SystemStats message back to stats. Only the typed fields are restored;
+// the optional metric groups are available as flattened values in msg.Metrics.
+func ToSystemStats(msg *SystemStats) *stats.SystemStats {
+	s := &stats.SystemStats{
+		TotalMemoryMB:      msg.GetTotalMemoryMb(),
+		UsedMemoryMB:       msg.GetUsedMemoryMb(),
+		UsedMemoryPercent:  msg.GetUsedMemoryPercent(),
+		SwapTotalMB:        msg.GetSwapTotalMb(),
+		SwapUsedMB:         msg.GetSwapUsedMb(),
+		SwapUsedPercent:    msg.GetSwapUsedPercent(),
+		TotalCPUPercentage: msg.GetTotalCpuPercentage(),
+		LoadAvg: stats.LoadAvg{
+			Load1:         msg.GetLoadAvg().GetLoad1(),
+			Load5:         msg.GetLoadAvg().GetLoad5(),
+			Load15:        msg.GetLoadAvg().GetLoad15(),
+			RunnableProcs: int(msg.GetLoadAvg().GetRunnableProcs()),
+			TotalProcs:    int(msg.GetLoadAvg().GetTotalProcs()),
+		},
+	}
+	for _, cpu := range msg.GetCpuStats() {
+		s.CPUStats = append(s.CPUStats, stats.CPUStat{Core: cpu.GetCore(), UsagePct: cpu.GetUsagePercent()})
+	}
+	for _, disk := range msg.GetDiskUsage() {
+		s.DiskUsage = append(s.DiskUsage, stats.DiskUsage{
+			MountPoint:  disk.GetMountPoint(),
+			Device:      disk.GetDevice(),
+			FSType:      disk.GetFsType(),
+			TotalMB:     disk.GetTotalMb(),
+			UsedMB:      disk.GetUsedMb(),
+			FreeMB:      disk.GetFreeMb(),
+			UsedPercent: disk.GetUsedPercent(),
+		})
+	}
+	for _, iface := range msg.GetNetInterfaces() {
+		s.NetInterfaces = append(s.NetInterfaces, stats.NetInterfaceStat{
+			Interface:       iface.GetInterface(),
+			RxBytesPerSec:   iface.GetRxBytesPerSec(),
+			TxBytesPerSec:   iface.GetTxBytesPerSec(),
+			RxPacketsPerSec: iface.GetRxPacketsPerSec(),
+			TxPacketsPerSec: iface.GetTxPacketsPerSec(),
+			RxMbps:          iface.GetRxMbps(),
+			TxMbps:          iface.GetTxMbps(),
+			RxErrors:        iface.GetRxErrors(),
+			TxErrors:        iface.GetTxErrors(),
+			RxDropped:       iface.GetRxDropped(),
+			TxDropped:       iface.GetTxDropped(),
+		})
+	}
+	return s
+}
+
+// DelimitedWriter is a sink writing samples as varint length-prefixed Sample messages,
+// a compact framing suitable for files and streams that DelimitedReader reads back
+type DelimitedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ stats.Sink = (*DelimitedWriter)(nil)
+
+// NewDelimitedWriter creates a DelimitedWriter. If w is an io.Closer, Close closes it.
+func NewDelimitedWriter(w io.Writer) *DelimitedWriter {
+	return &DelimitedWriter{w: w}
+}
+
+// WriteSample writes a single length-prefixed sample
+func (d *DelimitedWriter) WriteSample(sample *stats.Sample) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := protodelim.MarshalTo(d.w, FromSample(sample)); err != nil {
+		return fmt.Errorf("failed to write sample: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it is an io.Closer
+func (d *DelimitedWriter) Close() error {
+	if closer, ok := d.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// DelimitedReader reads the samples written by a DelimitedWriter
+type DelimitedReader struct {
+	r *bufio.Reader
+}
+
+// NewDelimitedReader creates a reader of length-prefixed samples
+func NewDelimitedReader(r io.Reader) *DelimitedReader {
+	return &DelimitedReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next sample, or io.EOF once the input is exhausted
+func (d *DelimitedReader) Next() (*stats.Sample, error) {
+	var msg Sample
+	if err := protodelim.UnmarshalFrom(d.r, &msg); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read sample: %w", err)
+	}
+	return ToSample(&msg), nil
+}